@@ -0,0 +1,232 @@
+// Package accountstest generates random accounts for property based tests.
+// Every generated account passes accounts.AccountData.Validate and survives a JSON round trip unchanged.
+// Generators take a *rand.Rand so that a failing case can be reproduced from its seed.
+package accountstest
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/google/uuid"
+)
+
+const (
+	upper         = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits        = "0123456789"
+	upperAndDigit = upper + digits
+
+	maxNames            = 4
+	maxAlternativeNames = 3
+	maxNameBytes        = 140
+	maxTextBytes        = 64
+)
+
+// textRunes are picked from to build free text, covering plain ASCII, JSON escapes and multi-byte characters
+var textRunes = []rune("abcdefghijklmnopqrstuvwxyz ABCDEFGHIJKLMNOPQRSTUVWXYZ 0123456789 -'.,&/\\\"<>\n\téüßøçñ日本語😀")
+
+var (
+	classifications = []accounts.AccountClassification{
+		accounts.AccountClassificationPersonal,
+		accounts.AccountClassificationBusiness,
+	}
+	nameMatchingStatuses = []accounts.AccountNameMatchingStatus{
+		accounts.AccountNameMatchingStatusSupported,
+		accounts.AccountNameMatchingStatusSwitched,
+		accounts.AccountNameMatchingStatusOptedOut,
+		accounts.AccountNameMatchingStatusNotsupported,
+	}
+	statuses = []accounts.AccountStatus{
+		accounts.AccountStatusConfirmed,
+		accounts.AccountStatusPending,
+		accounts.AccountStatusCancelled,
+		accounts.AccountStatusFailed,
+	}
+)
+
+// RandomAccountData returns a random valid account. Each optional field is set about half the time.
+func RandomAccountData(r *rand.Rand) accounts.AccountData {
+	account := accounts.AccountData{
+		ID:             RandomUUID(r),
+		OrganisationID: RandomUUID(r),
+		Type:           "accounts",
+		Attributes:     randomAttributes(r),
+	}
+
+	if r.Intn(2) == 0 {
+		version := r.Int63n(1000)
+		account.Version = &version
+	}
+
+	if r.Intn(2) == 0 {
+		createdOn := RandomTime(r)
+		account.CreatedOn = &createdOn
+
+		modifiedOn := createdOn.Add(time.Duration(r.Int63n(int64(365 * 24 * time.Hour))))
+		account.ModifiedOn = &modifiedOn
+	}
+
+	return account
+}
+
+// RandomResponse returns a random API response holding a valid account
+func RandomResponse(r *rand.Rand) accounts.Response {
+	account := RandomAccountData(r)
+	resp := accounts.Response{Data: &account}
+	if r.Intn(2) == 0 {
+		resp.Links = &accounts.Links{Self: "/v1/organisation/accounts/" + account.ID}
+	}
+
+	return resp
+}
+
+// RandomUUID returns a random version 4 UUID
+func RandomUUID(r *rand.Rand) string {
+	id, err := uuid.NewRandomFromReader(r)
+	if err != nil {
+		// a *rand.Rand never fails to read
+		panic(err)
+	}
+
+	return id.String()
+}
+
+// RandomTime returns a random UTC time between 2000 and 2030 with nanosecond precision
+func RandomTime(r *rand.Rand) time.Time {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	return start.Add(time.Duration(r.Int63n(int64(end.Sub(start)))))
+}
+
+func randomAttributes(r *rand.Rand) *accounts.AccountAttributes {
+	country := randomString(r, upper, 2, 2)
+	attrs := &accounts.AccountAttributes{
+		AccountClassification: classifications[r.Intn(len(classifications))],
+		AccountNumber:         randomString(r, upperAndDigit, 0, 64),
+		BankIDCode:            randomString(r, upper, 0, 16),
+		Country:               &country,
+		Name:                  randomTexts(r, 1, maxNames, 1, maxNameBytes),
+		NameMatchingStatus:    nameMatchingStatuses[r.Intn(len(nameMatchingStatuses))],
+		AccountMatchingOptOut: r.Intn(2) == 0,
+	}
+
+	// free text fields are left empty about half the time
+	for _, field := range []*string{
+		&attrs.AcceptanceQualifier,
+		&attrs.BankID,
+		&attrs.CustomerID,
+		&attrs.ReferenceMask,
+		&attrs.SecondaryIdentification,
+		&attrs.StatusReason,
+		&attrs.ValidationType,
+		&attrs.BankAccountName,
+		&attrs.FirstName,
+		&attrs.Title,
+		&attrs.ProcessingService,
+		&attrs.UserDefinedInformation,
+	} {
+		if r.Intn(2) == 0 {
+			*field = RandomText(r, 0, maxTextBytes)
+		}
+	}
+
+	if r.Intn(2) == 0 {
+		attrs.AlternativeNames = randomTexts(r, 1, maxAlternativeNames, 0, maxNameBytes)
+	}
+
+	if r.Intn(2) == 0 {
+		attrs.AlternativeBankAccountNames = randomTexts(r, 1, maxAlternativeNames, 0, maxNameBytes)
+	}
+
+	if r.Intn(2) == 0 {
+		attrs.BaseCurrency = randomString(r, upper, 3, 3)
+	}
+
+	if r.Intn(2) == 0 {
+		// BICs are 8 or 11 characters: bank and country code, location code and an optional branch code
+		attrs.Bic = randomString(r, upper, 6, 6) + randomString(r, upperAndDigit, 2, 2)
+		if r.Intn(2) == 0 {
+			attrs.Bic += randomString(r, upperAndDigit, 3, 3)
+		}
+	}
+
+	if r.Intn(2) == 0 {
+		attrs.Iban = country + randomString(r, digits, 2, 2) + randomString(r, upperAndDigit, 0, 30)
+	}
+
+	if r.Intn(2) == 0 {
+		status := statuses[r.Intn(len(statuses))]
+		attrs.Status = &status
+	}
+
+	attrs.JointAccount = randomBoolPtr(r)
+	attrs.Switched = randomBoolPtr(r)
+
+	if r.Intn(2) == 0 {
+		n := 1 + r.Intn(5)
+		for idx := 0; idx < n; idx++ {
+			attrs.UserDefinedData = append(attrs.UserDefinedData, accounts.UserDefinedData{
+				Key:   RandomText(r, 1, maxTextBytes),
+				Value: RandomText(r, 0, maxTextBytes),
+			})
+		}
+	}
+
+	return attrs
+}
+
+// RandomText returns valid UTF-8 text of between minBytes and maxBytes bytes, mixing ASCII,
+// characters JSON escapes and multi-byte characters
+func RandomText(r *rand.Rand, minBytes, maxBytes int) string {
+	target := minBytes + r.Intn(maxBytes-minBytes+1)
+
+	var b strings.Builder
+	for b.Len() < target {
+		c := textRunes[r.Intn(len(textRunes))]
+		if b.Len()+utf8.RuneLen(c) > maxBytes {
+			c = 'a'
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// randomTexts returns between minItems and maxItems texts of between minBytes and maxBytes bytes
+func randomTexts(r *rand.Rand, minItems, maxItems, minBytes, maxBytes int) []string {
+	n := minItems + r.Intn(maxItems-minItems+1)
+	texts := make([]string, 0, n)
+	for idx := 0; idx < n; idx++ {
+		texts = append(texts, RandomText(r, minBytes, maxBytes))
+	}
+
+	return texts
+}
+
+// randomString returns between minLen and maxLen characters picked from alphabet
+func randomString(r *rand.Rand, alphabet string, minLen, maxLen int) string {
+	n := minLen + r.Intn(maxLen-minLen+1)
+	b := make([]byte, n)
+	for idx := range b {
+		b[idx] = alphabet[r.Intn(len(alphabet))]
+	}
+
+	return string(b)
+}
+
+// randomBoolPtr returns nil, true or false
+func randomBoolPtr(r *rand.Rand) *bool {
+	switch r.Intn(3) {
+	case 0:
+		return nil
+	case 1:
+		b := true
+		return &b
+	default:
+		b := false
+		return &b
+	}
+}
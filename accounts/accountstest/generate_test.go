@@ -0,0 +1,76 @@
+package accountstest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomAccountData_validAndRoundTrips(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	seenStatuses := map[accounts.AccountStatus]bool{}
+	seenClassifications := map[accounts.AccountClassification]bool{}
+	seenNameMatchingStatuses := map[accounts.AccountNameMatchingStatus]bool{}
+	for idx := 0; idx < 1000; idx++ {
+		account := RandomAccountData(r)
+		assert.NoError(t, account.Validate())
+
+		raw, err := json.Marshal(account)
+		assert.NoError(t, err)
+
+		var decoded accounts.AccountData
+		assert.NoError(t, json.Unmarshal(raw, &decoded))
+		if !assert.Equal(t, account, decoded) {
+			return
+		}
+
+		if account.Attributes.Status != nil {
+			seenStatuses[*account.Attributes.Status] = true
+		}
+
+		seenClassifications[account.Attributes.AccountClassification] = true
+		seenNameMatchingStatuses[account.Attributes.NameMatchingStatus] = true
+	}
+
+	// every enum value is generated
+	assert.Len(t, seenStatuses, len(statuses))
+	assert.Len(t, seenClassifications, len(classifications))
+	assert.Len(t, seenNameMatchingStatuses, len(nameMatchingStatuses))
+}
+
+func TestRandomAccountData_sameSeedSameAccount(t *testing.T) {
+	first := RandomAccountData(rand.New(rand.NewSource(42)))
+	second := RandomAccountData(rand.New(rand.NewSource(42)))
+	assert.Equal(t, first, second)
+
+	assert.NotEqual(t, first, RandomAccountData(rand.New(rand.NewSource(43))))
+}
+
+func TestRandomResponse_roundTrips(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for idx := 0; idx < 100; idx++ {
+		resp := RandomResponse(r)
+
+		raw, err := json.Marshal(resp)
+		assert.NoError(t, err)
+
+		var decoded accounts.Response
+		assert.NoError(t, json.Unmarshal(raw, &decoded))
+		assert.Equal(t, resp, decoded)
+	}
+}
+
+func TestRandomText(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for idx := 0; idx < 1000; idx++ {
+		text := RandomText(r, 1, 5)
+		assert.True(t, utf8.ValidString(text))
+		assert.GreaterOrEqual(t, len(text), 1)
+		assert.LessOrEqual(t, len(text), 5)
+	}
+}
@@ -0,0 +1,68 @@
+package accounts
+
+// Clone returns a deep copy of r so that the copy can be modified without affecting r
+func (r *Response) Clone() *Response {
+	if r == nil {
+		return nil
+	}
+
+	clone := &Response{}
+	if r.Data != nil {
+		data := r.Data.Clone()
+		clone.Data = &data
+	}
+
+	if r.Links != nil {
+		links := *r.Links
+		clone.Links = &links
+	}
+
+	return clone
+}
+
+// Clone returns a deep copy of a so that the copy can be modified without affecting a
+func (a AccountData) Clone() AccountData {
+	clone := a
+	clone.Version = clonePtr(a.Version)
+	clone.CreatedOn = clonePtr(a.CreatedOn)
+	clone.ModifiedOn = clonePtr(a.ModifiedOn)
+
+	if a.Attributes != nil {
+		attributes := a.Attributes.Clone()
+		clone.Attributes = &attributes
+	}
+
+	return clone
+}
+
+// Clone returns a deep copy of aa so that the copy can be modified without affecting aa
+func (aa AccountAttributes) Clone() AccountAttributes {
+	clone := aa
+	clone.AlternativeNames = cloneSlice(aa.AlternativeNames)
+	clone.Country = clonePtr(aa.Country)
+	clone.JointAccount = clonePtr(aa.JointAccount)
+	clone.Name = cloneSlice(aa.Name)
+	clone.Status = clonePtr(aa.Status)
+	clone.UserDefinedData = cloneSlice(aa.UserDefinedData)
+	clone.AlternativeBankAccountNames = cloneSlice(aa.AlternativeBankAccountNames)
+	clone.Switched = clonePtr(aa.Switched)
+
+	return clone
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+
+	v := *p
+	return &v
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+
+	return append(make([]T, 0, len(s)), s...)
+}
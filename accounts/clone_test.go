@@ -0,0 +1,59 @@
+package accounts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseClone_isDeepCopy(t *testing.T) {
+	country := "GB"
+	version := int64(1)
+	joint := false
+	status := AccountStatusConfirmed
+	createdOn := time.Date(2017, 07, 23, 0, 0, 0, 0, time.UTC)
+
+	original := &Response{
+		Data: &AccountData{
+			ID:        "1dfaf917-c6d6-4e18-b7e7-972e66492976",
+			Version:   &version,
+			CreatedOn: &createdOn,
+			Attributes: &AccountAttributes{
+				Country:         &country,
+				JointAccount:    &joint,
+				Status:          &status,
+				Name:            []string{"Jane Doe"},
+				UserDefinedData: []UserDefinedData{{Key: "k", Value: "v"}},
+			},
+		},
+		Links: &Links{Self: "/v1/organisation/accounts/1dfaf917-c6d6-4e18-b7e7-972e66492976"},
+	}
+
+	clone := original.Clone()
+	assert.Equal(t, original, clone)
+
+	*clone.Data.Version = 2
+	*clone.Data.CreatedOn = time.Time{}
+	*clone.Data.Attributes.Country = "FR"
+	*clone.Data.Attributes.JointAccount = true
+	*clone.Data.Attributes.Status = AccountStatusFailed
+	clone.Data.Attributes.Name[0] = "John Doe"
+	clone.Data.Attributes.UserDefinedData[0].Value = "changed"
+	clone.Links.Self = "changed"
+
+	assert.Equal(t, int64(1), *original.Data.Version)
+	assert.Equal(t, createdOn, *original.Data.CreatedOn)
+	assert.Equal(t, "GB", *original.Data.Attributes.Country)
+	assert.False(t, *original.Data.Attributes.JointAccount)
+	assert.Equal(t, AccountStatusConfirmed, *original.Data.Attributes.Status)
+	assert.Equal(t, []string{"Jane Doe"}, original.Data.Attributes.Name)
+	assert.Equal(t, "v", original.Data.Attributes.UserDefinedData[0].Value)
+	assert.Equal(t, "/v1/organisation/accounts/1dfaf917-c6d6-4e18-b7e7-972e66492976", original.Links.Self)
+}
+
+func TestResponseClone_nil(t *testing.T) {
+	var r *Response
+	assert.Nil(t, r.Clone())
+	assert.Equal(t, &Response{}, (&Response{}).Clone())
+}
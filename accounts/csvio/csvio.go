@@ -0,0 +1,82 @@
+// Package csvio reads accounts from and writes accounts to CSV files.
+//
+// Each column of a file holds one account field. Fields are named after their JSON names in the API, e.g.
+// "organisation_id", "country" or "bank_id", see FieldNames for the full list. Files whose headers are the
+// field names need no configuration, otherwise Config.Columns maps headers such as "Sort Code" to fields.
+//
+// Empty cells leave their field unset. Other cells are encoded as follows:
+//
+//   - version is a whole number and joint_account, account_matching_opt_out and switched are true or false
+//   - account_classification, name_matching_status and status use the same values as the API e.g. Personal or confirmed
+//   - created_on and modified_on are RFC 3339 timestamps
+//   - name, alternative_names and alternative_bank_account_names hold several values separated by |,
+//     e.g. "Jane Smith|Smith Holdings". A | or \ within a value is escaped with a \.
+//   - user_defined_data holds key=value pairs separated by |, e.g. "tier=gold|region=north". Keys cannot
+//     contain =, values may. A | or \ within a pair is escaped with a \.
+package csvio
+
+import (
+	"fmt"
+)
+
+// Column maps a CSV header to the account field held in that column
+type Column struct {
+	Header string
+	Field  string
+}
+
+// Config describes the layout of a CSV file
+type Config struct {
+	// Columns maps headers to fields. When reading, headers that are not in Columns must be field names.
+	// When writing, Columns sets the columns and their order, every field is written in the default order if it is empty.
+	Columns []Column
+	// IgnoreUnknownColumns makes the Reader skip columns whose header is neither in Columns nor a field name
+	IgnoreUnknownColumns bool
+	// OrganisationID is given to accounts read without an organisation_id
+	OrganisationID string
+	// GenerateIDs gives a random ID to accounts read without an id
+	GenerateIDs bool
+}
+
+// fieldFor returns the field held in the column with header, if there is one
+func (cfg Config) fieldFor(header string) (field, bool, error) {
+	name := header
+	for _, col := range cfg.Columns {
+		if col.Header == header {
+			name = col.Field
+			break
+		}
+	}
+
+	f, ok := fieldsByName[name]
+	if ok {
+		return f, true, nil
+	}
+
+	if cfg.IgnoreUnknownColumns {
+		return field{}, false, nil
+	}
+
+	if name != header {
+		return field{}, false, fmt.Errorf("column %q is mapped to unknown field %q", header, name)
+	}
+
+	return field{}, false, fmt.Errorf("unknown column %q", header)
+}
+
+// RowError is a problem with a single row of a CSV file. Reading can carry on with the next row.
+type RowError struct {
+	// Line is the line of the file the row starts on, counting from 1
+	Line int
+	// Err is an *accounts.ValidationError for invalid cells and accounts, otherwise the CSV parse error
+	Err error
+}
+
+func (rerr *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", rerr.Line, rerr.Err)
+}
+
+// Unwrap returns the error underlying rerr so that it can be inspected with errors.Is and errors.As
+func (rerr *RowError) Unwrap() error {
+	return rerr.Err
+}
@@ -0,0 +1,240 @@
+package csvio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// fieldKind is how the value of a field is written in a CSV cell
+type fieldKind int
+
+const (
+	// kindString cells hold the value as is
+	kindString fieldKind = iota
+	// kindInt cells hold a base 10 integer
+	kindInt
+	// kindBool cells hold true or false
+	kindBool
+	// kindList cells hold values separated by |
+	kindList
+	// kindKeyValues cells hold key=value pairs separated by |
+	kindKeyValues
+)
+
+const (
+	listSeparator     = '|'
+	keyValueSeparator = '='
+	escapeChar        = '\\'
+)
+
+// field is an account field that can be mapped to a CSV column
+type field struct {
+	name string
+	kind fieldKind
+	// attribute is set for fields of AccountAttributes as opposed to AccountData
+	attribute bool
+	// check validates the value of string fields with a restricted format, e.g. enums and timestamps
+	check func(value string) error
+}
+
+// fields are every account field that can be read from or written to CSV, in the default column order.
+// Each is named after its JSON field in the API.
+var fields = []field{
+	{name: "id"},
+	{name: "organisation_id"},
+	{name: "type"},
+	{name: "version", kind: kindInt},
+	{name: "created_on", check: checkTime},
+	{name: "modified_on", check: checkTime},
+	{name: "country", attribute: true},
+	{name: "base_currency", attribute: true},
+	{name: "bank_id", attribute: true},
+	{name: "bank_id_code", attribute: true},
+	{name: "bic", attribute: true},
+	{name: "account_number", attribute: true},
+	{name: "iban", attribute: true},
+	{name: "customer_id", attribute: true},
+	{name: "name", kind: kindList, attribute: true},
+	{name: "alternative_names", kind: kindList, attribute: true},
+	{name: "account_classification", attribute: true, check: checkEnum(accounts.NewAccountClassification)},
+	{name: "joint_account", kind: kindBool, attribute: true},
+	{name: "secondary_identification", attribute: true},
+	{name: "name_matching_status", attribute: true, check: checkEnum(accounts.NewAccountNameMatchingStatus)},
+	{name: "status", attribute: true, check: checkEnum(accounts.NewAccountStatus)},
+	{name: "status_reason", attribute: true},
+	{name: "acceptance_qualifier", attribute: true},
+	{name: "reference_mask", attribute: true},
+	{name: "validation_type", attribute: true},
+	{name: "user_defined_data", kind: kindKeyValues, attribute: true},
+	{name: "alternative_bank_account_names", kind: kindList, attribute: true},
+	{name: "bank_account_name", attribute: true},
+	{name: "first_name", attribute: true},
+	{name: "title", attribute: true},
+	{name: "processing_service", attribute: true},
+	{name: "user_defined_information", attribute: true},
+	{name: "account_matching_opt_out", kind: kindBool, attribute: true},
+	{name: "switched", kind: kindBool, attribute: true},
+}
+
+// fieldsByName indexes fields by name
+var fieldsByName = func() map[string]field {
+	byName := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byName[f.name] = f
+	}
+
+	return byName
+}()
+
+// FieldNames returns the names of every field that can be mapped to a column, in the default column order
+func FieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+
+	return names
+}
+
+// path returns the JSON path of f within an account
+func (f field) path() string {
+	if f.attribute {
+		return "attributes." + f.name
+	}
+
+	return f.name
+}
+
+// checkEnum returns a check that value can be parsed with parse
+func checkEnum[T any](parse func(string) (T, error)) func(string) error {
+	return func(value string) error {
+		_, err := parse(value)
+		return err
+	}
+}
+
+// checkTime checks that value is a timestamp in the format used by the API
+func checkTime(value string) error {
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return fmt.Errorf("must be an RFC 3339 timestamp")
+	}
+
+	return nil
+}
+
+// decode converts a non-empty cell into the JSON value of f
+func (f field) decode(cell string) (interface{}, error) {
+	switch f.kind {
+	case kindInt:
+		n, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a whole number")
+		}
+
+		return n, nil
+	case kindBool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+
+		return b, nil
+	case kindList:
+		return splitList(cell), nil
+	case kindKeyValues:
+		var pairs []accounts.UserDefinedData
+		for _, item := range splitList(cell) {
+			key, value, ok := strings.Cut(item, string(keyValueSeparator))
+			if !ok || key == "" {
+				return nil, fmt.Errorf("must be key=value pairs separated by %c", listSeparator)
+			}
+
+			pairs = append(pairs, accounts.UserDefinedData{Key: key, Value: value})
+		}
+
+		return pairs, nil
+	default:
+		if f.check != nil {
+			if err := f.check(cell); err != nil {
+				return nil, err
+			}
+		}
+
+		return cell, nil
+	}
+}
+
+// encode converts the JSON value of f, as decoded into an interface{}, into a cell
+func (f field) encode(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if pair, ok := item.(map[string]interface{}); ok {
+				items = append(items, fmt.Sprintf("%v%c%v", pair["key"], keyValueSeparator, pair["value"]))
+				continue
+			}
+
+			items = append(items, fmt.Sprint(item))
+		}
+
+		return joinList(items)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// joinList joins items with |, escaping any | or \ within them with a \
+func joinList(items []string) string {
+	var b strings.Builder
+	for idx, item := range items {
+		if idx > 0 {
+			b.WriteRune(listSeparator)
+		}
+
+		for _, r := range item {
+			if r == listSeparator || r == escapeChar {
+				b.WriteRune(escapeChar)
+			}
+
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// splitList is the inverse of joinList
+func splitList(cell string) []string {
+	var items []string
+	var item strings.Builder
+	escaped := false
+	for _, r := range cell {
+		switch {
+		case escaped:
+			item.WriteRune(r)
+			escaped = false
+		case r == escapeChar:
+			escaped = true
+		case r == listSeparator:
+			items = append(items, item.String())
+			item.Reset()
+		default:
+			item.WriteRune(r)
+		}
+	}
+
+	return append(items, item.String())
+}
@@ -0,0 +1,56 @@
+package csvio
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinAndSplitList(t *testing.T) {
+	testCases := []struct {
+		name  string
+		items []string
+		cell  string
+	}{
+		{name: "single", items: []string{"Jane Smith"}, cell: "Jane Smith"},
+		{name: "several", items: []string{"Jane Smith", "Smith Holdings"}, cell: "Jane Smith|Smith Holdings"},
+		{name: "escaped separator", items: []string{"A|B", "C"}, cell: `A\|B|C`},
+		{name: "escaped escape", items: []string{`A\`, "B"}, cell: `A\\|B`},
+		{name: "empty item", items: []string{"A", "", "B"}, cell: "A||B"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.cell, joinList(tc.items))
+			assert.Equal(t, tc.items, splitList(tc.cell))
+		})
+	}
+}
+
+func TestField_decodeErrors(t *testing.T) {
+	testCases := []struct {
+		field  string
+		cell   string
+		errMsg string
+	}{
+		{field: "version", cell: "one", errMsg: "must be a whole number"},
+		{field: "joint_account", cell: "yes", errMsg: "must be true or false"},
+		{field: "user_defined_data", cell: "a=1|b", errMsg: "must be key=value pairs separated by |"},
+		{field: "status", cell: "open", errMsg: "invalid account status: open"},
+		{field: "created_on", cell: "yesterday", errMsg: "must be an RFC 3339 timestamp"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.field), func(t *testing.T) {
+			_, err := fieldsByName[tc.field].decode(tc.cell)
+			assert.EqualError(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestFieldNames_coverEveryField(t *testing.T) {
+	names := FieldNames()
+	assert.Len(t, names, len(fieldsByName))
+	assert.Equal(t, "id", names[0])
+}
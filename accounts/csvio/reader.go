@@ -0,0 +1,186 @@
+package csvio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/google/uuid"
+)
+
+// Reader reads accounts from a CSV file whose first row is a header
+type Reader struct {
+	cfg Config
+	csv *csv.Reader
+
+	// columns holds the field of each column, nil for ignored columns. It is set once the header has been read.
+	columns []*field
+	headers []string
+}
+
+// NewReader returns a Reader that reads accounts from r laid out as described by cfg
+func NewReader(r io.Reader, cfg Config) *Reader {
+	csvReader := csv.NewReader(r)
+	csvReader.TrimLeadingSpace = true
+
+	return &Reader{cfg: cfg, csv: csvReader}
+}
+
+// Read returns the account in the next row, or io.EOF once there are no more rows.
+// A *RowError is returned for a row that is malformed or holds an invalid account, in which case
+// Read can be called again to carry on with the next row. Any other error is fatal.
+func (r *Reader) Read() (accounts.AccountData, error) {
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			return accounts.AccountData{}, err
+		}
+	}
+
+	record, err := r.csv.Read()
+	if err != nil {
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			return accounts.AccountData{}, &RowError{Line: perr.StartLine, Err: perr.Err}
+		}
+
+		return accounts.AccountData{}, err
+	}
+
+	line, _ := r.csv.FieldPos(0)
+	account, err := r.decodeRecord(record)
+	if err != nil {
+		return accounts.AccountData{}, &RowError{Line: line, Err: err}
+	}
+
+	return account, nil
+}
+
+// ReadAll reads every row, returning the valid accounts and an error for each invalid row.
+// The returned error is only non-nil if the file could not be read at all.
+func (r *Reader) ReadAll() ([]accounts.AccountData, []*RowError, error) {
+	var accts []accounts.AccountData
+	var rowErrs []*RowError
+	for {
+		account, err := r.Read()
+		if err == io.EOF {
+			return accts, rowErrs, nil
+		}
+
+		var rerr *RowError
+		if errors.As(err, &rerr) {
+			rowErrs = append(rowErrs, rerr)
+			continue
+		}
+
+		if err != nil {
+			return accts, rowErrs, err
+		}
+
+		accts = append(accts, account)
+	}
+}
+
+// readHeader reads the first row and works out which field each column holds
+func (r *Reader) readHeader() error {
+	headers, err := r.csv.Read()
+	if err == io.EOF {
+		return errors.New("csv file has no header")
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make([]*field, len(headers))
+	seen := map[string]string{}
+	for idx, header := range headers {
+		header = strings.TrimSpace(header)
+		headers[idx] = header
+
+		f, ok, err := r.cfg.fieldFor(header)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if other, dup := seen[f.name]; dup {
+			return fmt.Errorf("columns %q and %q both hold field %q", other, header, f.name)
+		}
+
+		seen[f.name] = header
+		columns[idx] = &f
+	}
+
+	r.columns = columns
+	r.headers = headers
+	return nil
+}
+
+// decodeRecord converts record into an account and validates it
+func (r *Reader) decodeRecord(record []string) (accounts.AccountData, error) {
+	doc := map[string]interface{}{}
+	attrs := map[string]interface{}{}
+
+	var fieldErrs []accounts.FieldError
+	for idx, cell := range record {
+		f := r.columns[idx]
+		if f == nil || cell == "" {
+			continue
+		}
+
+		value, err := f.decode(cell)
+		if err != nil {
+			fieldErrs = append(fieldErrs, accounts.FieldError{
+				Field:   f.path(),
+				Message: fmt.Sprintf("in column %q is invalid: %v", r.headers[idx], err),
+			})
+
+			continue
+		}
+
+		if f.attribute {
+			attrs[f.name] = value
+		} else {
+			doc[f.name] = value
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		return accounts.AccountData{}, &accounts.ValidationError{Fields: fieldErrs}
+	}
+
+	if len(attrs) > 0 {
+		doc["attributes"] = attrs
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return accounts.AccountData{}, err
+	}
+
+	var account accounts.AccountData
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return accounts.AccountData{}, err
+	}
+
+	if account.OrganisationID == "" {
+		account.OrganisationID = r.cfg.OrganisationID
+	}
+
+	if account.ID == "" && r.cfg.GenerateIDs {
+		account.ID = uuid.NewString()
+	}
+
+	if err := account.Validate(); err != nil {
+		return accounts.AccountData{}, err
+	}
+
+	return account, nil
+}
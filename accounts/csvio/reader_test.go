@@ -0,0 +1,131 @@
+package csvio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAccountID = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+	testOrgID     = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+)
+
+func TestReader_mappedColumns(t *testing.T) {
+	file := "Account ID,Country,Sort Code,Holder,Joint,Extra Data,Notes\n" +
+		testAccountID + `,GB,400300,"Jane Smith|Smith Holdings",true,tier=gold|region=north,ignored` + "\n"
+
+	r := NewReader(strings.NewReader(file), Config{
+		Columns: []Column{
+			{Header: "Account ID", Field: "id"},
+			{Header: "Country", Field: "country"},
+			{Header: "Sort Code", Field: "bank_id"},
+			{Header: "Holder", Field: "name"},
+			{Header: "Joint", Field: "joint_account"},
+			{Header: "Extra Data", Field: "user_defined_data"},
+		},
+		IgnoreUnknownColumns: true,
+		OrganisationID:       testOrgID,
+	})
+
+	account, err := r.Read()
+	assert.NoError(t, err)
+
+	country, joint := "GB", true
+	assert.Equal(t, accounts.AccountData{
+		ID:             testAccountID,
+		OrganisationID: testOrgID,
+		Attributes: &accounts.AccountAttributes{
+			Country:         &country,
+			BankID:          "400300",
+			Name:            []string{"Jane Smith", "Smith Holdings"},
+			JointAccount:    &joint,
+			UserDefinedData: []accounts.UserDefinedData{{Key: "tier", Value: "gold"}, {Key: "region", Value: "north"}},
+		},
+	}, account)
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReader_rowErrorsHaveLineNumbers(t *testing.T) {
+	file := "id,organisation_id,country,name,status,version\n" +
+		testAccountID + "," + testOrgID + ",GB,Jane,confirmed,1\n" +
+		"not-a-uuid," + testOrgID + ",GB,Jane,,\n" +
+		testAccountID + "," + testOrgID + ",GB,Jane,open,one\n" +
+		"too,few\n" +
+		// a quoted cell spanning two lines puts the next row on line 8
+		testAccountID + "," + testOrgID + ",GB,\"Jane\nSmith\",,\n" +
+		testAccountID + "," + testOrgID + ",gb,Jane,,\n"
+
+	accts, rowErrs, err := NewReader(strings.NewReader(file), Config{}).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, accts, 2)
+	assert.Equal(t, []string{"Jane\nSmith"}, accts[1].Attributes.Name)
+
+	var lines []int
+	var msgs []string
+	for _, rerr := range rowErrs {
+		lines = append(lines, rerr.Line)
+		msgs = append(msgs, rerr.Error())
+	}
+
+	assert.Equal(t, []int{3, 4, 5, 8}, lines)
+	assert.Equal(t, []string{
+		"line 3: invalid account: id must be a UUID",
+		`line 4: invalid account: attributes.status in column "status" is invalid: invalid account status: open; ` +
+			`version in column "version" is invalid: must be a whole number`,
+		"line 5: wrong number of fields",
+		"line 8: invalid account: attributes.country must be an ISO 3166-1 alpha-2 country code",
+	}, msgs)
+
+	var verr *accounts.ValidationError
+	assert.True(t, errors.As(rowErrs[0], &verr))
+	assert.Equal(t, []accounts.FieldError{{Field: "id", Message: "must be a UUID"}}, verr.Fields)
+}
+
+func TestReader_headerErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		file   string
+		cfg    Config
+		errMsg string
+	}{
+		{name: "empty file", file: "", errMsg: "csv file has no header"},
+		{name: "unknown column", file: "id,colour\n", errMsg: `unknown column "colour"`},
+		{
+			name:   "unknown mapped field",
+			file:   "Colour\n",
+			cfg:    Config{Columns: []Column{{Header: "Colour", Field: "colour"}}},
+			errMsg: `column "Colour" is mapped to unknown field "colour"`,
+		},
+		{
+			name:   "duplicate field",
+			file:   "id,Account ID\n",
+			cfg:    Config{Columns: []Column{{Header: "Account ID", Field: "id"}}},
+			errMsg: `columns "id" and "Account ID" both hold field "id"`,
+		},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tc.file), tc.cfg).Read()
+			assert.EqualError(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestReader_generateIDs(t *testing.T) {
+	file := "organisation_id,country,name\n" + testOrgID + ",GB,Jane\n"
+
+	accts, rowErrs, err := NewReader(strings.NewReader(file), Config{GenerateIDs: true}).ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, rowErrs)
+	assert.Len(t, accts, 1)
+	assert.NoError(t, accts[0].Validate())
+}
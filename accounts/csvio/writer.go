@@ -0,0 +1,110 @@
+package csvio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// Writer writes accounts to a CSV file, starting with a header row
+type Writer struct {
+	cfg Config
+	csv *csv.Writer
+
+	// columns holds the field of each column, it is set once the header has been written
+	columns []field
+}
+
+// NewWriter returns a Writer that writes accounts to w laid out as described by cfg.
+// Writes are buffered so Flush must be called once every account has been written.
+func NewWriter(w io.Writer, cfg Config) *Writer {
+	return &Writer{cfg: cfg, csv: csv.NewWriter(w)}
+}
+
+// Write writes account as a row, writing the header first if this is the first row
+func (w *Writer) Write(account accounts.AccountData) error {
+	if w.columns == nil {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	// going through JSON gives every field the same format as the API uses for it
+	raw, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	attrs, _ := doc["attributes"].(map[string]interface{})
+
+	record := make([]string, len(w.columns))
+	for idx, f := range w.columns {
+		if f.attribute {
+			record[idx] = f.encode(attrs[f.name])
+		} else {
+			record[idx] = f.encode(doc[f.name])
+		}
+	}
+
+	return w.csv.Write(record)
+}
+
+// WriteAll writes every account and flushes the Writer
+func (w *Writer) WriteAll(accts []accounts.AccountData) error {
+	for _, account := range accts {
+		if err := w.Write(account); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// Flush writes any buffered rows and reports any error from this or an earlier write
+func (w *Writer) Flush() error {
+	// a file with no accounts still gets its header
+	if w.columns == nil {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// writeHeader writes the header row for the configured columns
+func (w *Writer) writeHeader() error {
+	var columns []field
+	var headers []string
+
+	if len(w.cfg.Columns) == 0 {
+		columns = fields
+		headers = FieldNames()
+	}
+
+	for _, col := range w.cfg.Columns {
+		f, ok := fieldsByName[col.Field]
+		if !ok {
+			return fmt.Errorf("column %q is mapped to unknown field %q", col.Header, col.Field)
+		}
+
+		columns = append(columns, f)
+		headers = append(headers, col.Header)
+	}
+
+	if err := w.csv.Write(headers); err != nil {
+		return err
+	}
+
+	w.columns = columns
+	return nil
+}
@@ -0,0 +1,98 @@
+package csvio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter_mappedColumns(t *testing.T) {
+	country, status := "GB", accounts.AccountStatusPending
+	account := accounts.AccountData{
+		ID: testAccountID,
+		Attributes: &accounts.AccountAttributes{
+			Country:         &country,
+			Name:            []string{"Jane Smith", "Smith|Co"},
+			Status:          &status,
+			UserDefinedData: []accounts.UserDefinedData{{Key: "tier", Value: "gold=1"}},
+		},
+	}
+
+	var out bytes.Buffer
+	w := NewWriter(&out, Config{Columns: []Column{
+		{Header: "Account ID", Field: "id"},
+		{Header: "Holder", Field: "name"},
+		{Header: "Status", Field: "status"},
+		{Header: "Extra Data", Field: "user_defined_data"},
+		{Header: "IBAN", Field: "iban"},
+	}})
+
+	assert.NoError(t, w.WriteAll([]accounts.AccountData{account}))
+	assert.Equal(
+		t,
+		"Account ID,Holder,Status,Extra Data,IBAN\n"+
+			testAccountID+`,Jane Smith|Smith\|Co,pending,tier=gold=1,`+"\n",
+		out.String(),
+	)
+}
+
+func TestWriter_roundTripsEveryField(t *testing.T) {
+	country, joint, switched, version := "GB", true, false, int64(3)
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	status := accounts.AccountStatusConfirmed
+	account := accounts.AccountData{
+		ID:             testAccountID,
+		OrganisationID: testOrgID,
+		Type:           "accounts",
+		Version:        &version,
+		CreatedOn:      &created,
+		ModifiedOn:     &created,
+		Attributes: &accounts.AccountAttributes{
+			Country:                     &country,
+			BaseCurrency:                "GBP",
+			BankID:                      "400300",
+			BankIDCode:                  "GBDSC",
+			Bic:                         "NWBKGB22",
+			AccountNumber:               "41426819",
+			Iban:                        "GB11NWBK40030041426819",
+			CustomerID:                  "cust-1",
+			Name:                        []string{"Jane Smith", `back\slash`},
+			AlternativeNames:            []string{"J Smith"},
+			AccountClassification:       accounts.AccountClassificationBusiness,
+			JointAccount:                &joint,
+			SecondaryIdentification:     "roll-1",
+			NameMatchingStatus:          accounts.AccountNameMatchingStatusOptedOut,
+			Status:                      &status,
+			StatusReason:                "unspecified",
+			UserDefinedData:             []accounts.UserDefinedData{{Key: "a", Value: "b|c"}},
+			AlternativeBankAccountNames: []string{"Smith"},
+			BankAccountName:             "Jane",
+			FirstName:                   "Jane",
+			Title:                       "Dr",
+			AccountMatchingOptOut:       true,
+			Switched:                    &switched,
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, NewWriter(&out, Config{}).WriteAll([]accounts.AccountData{account}))
+	assert.True(t, strings.HasPrefix(out.String(), strings.Join(FieldNames(), ",")+"\n"))
+
+	accts, rowErrs, err := NewReader(&out, Config{}).ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, rowErrs)
+	assert.Equal(t, []accounts.AccountData{account}, accts)
+}
+
+func TestWriter_headerOnlyAndBadColumns(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, NewWriter(&out, Config{Columns: []Column{{Header: "ID", Field: "id"}}}).Flush())
+	assert.Equal(t, "ID\n", out.String())
+
+	err := NewWriter(&out, Config{Columns: []Column{{Header: "Colour", Field: "colour"}}}).Write(accounts.AccountData{})
+	assert.EqualError(t, err, `column "Colour" is mapped to unknown field "colour"`)
+}
@@ -0,0 +1,118 @@
+package fixtures
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mod97 returns the remainder of s divided by 97, where s is a string of digits and upper case letters
+// with each letter standing for the two digits 10 (A) to 35 (Z), as in ISO 7064 MOD 97-10
+func mod97(s string) int {
+	rem := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			rem = (rem*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			rem = (rem*100 + int(c-'A') + 10) % 97
+		}
+	}
+
+	return rem
+}
+
+// ibanFor returns the IBAN for bban in country, with its check digits worked out
+func ibanFor(country, bban string) string {
+	check := 98 - mod97(bban+country+"00")
+	return fmt.Sprintf("%s%02d%s", country, check, bban)
+}
+
+// ValidIBAN reports whether iban, without spaces, has correct check digits
+func ValidIBAN(iban string) bool {
+	if len(iban) < 5 || iban != strings.ToUpper(iban) {
+		return false
+	}
+
+	for _, c := range iban {
+		if !(c >= '0' && c <= '9') && !(c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+
+	return mod97(iban[4:]+iban[:4]) == 1
+}
+
+// belgianCheck returns the two check digits of a Belgian account made of a 3 digit bank code and 7 digit account
+func belgianCheck(bankAndAccount string) string {
+	check := mod97(bankAndAccount)
+	if check == 0 {
+		check = 97
+	}
+
+	return fmt.Sprintf("%02d", check)
+}
+
+// frenchRIBKey returns the two digit RIB key of a French account from its 5 digit bank code,
+// 5 digit branch code and 11 digit account number
+func frenchRIBKey(bank, branch, account string) string {
+	return fmt.Sprintf("%02d", 97-mod97(bank+branch+account+"00"))
+}
+
+// portugueseNIBCheck returns the two check digits of a Portuguese NIB from its 8 digit bank and branch code
+// and 11 digit account number
+func portugueseNIBCheck(bankAndBranch, account string) string {
+	return fmt.Sprintf("%02d", 98-mod97(bankAndBranch+account+"00"))
+}
+
+// spanishControlDigits returns the two control digits of a Spanish account from its 8 digit bank and branch code
+// and 10 digit account number
+func spanishControlDigits(bankAndBranch, account string) string {
+	return spanishControlDigit("00"+bankAndBranch) + spanishControlDigit(account)
+}
+
+// spanishControlDigit returns the control digit of 10 digits
+func spanishControlDigit(digits string) string {
+	weights := []int{1, 2, 4, 8, 5, 10, 9, 7, 3, 6}
+
+	sum := 0
+	for idx, c := range digits {
+		sum += int(c-'0') * weights[idx]
+	}
+
+	check := 11 - sum%11
+	switch check {
+	case 11:
+		check = 0
+	case 10:
+		check = 1
+	}
+
+	return fmt.Sprint(check)
+}
+
+// abaCheckDigit returns the ninth digit that makes the first 8 digits of a US ABA routing number valid
+func abaCheckDigit(first8 string) string {
+	weights := []int{3, 7, 1, 3, 7, 1, 3, 7}
+
+	sum := 0
+	for idx, c := range first8 {
+		sum += int(c-'0') * weights[idx]
+	}
+
+	return fmt.Sprint((10 - sum%10) % 10)
+}
+
+// validABA reports whether routing is a 9 digit ABA routing number with a correct check digit
+func validABA(routing string) bool {
+	if len(routing) != 9 {
+		return false
+	}
+
+	for _, c := range routing {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return abaCheckDigit(routing[:8]) == routing[8:]
+}
@@ -0,0 +1,55 @@
+package fixtures
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidIBAN(t *testing.T) {
+	testCases := []struct {
+		name  string
+		iban  string
+		valid bool
+	}{
+		{name: "GB", iban: "GB29NWBK60161331926819", valid: true},
+		{name: "GB example", iban: "GB82WEST12345698765432", valid: true},
+		{name: "DE", iban: "DE89370400440532013000", valid: true},
+		{name: "FR with letters", iban: "FR1420041010050500013M02606", valid: true},
+		{name: "BE", iban: "BE68539007547034", valid: true},
+		{name: "IT", iban: "IT60X0542811101000000123456", valid: true},
+		// the IBAN the integration tests used to hardcode
+		{name: "wrong check digits", iban: "GB28NWBK40030212764204", valid: false},
+		{name: "transposed digits", iban: "GB29NWBK60161331928619", valid: false},
+		{name: "lower case", iban: "gb29nwbk60161331926819", valid: false},
+		{name: "spaces", iban: "GB29 NWBK 6016 1331 9268 19", valid: false},
+		{name: "too short", iban: "GB2", valid: false},
+	}
+
+	for idx, tc := range testCases {
+		assert.Equal(t, tc.valid, ValidIBAN(tc.iban), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
+
+func TestIbanFor(t *testing.T) {
+	assert.Equal(t, "GB29NWBK60161331926819", ibanFor("GB", "NWBK60161331926819"))
+	assert.Equal(t, "DE89370400440532013000", ibanFor("DE", "370400440532013000"))
+}
+
+func TestNationalCheckDigits(t *testing.T) {
+	// account numbers taken from published IBAN examples
+	assert.Equal(t, "34", belgianCheck("5390075470"))
+	assert.Equal(t, "89", frenchRIBKey("30006", "00001", "12345678901"))
+	assert.Equal(t, "45", spanishControlDigits("21000418", "0200051332"))
+	assert.Equal(t, "54", portugueseNIBCheck("00020123", "12345678901"))
+	assert.Equal(t, "X", italianCIN("0542811101000000123456"))
+}
+
+func TestValidABA(t *testing.T) {
+	assert.True(t, validABA("011000015"))
+	assert.True(t, validABA("021000021"))
+	assert.False(t, validABA("021000022"))
+	assert.False(t, validABA("02100002"))
+	assert.False(t, validABA("02100002A"))
+}
@@ -0,0 +1,247 @@
+package fixtures
+
+import (
+	"math/rand"
+)
+
+// country describes how accounts are numbered in a country supported by the API
+type country struct {
+	currency   string
+	bankIDCode string
+	// bicBanks are the bank codes, the first 4 letters of a BIC, of real banks in the country
+	bicBanks []string
+	// companySuffix is appended to the name of business accounts
+	companySuffix string
+	firstNames    []string
+	lastNames     []string
+	// number returns a bank ID, account number and IBAN, the IBAN is empty for countries that do not use them.
+	// bicBank is the bank code of the account's BIC, which some IBANs include.
+	number func(r *rand.Rand, bicBank string) (bankID, accountNumber, iban string)
+}
+
+// countries are the countries the API supports, by ISO 3166-1 alpha-2 code
+var countries = map[string]country{
+	"AU": {
+		currency:      "AUD",
+		bankIDCode:    "AUBSB",
+		bicBanks:      []string{"CTBA", "NATA", "WPAC"},
+		companySuffix: "Pty Ltd",
+		firstNames:    []string{"Olivia", "Jack", "Charlotte", "William", "Mia", "Noah"},
+		lastNames:     []string{"Smith", "Jones", "Williams", "Brown", "Wilson", "Taylor"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			return digits(r, 6), leadingNonZero(r, 6+r.Intn(5)), ""
+		},
+	},
+	"BE": {
+		currency:      "EUR",
+		bankIDCode:    "BE",
+		bicBanks:      []string{"GEBA", "KRED", "BBRU"},
+		companySuffix: "BV",
+		firstNames:    []string{"Lucas", "Emma", "Louis", "Olivia", "Noah", "Louise"},
+		lastNames:     []string{"Peeters", "Janssens", "Maes", "Jacobs", "Mertens", "Willems"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, account := digits(r, 3), digits(r, 7)
+			return bank, account, ibanFor("BE", bank+account+belgianCheck(bank+account))
+		},
+	},
+	"CA": {
+		currency:      "CAD",
+		bankIDCode:    "CACPA",
+		bicBanks:      []string{"ROYC", "TDOM", "BOFM"},
+		companySuffix: "Inc.",
+		firstNames:    []string{"Liam", "Olivia", "Noah", "Emma", "Ethan", "Chloe"},
+		lastNames:     []string{"Smith", "Tremblay", "Martin", "Roy", "Wilson", "MacDonald"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			return "0" + digits(r, 8), digits(r, 7+r.Intn(6)), ""
+		},
+	},
+	"CH": {
+		currency:      "CHF",
+		bankIDCode:    "CHBCC",
+		bicBanks:      []string{"UBSW", "CRES", "ZKBK"},
+		companySuffix: "AG",
+		firstNames:    []string{"Noah", "Mia", "Liam", "Emma", "Luca", "Lina"},
+		lastNames:     []string{"Müller", "Meier", "Schmid", "Keller", "Weber", "Huber"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, account := digits(r, 5), digits(r, 12)
+			return bank, account, ibanFor("CH", bank+account)
+		},
+	},
+	"DE": {
+		currency:      "EUR",
+		bankIDCode:    "DEBLZ",
+		bicBanks:      []string{"DEUT", "COBA", "GENO"},
+		companySuffix: "GmbH",
+		firstNames:    []string{"Lukas", "Anna", "Leon", "Lena", "Finn", "Hannah"},
+		lastNames:     []string{"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Becker"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			// the first digit of a Bankleitzahl is the clearing area, 1 to 8
+			bank, account := string(rune('1'+r.Intn(8)))+digits(r, 7), digits(r, 10)
+			return bank, account, ibanFor("DE", bank+account)
+		},
+	},
+	"ES": {
+		currency:      "EUR",
+		bankIDCode:    "ESNCC",
+		bicBanks:      []string{"BBVA", "CAIX", "BSCH"},
+		companySuffix: "S.L.",
+		firstNames:    []string{"Hugo", "Lucía", "Martín", "Sofía", "Pablo", "María"},
+		lastNames:     []string{"García", "Rodríguez", "González", "Fernández", "López", "Martínez"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, account := digits(r, 8), digits(r, 10)
+			return bank, account, ibanFor("ES", bank+spanishControlDigits(bank, account)+account)
+		},
+	},
+	"FR": {
+		currency:      "EUR",
+		bankIDCode:    "FR",
+		bicBanks:      []string{"BNPA", "SOGE", "AGRI"},
+		companySuffix: "SARL",
+		firstNames:    []string{"Gabriel", "Louise", "Raphaël", "Jade", "Léo", "Ambre"},
+		lastNames:     []string{"Martin", "Bernard", "Dubois", "Thomas", "Robert", "Richard"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, branch, account := digits(r, 5), digits(r, 5), digits(r, 11)
+			return bank + branch, account, ibanFor("FR", bank+branch+account+frenchRIBKey(bank, branch, account))
+		},
+	},
+	"GB": {
+		currency:      "GBP",
+		bankIDCode:    "GBDSC",
+		bicBanks:      []string{"NWBK", "BARC", "LOYD", "HBUK"},
+		companySuffix: "Ltd",
+		firstNames:    []string{"Oliver", "Amelia", "George", "Isla", "Harry", "Ava"},
+		lastNames:     []string{"Smith", "Jones", "Taylor", "Brown", "Williams", "Davies"},
+		number: func(r *rand.Rand, bicBank string) (string, string, string) {
+			sortCode, account := digits(r, 6), digits(r, 8)
+			return sortCode, account, ibanFor("GB", bicBank+sortCode+account)
+		},
+	},
+	"GR": {
+		currency:      "EUR",
+		bankIDCode:    "GRBIC",
+		bicBanks:      []string{"ETHN", "PIRB", "EFGB"},
+		companySuffix: "A.E.",
+		firstNames:    []string{"Georgios", "Maria", "Dimitrios", "Eleni", "Ioannis", "Katerina"},
+		lastNames:     []string{"Papadopoulos", "Pappas", "Oikonomou", "Georgiou", "Nikolaidis", "Vlachos"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, account := digits(r, 7), digits(r, 16)
+			return bank, account, ibanFor("GR", bank+account)
+		},
+	},
+	"HK": {
+		currency:      "HKD",
+		bankIDCode:    "HKNCC",
+		bicBanks:      []string{"HSBC", "BKCH", "SCBL"},
+		companySuffix: "Limited",
+		firstNames:    []string{"Wing", "Ka Yan", "Chi Ming", "Mei Ling", "Ho Yin", "Wai Man"},
+		lastNames:     []string{"Chan", "Wong", "Lee", "Cheung", "Lau", "Ho"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			return digits(r, 3), digits(r, 9+r.Intn(4)), ""
+		},
+	},
+	"IT": {
+		currency:      "EUR",
+		bankIDCode:    "ITNCC",
+		bicBanks:      []string{"UNCR", "BCIT", "BPMO"},
+		companySuffix: "S.r.l.",
+		firstNames:    []string{"Leonardo", "Sofia", "Francesco", "Giulia", "Alessandro", "Aurora"},
+		lastNames:     []string{"Rossi", "Russo", "Ferrari", "Esposito", "Bianchi", "Romano"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			abi, cab, account := digits(r, 5), digits(r, 5), digits(r, 12)
+			return abi + cab, account, ibanFor("IT", italianCIN(abi+cab+account)+abi+cab+account)
+		},
+	},
+	"LU": {
+		currency:      "EUR",
+		bankIDCode:    "LULUX",
+		bicBanks:      []string{"BCEE", "BGLL", "BILL"},
+		companySuffix: "S.à r.l.",
+		firstNames:    []string{"Gabriel", "Emma", "Leo", "Mia", "Luca", "Zoé"},
+		lastNames:     []string{"Schmit", "Muller", "Weber", "Wagner", "Hoffmann", "Thill"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, account := digits(r, 3), digits(r, 13)
+			return bank, account, ibanFor("LU", bank+account)
+		},
+	},
+	"NL": {
+		currency:      "EUR",
+		bicBanks:      []string{"INGB", "ABNA", "RABO"},
+		companySuffix: "B.V.",
+		firstNames:    []string{"Noah", "Emma", "Sem", "Julia", "Lucas", "Tess"},
+		lastNames:     []string{"de Jong", "Jansen", "de Vries", "van den Berg", "Bakker", "Visser"},
+		number: func(r *rand.Rand, bicBank string) (string, string, string) {
+			// Dutch accounts have no bank ID, the bank is identified by the BIC
+			account := digits(r, 10)
+			return "", account, ibanFor("NL", bicBank+account)
+		},
+	},
+	"PL": {
+		currency:      "PLN",
+		bankIDCode:    "PLKNR",
+		bicBanks:      []string{"BPKO", "PKOP", "INGB"},
+		companySuffix: "sp. z o.o.",
+		firstNames:    []string{"Antoni", "Zuzanna", "Jan", "Julia", "Aleksander", "Maja"},
+		lastNames:     []string{"Nowak", "Kowalski", "Wiśniewski", "Wójcik", "Kowalczyk", "Kamiński"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, account := digits(r, 8), digits(r, 16)
+			return bank, account, ibanFor("PL", bank+account)
+		},
+	},
+	"PT": {
+		currency:      "EUR",
+		bankIDCode:    "PTNCC",
+		bicBanks:      []string{"CGDI", "BCOM", "TOTA"},
+		companySuffix: "Lda",
+		firstNames:    []string{"Francisco", "Maria", "Santiago", "Leonor", "Afonso", "Matilde"},
+		lastNames:     []string{"Silva", "Santos", "Ferreira", "Pereira", "Oliveira", "Costa"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			bank, account := digits(r, 8), digits(r, 11)
+			return bank, account, ibanFor("PT", bank+account+portugueseNIBCheck(bank, account))
+		},
+	},
+	"US": {
+		currency:      "USD",
+		bankIDCode:    "USABA",
+		bicBanks:      []string{"CHAS", "BOFA", "CITI"},
+		companySuffix: "Inc.",
+		firstNames:    []string{"Liam", "Olivia", "Noah", "Emma", "James", "Ava"},
+		lastNames:     []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia"},
+		number: func(r *rand.Rand, _ string) (string, string, string) {
+			routing := digits(r, 8)
+			return routing + abaCheckDigit(routing), leadingNonZero(r, 6+r.Intn(12)), ""
+		},
+	},
+}
+
+// digits returns n random digits
+func digits(r *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for idx := range b {
+		b[idx] = byte('0' + r.Intn(10))
+	}
+
+	return string(b)
+}
+
+// leadingNonZero returns n random digits, the first of which is not 0
+func leadingNonZero(r *rand.Rand, n int) string {
+	return string(rune('1'+r.Intn(9))) + digits(r, n-1)
+}
+
+// italianCIN returns the CIN check letter of an Italian account from its ABI, CAB and account number
+func italianCIN(abiCabAccount string) string {
+	// odd positions are weighted with this table, indexed by digit value, even positions by the digit itself
+	odd := []int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21}
+
+	sum := 0
+	for idx, c := range abiCabAccount {
+		value := int(c - '0')
+		if idx%2 == 0 {
+			sum += odd[value]
+		} else {
+			sum += value
+		}
+	}
+
+	return string(rune('A' + sum%26))
+}
@@ -0,0 +1,226 @@
+// Package fixtures produces realistic accounts for every country the API supports, for load tests and
+// test suites that need accounts the API will accept rather than arbitrary valid JSON.
+//
+// Accounts have the bank ID, account number and IBAN formats of their country, a BIC from a bank in the country
+// and the country's currency. IBAN check digits are always correct, as are the national check digits of Belgian,
+// French, Italian, Portuguese and Spanish accounts and US ABA routing numbers. Other national check digits,
+// e.g. of German account numbers, are not worked out.
+//
+//	factory := fixtures.New(42, fixtures.WithOrganisationID(orgID))
+//	account, err := factory.Account("GB")
+//
+// Account IDs are random UUIDs and a Factory does not repeat an account number among the last 100,000 accounts
+// it returned. Factories made with the same seed and options return the same accounts in the same order.
+package fixtures
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/accounts/accountstest"
+)
+
+const (
+	upperAndDigit = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// defaultRemembered is the number of recent account numbers a Factory keeps to avoid repeating them,
+	// which bounds the memory used by long running load tests
+	defaultRemembered = 100000
+)
+
+// Option configures a Factory
+type Option func(*Factory)
+
+// WithOrganisationID sets the organisation ID of the accounts, by default it is derived from the seed
+func WithOrganisationID(id string) Option {
+	return func(f *Factory) {
+		f.organisationID = id
+	}
+}
+
+// WithDeprecatedFields fills in the deprecated account attributes, e.g. bank_account_name and title,
+// as older clients of the API still send them
+func WithDeprecatedFields() Option {
+	return func(f *Factory) {
+		f.deprecatedFields = true
+	}
+}
+
+// Factory produces accounts. It is safe for concurrent use, though the order concurrent calls
+// draw accounts in is not reproducible.
+type Factory struct {
+	organisationID   string
+	deprecatedFields bool
+
+	mu  sync.Mutex
+	rng *rand.Rand
+	// seen holds the country, bank ID and account number of the most recent accounts returned. order holds
+	// the same keys in a ring buffer, oldest first from next, so that the oldest can be forgotten.
+	seen       map[string]bool
+	order      []string
+	next       int
+	remembered int
+}
+
+// New returns a Factory whose random choices come from seed
+func New(seed int64, opts ...Option) *Factory {
+	f := &Factory{rng: rand.New(rand.NewSource(seed)), seen: map[string]bool{}, remembered: defaultRemembered}
+	f.organisationID = accountstest.RandomUUID(f.rng)
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// Countries returns the supported country codes in alphabetical order
+func Countries() []string {
+	codes := make([]string, 0, len(countries))
+	for code := range countries {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+	return codes
+}
+
+// OrganisationID returns the organisation ID of the accounts
+func (f *Factory) OrganisationID() string {
+	return f.organisationID
+}
+
+// Account returns a new account in countryCode, which must be one of Countries
+func (f *Factory) Account(countryCode string) (accounts.AccountData, error) {
+	spec, ok := countries[countryCode]
+	if !ok {
+		return accounts.AccountData{}, fmt.Errorf("fixtures: unsupported country %q", countryCode)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.account(countryCode, spec), nil
+}
+
+// Accounts returns n new accounts in countryCode, which must be one of Countries
+func (f *Factory) Accounts(countryCode string, n int) ([]accounts.AccountData, error) {
+	spec, ok := countries[countryCode]
+	if !ok {
+		return nil, fmt.Errorf("fixtures: unsupported country %q", countryCode)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	accs := make([]accounts.AccountData, 0, n)
+	for idx := 0; idx < n; idx++ {
+		accs = append(accs, f.account(countryCode, spec))
+	}
+
+	return accs, nil
+}
+
+// AnyAccount returns a new account in a country picked at random
+func (f *Factory) AnyAccount() accounts.AccountData {
+	codes := Countries()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code := codes[f.rng.Intn(len(codes))]
+	return f.account(code, countries[code])
+}
+
+// account returns a new account in countryCode. f.mu must be held.
+func (f *Factory) account(countryCode string, spec country) accounts.AccountData {
+	id := accountstest.RandomUUID(f.rng)
+
+	bicBank := spec.bicBanks[f.rng.Intn(len(spec.bicBanks))]
+	bankID, accountNumber, iban := spec.number(f.rng, bicBank)
+	for f.seen[countryCode+bankID+accountNumber] {
+		bankID, accountNumber, iban = spec.number(f.rng, bicBank)
+	}
+
+	f.remember(countryCode + bankID + accountNumber)
+
+	firstName := spec.firstNames[f.rng.Intn(len(spec.firstNames))]
+	lastName := spec.lastNames[f.rng.Intn(len(spec.lastNames))]
+
+	classification := accounts.AccountClassificationPersonal
+	name := firstName + " " + lastName
+	if f.rng.Intn(4) == 0 {
+		classification = accounts.AccountClassificationBusiness
+		name = lastName + " " + spec.companySuffix
+	}
+
+	attrs := &accounts.AccountAttributes{
+		AccountClassification: classification,
+		AccountNumber:         accountNumber,
+		BankID:                bankID,
+		BankIDCode:            spec.bankIDCode,
+		BaseCurrency:          spec.currency,
+		Bic:                   f.bic(bicBank, countryCode),
+		Country:               &countryCode,
+		CustomerID:            digits(f.rng, 8),
+		Iban:                  iban,
+		Name:                  []string{name},
+	}
+
+	if f.deprecatedFields {
+		switched := false
+		attrs.BankAccountName = name
+		attrs.AlternativeBankAccountNames = []string{lastName}
+		attrs.ProcessingService = "ABC Bank"
+		attrs.UserDefinedInformation = "fixture"
+		attrs.Switched = &switched
+		if classification == accounts.AccountClassificationPersonal {
+			attrs.FirstName = firstName
+			attrs.Title = []string{"Mr", "Ms", "Mx", "Dr"}[f.rng.Intn(4)]
+		}
+	}
+
+	return accounts.AccountData{
+		ID:             id,
+		OrganisationID: f.organisationID,
+		Type:           "accounts",
+		Attributes:     attrs,
+	}
+}
+
+// remember adds key to the recently returned account numbers, forgetting the oldest once there are
+// f.remembered of them. f.mu must be held.
+func (f *Factory) remember(key string) {
+	if len(f.order) < f.remembered {
+		f.order = append(f.order, key)
+	} else {
+		delete(f.seen, f.order[f.next])
+		f.order[f.next] = key
+		f.next = (f.next + 1) % f.remembered
+	}
+
+	f.seen[key] = true
+}
+
+// bic returns a BIC of bank in countryCode: the bank and country code, a location code
+// and, half the time, a branch code
+func (f *Factory) bic(bank, countryCode string) string {
+	bic := bank + countryCode + f.pick(upperAndDigit, 2)
+	if f.rng.Intn(2) == 0 {
+		bic += f.pick(upperAndDigit, 3)
+	}
+
+	return bic
+}
+
+// pick returns n characters picked from alphabet
+func (f *Factory) pick(alphabet string, n int) string {
+	b := make([]byte, n)
+	for idx := range b {
+		b[idx] = alphabet[f.rng.Intn(len(alphabet))]
+	}
+
+	return string(b)
+}
@@ -0,0 +1,165 @@
+package fixtures
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactory_Account_everyCountry(t *testing.T) {
+	factory := New(1)
+
+	for _, code := range Countries() {
+		accs, err := factory.Accounts(code, 50)
+		if !assert.NoError(t, err, code) {
+			continue
+		}
+
+		for _, account := range accs {
+			attrs := account.Attributes
+			assert.NoError(t, account.Validate(), code)
+			assert.Equal(t, code, *attrs.Country)
+			assert.Equal(t, countries[code].currency, attrs.BaseCurrency, code)
+			assert.Equal(t, countries[code].bankIDCode, attrs.BankIDCode, code)
+			assert.Equal(t, code, attrs.Bic[4:6], "BIC %s of %s account", attrs.Bic, code)
+			assert.Equal(t, factory.OrganisationID(), account.OrganisationID)
+
+			if attrs.Iban != "" {
+				assert.True(t, ValidIBAN(attrs.Iban), "IBAN %s", attrs.Iban)
+				assert.True(t, strings.HasPrefix(attrs.Iban, code), "IBAN %s of %s account", attrs.Iban, code)
+				assert.Contains(t, attrs.Iban, attrs.AccountNumber)
+			}
+		}
+	}
+}
+
+func TestFactory_Account_ibanOnlyWhereUsed(t *testing.T) {
+	factory := New(2)
+
+	for _, code := range Countries() {
+		account, err := factory.Account(code)
+		assert.NoError(t, err)
+
+		switch code {
+		case "AU", "CA", "HK", "US":
+			assert.Empty(t, account.Attributes.Iban, code)
+		default:
+			assert.NotEmpty(t, account.Attributes.Iban, code)
+		}
+	}
+}
+
+func TestFactory_Account_usRoutingNumbersValid(t *testing.T) {
+	accs, err := New(3).Accounts("US", 100)
+	assert.NoError(t, err)
+
+	for _, account := range accs {
+		assert.True(t, validABA(account.Attributes.BankID), account.Attributes.BankID)
+	}
+}
+
+func TestFactory_Account_unsupportedCountry(t *testing.T) {
+	factory := New(4)
+
+	_, err := factory.Account("ZZ")
+	assert.EqualError(t, err, `fixtures: unsupported country "ZZ"`)
+
+	_, err = factory.Accounts("gb", 1)
+	assert.EqualError(t, err, `fixtures: unsupported country "gb"`)
+}
+
+func TestFactory_sameSeedSameAccounts(t *testing.T) {
+	first, second := New(42), New(42)
+	for idx := 0; idx < 20; idx++ {
+		assert.Equal(t, first.AnyAccount(), second.AnyAccount())
+	}
+
+	assert.Equal(t, first.OrganisationID(), second.OrganisationID())
+	assert.NotEqual(t, New(42).AnyAccount(), New(43).AnyAccount())
+}
+
+func TestFactory_uniqueAccounts(t *testing.T) {
+	factory := New(5)
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		accs []accounts.AccountData
+	)
+
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// HK accounts have the fewest digits so are the most likely to collide
+			batch, err := factory.Accounts("HK", 500)
+			assert.NoError(t, err)
+
+			mu.Lock()
+			accs = append(accs, batch...)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	ids, numbers := map[string]bool{}, map[string]bool{}
+	for _, account := range accs {
+		assert.False(t, ids[account.ID], "duplicate ID %s", account.ID)
+		ids[account.ID] = true
+
+		number := account.Attributes.BankID + account.Attributes.AccountNumber
+		assert.False(t, numbers[number], "duplicate account %s", number)
+		numbers[number] = true
+	}
+
+	assert.Len(t, accs, 4000)
+}
+
+func TestFactory_remembersBoundedNumberOfAccounts(t *testing.T) {
+	factory := New(7)
+	factory.remembered = 10
+
+	var last []accounts.AccountData
+	for idx := 0; idx < 100; idx++ {
+		last = append(last, factory.AnyAccount())
+	}
+
+	assert.Len(t, factory.seen, 10)
+	assert.Len(t, factory.order, 10)
+
+	// the most recent accounts are the ones still remembered
+	for _, account := range last[90:] {
+		assert.True(t, factory.seen[*account.Attributes.Country+account.Attributes.BankID+account.Attributes.AccountNumber])
+	}
+}
+
+func TestWithOrganisationID(t *testing.T) {
+	orgID := "7d6b5f2e-3a61-4c1f-9b0e-2f8d4a6c1e53"
+	account := New(6, WithOrganisationID(orgID)).AnyAccount()
+	assert.Equal(t, orgID, account.OrganisationID)
+}
+
+func TestWithDeprecatedFields(t *testing.T) {
+	account := New(7).AnyAccount()
+	assert.Empty(t, account.Attributes.BankAccountName)
+	assert.Nil(t, account.Attributes.Switched)
+
+	accs, err := New(7, WithDeprecatedFields()).Accounts("GB", 20)
+	assert.NoError(t, err)
+
+	for _, account := range accs {
+		attrs := account.Attributes
+		assert.Equal(t, attrs.Name[0], attrs.BankAccountName)
+		assert.NotEmpty(t, attrs.AlternativeBankAccountNames)
+		assert.NotNil(t, attrs.Switched)
+		if attrs.AccountClassification == accounts.AccountClassificationPersonal {
+			assert.NotEmpty(t, attrs.FirstName)
+			assert.NotEmpty(t, attrs.Title)
+		}
+	}
+}
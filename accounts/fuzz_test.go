@@ -0,0 +1,146 @@
+package accounts_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/accounts/accountstest"
+)
+
+// enum is an account enum that is encoded in JSON as a string
+type enum interface {
+	comparable
+	fmt.Stringer
+}
+
+// fuzzEnumUnmarshalJSON checks that whatever T's UnmarshalJSON accepts is one of valid
+// and is encoded back to the same string
+func fuzzEnumUnmarshalJSON[T enum, PT interface {
+	*T
+	json.Unmarshaler
+}](f *testing.F, valid []string) {
+	for _, s := range valid {
+		f.Add([]byte(fmt.Sprintf("%q", s)))
+	}
+
+	for _, seed := range []string{`"unknown"`, `""`, `null`, `0`, `"`, `["confirmed"]`, `"Confirmed"`} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v T
+		if err := PT(&v).UnmarshalJSON(data); err != nil {
+			return
+		}
+
+		found := false
+		for _, s := range valid {
+			found = found || v.String() == s
+		}
+
+		if !found {
+			t.Fatalf("%s decoded to %v which is not a valid value", data, v)
+		}
+
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to encode %v: %v", v, err)
+		}
+
+		var decoded T
+		if err := json.Unmarshal(raw, PT(&decoded)); err != nil {
+			t.Fatalf("failed to decode %s: %v", raw, err)
+		}
+
+		if decoded != v {
+			t.Fatalf("%v was decoded as %v after a round trip", v, decoded)
+		}
+	})
+}
+
+func FuzzAccountStatusUnmarshalJSON(f *testing.F) {
+	fuzzEnumUnmarshalJSON[accounts.AccountStatus](f, []string{"confirmed", "pending", "cancelled", "failed"})
+}
+
+func FuzzAccountClassificationUnmarshalJSON(f *testing.F) {
+	fuzzEnumUnmarshalJSON[accounts.AccountClassification](f, []string{"Personal", "Business"})
+}
+
+func FuzzAccountNameMatchingStatusUnmarshalJSON(f *testing.F) {
+	fuzzEnumUnmarshalJSON[accounts.AccountNameMatchingStatus](f, []string{"supported", "switched", "opted_out", "not_supported"})
+}
+
+// FuzzResponseRoundTrip checks that any response that can be decoded is encoded in a stable form:
+// encoding, decoding and encoding again gives the same JSON
+func FuzzResponseRoundTrip(f *testing.F) {
+	r := rand.New(rand.NewSource(0))
+	for idx := 0; idx < 10; idx++ {
+		raw, err := json.Marshal(accountstest.RandomResponse(r))
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		f.Add(raw)
+	}
+
+	f.Add([]byte(`{"data": {"id": "1", "created_on": "2021-03-04T05:06:07+01:00", "attributes": {"status": null}}}`))
+	f.Add([]byte(`{"data": {"attributes": {"name": [], "user_defined_data": [{}], "country": ""}}, "links": {}}`))
+	f.Add([]byte(`{"data": null}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var resp accounts.Response
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return
+		}
+
+		first, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatalf("failed to encode decoded response: %v", err)
+		}
+
+		var decoded accounts.Response
+		if err := json.Unmarshal(first, &decoded); err != nil {
+			t.Fatalf("failed to decode %s: %v", first, err)
+		}
+
+		second, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("failed to encode response after a round trip: %v", err)
+		}
+
+		if !bytes.Equal(first, second) {
+			t.Fatalf("response changed after a round trip:\n%s\n%s", first, second)
+		}
+	})
+}
+
+// FuzzRandomAccountDataRoundTrip checks that every generated account decodes to exactly the value that was encoded
+func FuzzRandomAccountDataRoundTrip(f *testing.F) {
+	for seed := int64(0); seed < 20; seed++ {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		account := accountstest.RandomAccountData(rand.New(rand.NewSource(seed)))
+
+		raw, err := json.Marshal(account)
+		if err != nil {
+			t.Fatalf("failed to encode account: %v", err)
+		}
+
+		var decoded accounts.AccountData
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatalf("failed to decode %s: %v", raw, err)
+		}
+
+		// generated times are in UTC so decoding gives back exactly the same value
+		if !reflect.DeepEqual(account, decoded) {
+			t.Fatalf("account changed after a round trip:\n%+v\n%+v", account, decoded)
+		}
+	})
+}
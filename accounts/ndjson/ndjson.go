@@ -0,0 +1,100 @@
+// Package ndjson streams accounts as newline delimited JSON, one accounts.AccountData per line,
+// encoded with the same JSON field names as the API. Memory use does not grow with the number of accounts.
+package ndjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// Encoder writes accounts to a stream, one per line
+type Encoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewEncoder returns an Encoder writing to w.
+// Writes are buffered so Flush must be called once every account has been written.
+func NewEncoder(w io.Writer) *Encoder {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	return &Encoder{w: bw, enc: enc}
+}
+
+// Encode writes account as a single line
+func (e *Encoder) Encode(account accounts.AccountData) error {
+	// json.Encoder writes compact JSON followed by a newline, which is exactly one line of NDJSON
+	return e.enc.Encode(account)
+}
+
+// Flush writes any buffered accounts to the underlying writer
+func (e *Encoder) Flush() error {
+	return e.w.Flush()
+}
+
+// LineError is a line of the stream that does not hold a valid account.
+// Decoding can carry on with the next line.
+type LineError struct {
+	// Line is the line number, counting from 1
+	Line int
+	Err  error
+}
+
+func (lerr *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", lerr.Line, lerr.Err)
+}
+
+// Unwrap returns the error underlying lerr so that it can be inspected with errors.Is and errors.As
+func (lerr *LineError) Unwrap() error {
+	return lerr.Err
+}
+
+// Decoder reads accounts from a stream, one per line. Blank lines are skipped.
+type Decoder struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewDecoder returns a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode returns the account on the next line, or io.EOF once the stream is exhausted.
+// A *LineError is returned for a line that does not hold an account, in which case Decode
+// can be called again to carry on with the next line. Any other error is fatal.
+func (d *Decoder) Decode() (accounts.AccountData, error) {
+	for {
+		raw, err := d.r.ReadBytes('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && len(raw) > 0) {
+			return accounts.AccountData{}, err
+		}
+
+		d.line++
+
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+
+		var account accounts.AccountData
+		if err := json.Unmarshal(raw, &account); err != nil {
+			return accounts.AccountData{}, &LineError{Line: d.line, Err: err}
+		}
+
+		return account, nil
+	}
+}
+
+// Line returns the number of the line the last account or error was read from
+func (d *Decoder) Line() int {
+	return d.line
+}
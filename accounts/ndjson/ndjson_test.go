@@ -0,0 +1,113 @@
+package ndjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder_oneAccountPerLine(t *testing.T) {
+	country := "GB"
+	var out bytes.Buffer
+	enc := NewEncoder(&out)
+
+	assert.NoError(t, enc.Encode(accounts.AccountData{ID: "a", Attributes: &accounts.AccountAttributes{Country: &country, Name: []string{"<Jane>"}}}))
+	assert.NoError(t, enc.Encode(accounts.AccountData{ID: "b"}))
+	assert.Empty(t, out.String(), "writes are buffered until flushed")
+	assert.NoError(t, enc.Flush())
+
+	assert.Equal(
+		t,
+		`{"attributes":{"country":"GB","name":["<Jane>"]},"id":"a"}`+"\n"+`{"id":"b"}`+"\n",
+		out.String(),
+	)
+}
+
+func TestDecoder_roundTrip(t *testing.T) {
+	version := int64(2)
+	accts := []accounts.AccountData{
+		{ID: "a", Version: &version},
+		{ID: "b", Attributes: &accounts.AccountAttributes{Name: []string{"line\nbreak"}}},
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, account := range accts {
+		assert.NoError(t, enc.Encode(account))
+	}
+
+	assert.NoError(t, enc.Flush())
+
+	dec := NewDecoder(&buf)
+	var decoded []accounts.AccountData
+	for {
+		account, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+
+		assert.NoError(t, err)
+		decoded = append(decoded, account)
+	}
+
+	assert.Equal(t, accts, decoded)
+}
+
+func TestDecoder_lineErrors(t *testing.T) {
+	stream := `{"id":"a"}` + "\n" +
+		"\n" +
+		`{"id":` + "\n" +
+		`{"attributes":{"status":"open"}}` + "\n" +
+		`  {"id":"b"}  ` // no trailing newline
+
+	type result struct {
+		id     string
+		line   int
+		errMsg string
+	}
+
+	var results []result
+	dec := NewDecoder(strings.NewReader(stream))
+	for {
+		account, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+
+		var lerr *LineError
+		if errors.As(err, &lerr) {
+			results = append(results, result{line: lerr.Line, errMsg: lerr.Error()})
+			continue
+		}
+
+		assert.NoError(t, err)
+		results = append(results, result{id: account.ID, line: dec.Line()})
+	}
+
+	assert.Equal(t, []result{
+		{id: "a", line: 1},
+		{line: 3, errMsg: "line 3: unexpected end of JSON input"},
+		{line: 4, errMsg: "line 4: invalid account status: open"},
+		{id: "b", line: 5},
+	}, results)
+}
+
+func TestDecoder_readError(t *testing.T) {
+	readErr := fmt.Errorf("disk on fire")
+	_, err := NewDecoder(io.MultiReader(strings.NewReader(`{"id":"a"}`), &failingReader{err: readErr})).Decode()
+	assert.Equal(t, readErr, err)
+}
+
+type failingReader struct {
+	err error
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+	return 0, fr.err
+}
@@ -10,8 +10,17 @@ type (
 		Links *Links       `json:"links"`
 	}
 
+	ListResponse struct {
+		Data  []AccountData `json:"data"`
+		Links *Links        `json:"links"`
+	}
+
 	Links struct {
-		Self string `json:"self"`
+		Self  string `json:"self"`
+		First string `json:"first,omitempty"`
+		Last  string `json:"last,omitempty"`
+		Next  string `json:"next,omitempty"`
+		Prev  string `json:"prev,omitempty"`
 	}
 )
 
@@ -0,0 +1,127 @@
+package accounts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	accountType = "accounts"
+
+	maxNames            = 4
+	maxAlternativeNames = 3
+	maxNameLength       = 140
+)
+
+var (
+	countryPattern      = regexp.MustCompile(`^[A-Z]{2}$`)
+	currencyPattern     = regexp.MustCompile(`^[A-Z]{3}$`)
+	bicPattern          = regexp.MustCompile(`^([A-Z]{6}[A-Z0-9]{2}|[A-Z]{6}[A-Z0-9]{5})$`)
+	bankIDCodePattern   = regexp.MustCompile(`^[A-Z]{0,16}$`)
+	ibanPattern         = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{0,64}$`)
+	accountNumberFormat = regexp.MustCompile(`^[A-Z0-9]{0,64}$`)
+)
+
+// FieldError is a problem with a single field of an account, identified by its JSON path
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (ferr FieldError) Error() string {
+	return fmt.Sprintf("%s %s", ferr.Field, ferr.Message)
+}
+
+// ValidationError lists every problem found with an account
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (verr *ValidationError) Error() string {
+	msgs := make([]string, 0, len(verr.Fields))
+	for _, ferr := range verr.Fields {
+		msgs = append(msgs, ferr.Error())
+	}
+
+	return fmt.Sprintf("invalid account: %s", strings.Join(msgs, "; "))
+}
+
+// Validate checks the account against the rules the API applies when creating accounts,
+// so that mistakes can be caught without sending a request.
+// It returns a *ValidationError listing every problem found, or nil if there are none.
+func (a AccountData) Validate() error {
+	var fields []FieldError
+	addErr := func(field, msg string) {
+		fields = append(fields, FieldError{Field: field, Message: msg})
+	}
+
+	if _, err := uuid.Parse(a.ID); err != nil {
+		addErr("id", "must be a UUID")
+	}
+
+	if _, err := uuid.Parse(a.OrganisationID); err != nil {
+		addErr("organisation_id", "must be a UUID")
+	}
+
+	if a.Type != "" && a.Type != accountType {
+		addErr("type", fmt.Sprintf("must be %q", accountType))
+	}
+
+	if a.Attributes == nil {
+		addErr("attributes", "is required")
+		return newValidationError(fields)
+	}
+
+	attrs := a.Attributes
+	if attrs.Country == nil || !countryPattern.MatchString(*attrs.Country) {
+		addErr("attributes.country", "must be an ISO 3166-1 alpha-2 country code")
+	}
+
+	if len(attrs.Name) == 0 || len(attrs.Name) > maxNames {
+		addErr("attributes.name", fmt.Sprintf("must have between 1 and %d entries", maxNames))
+	}
+
+	for idx, name := range attrs.Name {
+		if name == "" || len(name) > maxNameLength {
+			addErr(fmt.Sprintf("attributes.name[%d]", idx), fmt.Sprintf("must be between 1 and %d characters", maxNameLength))
+		}
+	}
+
+	if len(attrs.AlternativeNames) > maxAlternativeNames {
+		addErr("attributes.alternative_names", fmt.Sprintf("must have at most %d entries", maxAlternativeNames))
+	}
+
+	if attrs.BaseCurrency != "" && !currencyPattern.MatchString(attrs.BaseCurrency) {
+		addErr("attributes.base_currency", "must be an ISO 4217 currency code")
+	}
+
+	if attrs.Bic != "" && !bicPattern.MatchString(attrs.Bic) {
+		addErr("attributes.bic", "must be an 8 or 11 character SWIFT BIC")
+	}
+
+	if !bankIDCodePattern.MatchString(attrs.BankIDCode) {
+		addErr("attributes.bank_id_code", "must be up to 16 upper case letters")
+	}
+
+	if attrs.Iban != "" && !ibanPattern.MatchString(attrs.Iban) {
+		addErr("attributes.iban", "must be an upper case IBAN without spaces")
+	}
+
+	if !accountNumberFormat.MatchString(attrs.AccountNumber) {
+		addErr("attributes.account_number", "must be up to 64 upper case letters and digits")
+	}
+
+	return newValidationError(fields)
+}
+
+// newValidationError returns a *ValidationError for fields, or nil if there are none
+func newValidationError(fields []FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: fields}
+}
@@ -0,0 +1,124 @@
+package accounts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validAccountData() AccountData {
+	country := "GB"
+	return AccountData{
+		ID:             "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+		OrganisationID: "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c",
+		Type:           "accounts",
+		Attributes: &AccountAttributes{
+			Country:       &country,
+			BaseCurrency:  "GBP",
+			BankID:        "400300",
+			BankIDCode:    "GBDSC",
+			Bic:           "NWBKGB22",
+			AccountNumber: "41426819",
+			Iban:          "GB11NWBK40030041426819",
+			Name:          []string{"Samantha Holder"},
+		},
+	}
+}
+
+func TestAccountData_Validate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		modify         func(a *AccountData)
+		expectedFields []FieldError
+	}{
+		{
+			name:   "valid",
+			modify: func(a *AccountData) {},
+		},
+		{
+			name: "valid without type or optional attributes",
+			modify: func(a *AccountData) {
+				a.Type = ""
+				a.Attributes.Bic = ""
+				a.Attributes.Iban = ""
+				a.Attributes.BaseCurrency = ""
+			},
+		},
+		{
+			name: "bad ids and type",
+			modify: func(a *AccountData) {
+				a.ID = "1"
+				a.OrganisationID = ""
+				a.Type = "account"
+			},
+			expectedFields: []FieldError{
+				{Field: "id", Message: "must be a UUID"},
+				{Field: "organisation_id", Message: "must be a UUID"},
+				{Field: "type", Message: `must be "accounts"`},
+			},
+		},
+		{
+			name:           "missing attributes",
+			modify:         func(a *AccountData) { a.Attributes = nil },
+			expectedFields: []FieldError{{Field: "attributes", Message: "is required"}},
+		},
+		{
+			name: "bad country and names",
+			modify: func(a *AccountData) {
+				a.Attributes.Country = nil
+				a.Attributes.Name = []string{"a", "", "c", "d", strings.Repeat("e", 141)}
+				a.Attributes.AlternativeNames = []string{"a", "b", "c", "d"}
+			},
+			expectedFields: []FieldError{
+				{Field: "attributes.country", Message: "must be an ISO 3166-1 alpha-2 country code"},
+				{Field: "attributes.name", Message: "must have between 1 and 4 entries"},
+				{Field: "attributes.name[1]", Message: "must be between 1 and 140 characters"},
+				{Field: "attributes.name[4]", Message: "must be between 1 and 140 characters"},
+				{Field: "attributes.alternative_names", Message: "must have at most 3 entries"},
+			},
+		},
+		{
+			name: "bad bank details",
+			modify: func(a *AccountData) {
+				a.Attributes.BaseCurrency = "gbp"
+				a.Attributes.Bic = "NWBK"
+				a.Attributes.BankIDCode = "GB-DSC"
+				a.Attributes.Iban = "GB11 NWBK"
+				a.Attributes.AccountNumber = "4142-6819"
+			},
+			expectedFields: []FieldError{
+				{Field: "attributes.base_currency", Message: "must be an ISO 4217 currency code"},
+				{Field: "attributes.bic", Message: "must be an 8 or 11 character SWIFT BIC"},
+				{Field: "attributes.bank_id_code", Message: "must be up to 16 upper case letters"},
+				{Field: "attributes.iban", Message: "must be an upper case IBAN without spaces"},
+				{Field: "attributes.account_number", Message: "must be up to 64 upper case letters and digits"},
+			},
+		},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			account := validAccountData()
+			tc.modify(&account)
+
+			err := account.Validate()
+			if tc.expectedFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, &ValidationError{Fields: tc.expectedFields}, err)
+		})
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Fields: []FieldError{
+		{Field: "id", Message: "must be a UUID"},
+		{Field: "attributes", Message: "is required"},
+	}}
+
+	assert.Equal(t, "invalid account: id must be a UUID; attributes is required", err.Error())
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// AccountsAPI is every operation of the account API client.
+// Code that depends on AccountsAPI rather than *Client can be tested with clienttest.Fake.
+type AccountsAPI interface {
+	Create(ctx context.Context, account accounts.AccountData) (*accounts.Response, error)
+	CreateMany(ctx context.Context, accts []accounts.AccountData, opts BulkOptions) ([]CreateResult, error)
+	Fetch(ctx context.Context, accountID string) (*accounts.Response, error)
+	FetchConditional(ctx context.Context, accountID string) (resp *accounts.Response, unchanged bool, err error)
+	List(ctx context.Context, opts ListOptions) (*accounts.ListResponse, error)
+	ListAll(ctx context.Context, opts ListOptions, fn func(account accounts.AccountData) error) error
+	Delete(ctx context.Context, accountID string, version uint) error
+	DeleteMany(ctx context.Context, req DeleteManyRequest, opts BulkOptions) (*DeleteSummary, error)
+	Export(ctx context.Context, w io.Writer, filter ListFilter) (int, error)
+	Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportSummary, error)
+	Health(ctx context.Context) (*Health, error)
+	WaitUntilHealthy(ctx context.Context, interval time.Duration) error
+}
+
+var _ AccountsAPI = (*Client)(nil)
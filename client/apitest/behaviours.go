@@ -0,0 +1,252 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/google/uuid"
+)
+
+// behaviour is something every implementation of the account API is expected to do
+type behaviour struct {
+	name string
+	// check returns an error describing how the API differs from the behaviour, nil if it does not
+	check func(ctx context.Context, s *session) error
+}
+
+// behaviours is the contract, in the order it is checked
+var behaviours = []behaviour{
+	{name: "create/success", check: checkCreateSuccess},
+	{name: "create/duplicate ID", check: checkCreateDuplicateID},
+	{name: "create/missing country", check: checkCreateMissing(func(a *accounts.AccountData) { a.Attributes.Country = nil })},
+	{name: "create/missing name", check: checkCreateMissing(func(a *accounts.AccountData) { a.Attributes.Name = nil })},
+	{name: "create/missing organisation ID", check: checkCreateMissing(func(a *accounts.AccountData) { a.OrganisationID = "" })},
+	{name: "create/missing type", check: checkCreateMissing(func(a *accounts.AccountData) { a.Type = "" })},
+	{name: "fetch/success", check: checkFetchSuccess},
+	{name: "fetch/not found", check: checkFetchNotFound},
+	{name: "fetch/invalid ID", check: checkFetchInvalidID},
+	{name: "list/page size", check: checkListPageSize},
+	{name: "delete/success", check: checkDeleteSuccess},
+	{name: "delete/not found", check: checkDeleteNotFound},
+	{name: "delete/wrong version", check: checkDeleteWrongVersion},
+	{name: "delete/invalid ID", check: checkDeleteInvalidID},
+}
+
+func isBehaviour(name string) bool {
+	for _, b := range behaviours {
+		if b.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expectStatus returns nil if err was caused by an API response with statusCode, or else an error describing what happened
+func expectStatus(err error, statusCode int, operation string) error {
+	if err == nil {
+		return fmt.Errorf("%s: expected status %d, got success", operation, statusCode)
+	}
+
+	if got := client.StatusCode(err); got != statusCode {
+		return fmt.Errorf("%s: expected status %d, got %d: %w", operation, statusCode, got, err)
+	}
+
+	return nil
+}
+
+// expectStored returns nil if resp holds account as stored by the API: at version 0, with creation and
+// modification times and a self link, and otherwise unchanged
+func expectStored(account accounts.AccountData, resp *accounts.Response, operation string) error {
+	if resp == nil || resp.Data == nil {
+		return fmt.Errorf("%s: response has no data", operation)
+	}
+
+	got := resp.Data.Clone()
+	if got.Version == nil || *got.Version != 0 {
+		return fmt.Errorf("%s: expected version 0, got %s", operation, describeVersion(got.Version))
+	}
+
+	if got.CreatedOn == nil || got.ModifiedOn == nil {
+		return fmt.Errorf("%s: expected created_on and modified_on to be set", operation)
+	}
+
+	if got.ModifiedOn.Before(*got.CreatedOn) {
+		return fmt.Errorf("%s: modified_on %s is before created_on %s", operation, got.ModifiedOn, got.CreatedOn)
+	}
+
+	if selfLink := "/v1/organisation/accounts/" + account.ID; resp.Links == nil || resp.Links.Self != selfLink {
+		return fmt.Errorf("%s: expected self link %q", operation, selfLink)
+	}
+
+	got.Version, got.CreatedOn, got.ModifiedOn = nil, nil, nil
+	if !reflect.DeepEqual(account, got) {
+		return fmt.Errorf("%s: stored account differs from the one sent\n sent: %s\n  got: %s", operation, asJSON(account), asJSON(got))
+	}
+
+	return nil
+}
+
+func describeVersion(version *int64) string {
+	if version == nil {
+		return "none"
+	}
+
+	return fmt.Sprint(*version)
+}
+
+func asJSON(account accounts.AccountData) string {
+	raw, err := json.Marshal(account)
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(raw)
+}
+
+func checkCreateSuccess(ctx context.Context, s *session) error {
+	account, err := s.account()
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.create(ctx, account)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	return expectStored(account, resp, "create")
+}
+
+func checkCreateDuplicateID(ctx context.Context, s *session) error {
+	account, err := s.account()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.create(ctx, account); err != nil {
+		return fmt.Errorf("first create: %w", err)
+	}
+
+	_, err = s.create(ctx, account)
+	return expectStatus(err, http.StatusConflict, "second create")
+}
+
+// checkCreateMissing returns a check that creating an account with a required field removed by clear is rejected
+func checkCreateMissing(clear func(account *accounts.AccountData)) func(ctx context.Context, s *session) error {
+	return func(ctx context.Context, s *session) error {
+		account, err := s.account()
+		if err != nil {
+			return err
+		}
+
+		clear(&account)
+
+		_, err = s.create(ctx, account)
+		return expectStatus(err, http.StatusBadRequest, "create")
+	}
+}
+
+func checkFetchSuccess(ctx context.Context, s *session) error {
+	account, err := s.account()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.create(ctx, account); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	resp, err := s.client.Fetch(ctx, account.ID)
+	if err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+
+	return expectStored(account, resp, "fetch")
+}
+
+func checkFetchNotFound(ctx context.Context, s *session) error {
+	_, err := s.client.Fetch(ctx, uuid.NewString())
+	return expectStatus(err, http.StatusNotFound, "fetch")
+}
+
+func checkFetchInvalidID(ctx context.Context, s *session) error {
+	_, err := s.client.Fetch(ctx, "not-a-uuid")
+	return expectStatus(err, http.StatusBadRequest, "fetch")
+}
+
+func checkListPageSize(ctx context.Context, s *session) error {
+	for idx := 0; idx < 2; idx++ {
+		account, err := s.account()
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.create(ctx, account); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+	}
+
+	resp, err := s.client.List(ctx, client.ListOptions{PageSize: 1})
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+
+	if len(resp.Data) != 1 {
+		return fmt.Errorf("list: expected 1 account on a page of size 1, got %d", len(resp.Data))
+	}
+
+	if resp.Links == nil || resp.Links.Next == "" {
+		return errors.New("list: expected a next link on the first page when there are more accounts")
+	}
+
+	return nil
+}
+
+func checkDeleteSuccess(ctx context.Context, s *session) error {
+	account, err := s.account()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.create(ctx, account); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	if err := s.client.Delete(ctx, account.ID, 0); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	_, err = s.client.Fetch(ctx, account.ID)
+	return expectStatus(err, http.StatusNotFound, "fetch after delete")
+}
+
+func checkDeleteNotFound(ctx context.Context, s *session) error {
+	err := s.client.Delete(ctx, uuid.NewString(), 0)
+	return expectStatus(err, http.StatusNotFound, "delete")
+}
+
+func checkDeleteWrongVersion(ctx context.Context, s *session) error {
+	account, err := s.account()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.create(ctx, account); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	err = s.client.Delete(ctx, account.ID, 1)
+	return expectStatus(err, http.StatusConflict, "delete")
+}
+
+func checkDeleteInvalidID(ctx context.Context, s *session) error {
+	err := s.client.Delete(ctx, "not-a-uuid", 0)
+	return expectStatus(err, http.StatusBadRequest, "delete")
+}
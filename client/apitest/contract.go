@@ -0,0 +1,239 @@
+// Package apitest checks that an implementation of the account API behaves as the client expects,
+// so that the same contract can be run against the docker-compose image, in-house fakes and staging:
+//
+//	func TestContract(t *testing.T) {
+//		apitest.RunContract(t, "http://localhost:8080")
+//	}
+//
+// Each behaviour, e.g. "create/duplicate ID", runs as its own subtest and a summary of the behaviours
+// the API differs in is logged at the end. Verify runs the same checks and returns the results
+// instead of failing a test. Accounts created by the checks are deleted afterwards.
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/accounts/fixtures"
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/google/uuid"
+)
+
+// checkTimeout is how long a single behaviour may take to check
+const checkTimeout = 30 * time.Second
+
+// Result is the outcome of checking one behaviour
+type Result struct {
+	Behaviour string
+	// Err describes how the API differs from the behaviour, nil if it does not
+	Err error
+}
+
+// Option configures a contract run
+type Option func(*config)
+
+type config struct {
+	organisationID string
+	seed           int64
+	clientOpts     []client.Option
+	skip           map[string]bool
+}
+
+// WithOrganisationID sets the organisation of the accounts created by the checks, by default a random one is used
+func WithOrganisationID(id string) Option {
+	return func(cfg *config) {
+		cfg.organisationID = id
+	}
+}
+
+// WithSeed seeds the accounts created by the checks, by default the current time is used
+func WithSeed(seed int64) Option {
+	return func(cfg *config) {
+		cfg.seed = seed
+	}
+}
+
+// WithClientOptions configures the client used to talk to the API, e.g. WithTLS to present a client certificate
+func WithClientOptions(opts ...client.Option) Option {
+	return func(cfg *config) {
+		cfg.clientOpts = append(cfg.clientOpts, opts...)
+	}
+}
+
+// Skip leaves out the named behaviours, e.g. for a known difference that is being worked on
+func Skip(behaviours ...string) Option {
+	return func(cfg *config) {
+		for _, name := range behaviours {
+			cfg.skip[name] = true
+		}
+	}
+}
+
+// Behaviours returns the names of the behaviours in the contract, in the order they are checked
+func Behaviours() []string {
+	names := make([]string, 0, len(behaviours))
+	for _, b := range behaviours {
+		names = append(names, b.name)
+	}
+
+	return names
+}
+
+// RunContract checks every behaviour against the API at baseURL as a subtest of t
+func RunContract(t *testing.T, baseURL string, opts ...Option) {
+	t.Helper()
+
+	s, err := newSession(baseURL, opts)
+	if err != nil {
+		t.Fatalf("apitest: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := s.cleanup(); err != nil {
+			t.Errorf("apitest: cleaning up: %v", err)
+		}
+	})
+
+	var differ []string
+	for _, b := range s.behaviours() {
+		b := b
+		t.Run(b.name, func(t *testing.T) {
+			if err := s.check(b); err != nil {
+				differ = append(differ, b.name)
+				t.Error(err)
+			}
+		})
+	}
+
+	if len(differ) > 0 {
+		t.Logf("apitest: %s differs from the contract in %d behaviours:\n  %s", baseURL, len(differ), strings.Join(differ, "\n  "))
+	}
+}
+
+// Verify checks every behaviour against the API at baseURL and returns the results in order.
+// The error is only non-nil if the checks could not be run, or the accounts they created could not be deleted.
+func Verify(baseURL string, opts ...Option) ([]Result, error) {
+	s, err := newSession(baseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, b := range s.behaviours() {
+		results = append(results, Result{Behaviour: b.name, Err: s.check(b)})
+	}
+
+	if err := s.cleanup(); err != nil {
+		return results, fmt.Errorf("cleaning up: %w", err)
+	}
+
+	return results, nil
+}
+
+// session is the state shared by the checks of one contract run
+type session struct {
+	cfg      config
+	client   *client.Client
+	fixtures *fixtures.Factory
+	// country is the country of the accounts the checks create
+	country string
+
+	mu sync.Mutex
+	// created are the IDs of the accounts created by the checks
+	created []string
+}
+
+func newSession(baseURL string, opts []Option) (*session, error) {
+	cfg := config{organisationID: uuid.NewString(), seed: time.Now().UnixNano(), skip: map[string]bool{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	for name := range cfg.skip {
+		if !isBehaviour(name) {
+			return nil, fmt.Errorf("cannot skip unknown behaviour %q", name)
+		}
+	}
+
+	c, err := client.NewClient(baseURL, nil, cfg.clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{
+		cfg:      cfg,
+		client:   c,
+		fixtures: fixtures.New(cfg.seed, fixtures.WithOrganisationID(cfg.organisationID)),
+		country:  "GB",
+	}, nil
+}
+
+// behaviours returns the behaviours that are not skipped
+func (s *session) behaviours() []behaviour {
+	var bs []behaviour
+	for _, b := range behaviours {
+		if !s.cfg.skip[b.name] {
+			bs = append(bs, b)
+		}
+	}
+
+	return bs
+}
+
+// check runs b with a time limit
+func (s *session) check(b behaviour) error {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	return b.check(ctx, s)
+}
+
+// account returns a new account to create
+func (s *session) account() (accounts.AccountData, error) {
+	account, err := s.fixtures.Account(s.country)
+	if err != nil {
+		return accounts.AccountData{}, fmt.Errorf("generating account: %w", err)
+	}
+
+	return account, nil
+}
+
+// create creates account, remembering it so that it is deleted by cleanup
+func (s *session) create(ctx context.Context, account accounts.AccountData) (*accounts.Response, error) {
+	s.mu.Lock()
+	s.created = append(s.created, account.ID)
+	s.mu.Unlock()
+
+	return s.client.Create(ctx, account)
+}
+
+// cleanup deletes the accounts created by the checks that are still there
+func (s *session) cleanup() error {
+	s.mu.Lock()
+	ids := s.created
+	s.created = nil
+	s.mu.Unlock()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	summary, err := s.client.DeleteMany(ctx, client.DeleteManyRequest{IDs: ids}, client.BulkOptions{})
+	if err != nil {
+		return err
+	}
+
+	if len(summary.Failed) > 0 {
+		return fmt.Errorf("%d accounts could not be deleted, first error: %w", len(summary.Failed), summary.Failed[0].Err)
+	}
+
+	return nil
+}
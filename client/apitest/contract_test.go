@@ -0,0 +1,153 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest/fakeapi"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// deviatingAPI wraps the in-memory API to break the contract in the ways it is told to
+type deviatingAPI struct {
+	*fakeapi.Server
+
+	// duplicateStatus, if set, is the status returned for a duplicate create instead of 409
+	duplicateStatus int
+	// ignorePageSize makes lists return every account on one page
+	ignorePageSize bool
+
+	mu sync.Mutex
+	// organisationIDs are the organisations of the accounts created
+	organisationIDs []string
+}
+
+func newDeviatingAPI() *deviatingAPI {
+	return &deviatingAPI{Server: fakeapi.NewServer()}
+}
+
+func (api *deviatingAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var req accounts.Request
+		if json.Unmarshal(body, &req) == nil && req.Data != nil {
+			api.mu.Lock()
+			if req.Data.OrganisationID != "" {
+				api.organisationIDs = append(api.organisationIDs, req.Data.OrganisationID)
+			}
+			api.mu.Unlock()
+
+			if _, ok := api.Account(req.Data.ID); ok && api.duplicateStatus != 0 {
+				w.WriteHeader(api.duplicateStatus)
+				_ = json.NewEncoder(w).Encode(accounts.ApiError{ErrMsg: "duplicate"})
+				return
+			}
+		}
+	case r.Method == http.MethodGet && api.ignorePageSize:
+		query := r.URL.Query()
+		query.Del("page[size]")
+		r.URL.RawQuery = query.Encode()
+	}
+
+	api.Server.ServeHTTP(w, r)
+}
+
+func TestRunContract_inMemoryAPI(t *testing.T) {
+	api := newDeviatingAPI()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	t.Run("contract", func(t *testing.T) {
+		RunContract(t, server.URL, WithSeed(1))
+	})
+
+	// the accounts created by the checks are cleaned up when the contract test ends
+	assert.Empty(t, api.Accounts())
+}
+
+func TestVerify_reportsDifferences(t *testing.T) {
+	api := newDeviatingAPI()
+	api.duplicateStatus = http.StatusBadRequest
+	api.ignorePageSize = true
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	results, err := Verify(server.URL, WithSeed(2))
+	assert.NoError(t, err)
+	assert.Len(t, results, len(Behaviours()))
+
+	differ := map[string]string{}
+	for _, result := range results {
+		if result.Err != nil {
+			differ[result.Behaviour] = result.Err.Error()
+		}
+	}
+
+	assert.Len(t, differ, 2)
+	assert.Contains(t, differ["create/duplicate ID"], "second create: expected status 409, got 400")
+	assert.Contains(t, differ["list/page size"], "list: expected 1 account on a page of size 1, got ")
+	assert.Empty(t, api.Accounts())
+}
+
+func TestVerify_skip(t *testing.T) {
+	api := newDeviatingAPI()
+	api.duplicateStatus = http.StatusBadRequest
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	results, err := Verify(server.URL, Skip("create/duplicate ID", "list/page size"))
+	assert.NoError(t, err)
+	assert.Len(t, results, len(Behaviours())-2)
+	for _, result := range results {
+		assert.NoError(t, result.Err, result.Behaviour)
+		assert.NotEqual(t, "create/duplicate ID", result.Behaviour)
+	}
+
+	_, err = Verify(server.URL, Skip("create/everything"))
+	assert.EqualError(t, err, `cannot skip unknown behaviour "create/everything"`)
+}
+
+func TestSession_fixtureErrorFailsBehaviour(t *testing.T) {
+	server := httptest.NewServer(newDeviatingAPI())
+	defer server.Close()
+
+	s, err := newSession(server.URL, nil)
+	assert.NoError(t, err)
+	s.country = "XX"
+
+	err = checkCreateSuccess(context.Background(), s)
+	assert.EqualError(t, err, `generating account: fixtures: unsupported country "XX"`)
+}
+
+func TestVerify_organisationID(t *testing.T) {
+	api := newDeviatingAPI()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	orgID := uuid.NewString()
+	_, err := Verify(server.URL, WithOrganisationID(orgID))
+	assert.NoError(t, err)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	assert.NotEmpty(t, api.organisationIDs)
+	for _, id := range api.organisationIDs {
+		assert.Equal(t, orgID, id)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const defaultBulkConcurrency = 10
+
+// ErrSkipped is the error recorded against items of a bulk operation that were never attempted
+// because the operation stopped early
+var ErrSkipped = errors.New("skipped because the bulk operation stopped early")
+
+// BulkOptions controls how bulk operations are carried out
+type BulkOptions struct {
+	// Concurrency is the number of items worked on at once, default 10
+	Concurrency int
+	// StopOnError stops new items being started once any item has failed.
+	// Items already in flight are allowed to finish.
+	StopOnError bool
+	// Progress, if set, is called each time an item finishes. Calls are never made concurrently.
+	Progress func(BulkProgress)
+}
+
+// BulkProgress describes how far through a bulk operation has got
+type BulkProgress struct {
+	Completed int
+	Failed    int
+	Total     int
+}
+
+// runBulk calls fn once for each of the n items of a bulk operation using a bounded pool of workers.
+// fn must record its own result and report whether the item failed by returning a non-nil error.
+// Items that are never started because ctx ended or because of opts.StopOnError are passed to skip.
+// The returned error is the first item error when opts.StopOnError caused items to be skipped, or ctx.Err()
+// if ctx ended. An item failing after every item has been started does not stop anything, so it is not returned.
+func runBulk(ctx context.Context, n int, opts BulkOptions, fn func(ctx context.Context, idx int) error, skip func(idx int, err error)) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	if concurrency > n {
+		concurrency = n
+	}
+
+	var (
+		mu       sync.Mutex
+		progress = BulkProgress{Total: n}
+		stopErr  error
+		wg       sync.WaitGroup
+	)
+
+	stopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return stopErr != nil
+	}
+
+	jobs := make(chan int)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				err := fn(ctx, idx)
+
+				mu.Lock()
+				progress.Completed++
+				if err != nil {
+					progress.Failed++
+					if opts.StopOnError && stopErr == nil {
+						stopErr = err
+					}
+				}
+
+				if opts.Progress != nil {
+					opts.Progress(progress)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	next := 0
+	for ; next < n; next++ {
+		if stopped() {
+			break
+		}
+
+		select {
+		case jobs <- next:
+			continue
+		case <-ctx.Done():
+		}
+
+		break
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	// anything not handed to a worker was skipped
+	for idx := next; idx < n; idx++ {
+		skip(idx, ErrSkipped)
+	}
+
+	if stopErr != nil && next < n {
+		return stopErr
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,286 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// Cache stores fetched accounts keyed by account ID.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(accountID string) (CacheEntry, bool)
+	Set(accountID string, entry CacheEntry)
+	Delete(accountID string)
+}
+
+// CacheEntry is a cached account
+type CacheEntry struct {
+	Response *accounts.Response
+	// Version is the version of the cached account
+	Version int64
+	// StoredAt is when the account was fetched from the API, or last confirmed unchanged by it
+	StoredAt time.Time
+	// ETag and LastModified are the validators the API returned with the account, used for conditional requests
+	ETag         string
+	LastModified string
+}
+
+// WithCache makes Fetch read through cache, serving accounts fetched less than ttl ago without calling the API.
+// Concurrent fetches of the same uncached account share a single request, and cached accounts are
+// invalidated when they are created or deleted through this client.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(cfg *config) error {
+		if cache == nil {
+			return newInputError("cache cannot be nil", nil)
+		}
+
+		if ttl <= 0 {
+			return newInputError("cache ttl must be positive", nil)
+		}
+
+		cfg.cache = newCacheLayer(cache, ttl)
+		return nil
+	}
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used account once it is full
+type LRUCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries *list.List
+	index   map[string]*list.Element
+}
+
+// lruItem is the value held by each element of LRUCache.entries
+type lruItem struct {
+	accountID string
+	entry     CacheEntry
+}
+
+// NewLRUCache returns an empty LRUCache that holds at most capacity accounts
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &LRUCache{
+		capacity: capacity,
+		entries:  list.New(),
+		index:    map[string]*list.Element{},
+	}
+}
+
+// Get returns the entry for accountID and marks it as recently used
+func (lru *LRUCache) Get(accountID string) (CacheEntry, bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	elem, ok := lru.index[accountID]
+	if !ok {
+		return CacheEntry{}, false
+	}
+
+	lru.entries.MoveToFront(elem)
+	return elem.Value.(*lruItem).entry, true
+}
+
+// Set stores entry for accountID, evicting the least recently used account if the cache is full
+func (lru *LRUCache) Set(accountID string, entry CacheEntry) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if elem, ok := lru.index[accountID]; ok {
+		elem.Value.(*lruItem).entry = entry
+		lru.entries.MoveToFront(elem)
+		return
+	}
+
+	lru.index[accountID] = lru.entries.PushFront(&lruItem{accountID: accountID, entry: entry})
+
+	if lru.entries.Len() > lru.capacity {
+		oldest := lru.entries.Back()
+		lru.entries.Remove(oldest)
+		delete(lru.index, oldest.Value.(*lruItem).accountID)
+	}
+}
+
+// Delete removes the entry for accountID if there is one
+func (lru *LRUCache) Delete(accountID string) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if elem, ok := lru.index[accountID]; ok {
+		lru.entries.Remove(elem)
+		delete(lru.index, accountID)
+	}
+}
+
+// Len returns the number of accounts in the cache
+func (lru *LRUCache) Len() int {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	return lru.entries.Len()
+}
+
+// cacheLayer is the middleware that serves fetches from a Cache
+type cacheLayer struct {
+	cache Cache
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu sync.Mutex
+	// flights are the API fetches currently in progress, keyed by account ID
+	flights map[string]*fetchFlight
+}
+
+// fetchFlight is a fetch from the API whose result is shared by every caller waiting on it
+type fetchFlight struct {
+	done chan struct{}
+	resp *OperationResponse
+	err  error
+
+	// invalidated is set if the account was written while the fetch was in progress,
+	// in which case the result may be stale and must not be cached. cacheLayer.mu guards it.
+	invalidated bool
+	// abandoned is set before done is closed if the fetch failed because the context of the caller that sent it ended,
+	// which says nothing about the account, so the callers waiting on it fetch it again themselves
+	abandoned bool
+}
+
+func newCacheLayer(cache Cache, ttl time.Duration) *cacheLayer {
+	return &cacheLayer{
+		cache:   cache,
+		ttl:     ttl,
+		now:     time.Now,
+		flights: map[string]*fetchFlight{},
+	}
+}
+
+// middleware serves fetches from the cache and invalidates accounts that are written through the client
+func (cl *cacheLayer) middleware(next Next) Next {
+	return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+		switch req.Operation {
+		case OperationFetch:
+			return cl.fetch(ctx, req, next)
+		case OperationCreate, OperationDelete:
+			// invalidate on the way in and out so that no fetch racing the write can cache the old account
+			cl.invalidate(req.AccountID)
+			defer cl.invalidate(req.AccountID)
+
+			return next(ctx, req)
+		default:
+			return next(ctx, req)
+		}
+	}
+}
+
+// fetch returns a copy of the cached account if it is fresh, and otherwise fetches it from the API
+// with concurrent callers for the same account sharing one request
+func (cl *cacheLayer) fetch(ctx context.Context, req *OperationRequest, next Next) (*OperationResponse, error) {
+	if entry, ok := cl.cache.Get(req.AccountID); ok && cl.now().Sub(entry.StoredAt) < cl.ttl {
+		return entry.operationResponse(), nil
+	}
+
+	cl.mu.Lock()
+	if flight, ok := cl.flights[req.AccountID]; ok {
+		cl.mu.Unlock()
+
+		resp, err := flight.wait(ctx)
+		if ctx.Err() == nil && flight.abandoned {
+			return cl.fetch(ctx, req, next)
+		}
+
+		return resp, err
+	}
+
+	flight := &fetchFlight{done: make(chan struct{})}
+	cl.flights[req.AccountID] = flight
+	cl.mu.Unlock()
+
+	flight.resp, flight.err = next(ctx, req)
+	flight.abandoned = flight.err != nil && ctx.Err() != nil
+
+	cl.mu.Lock()
+	delete(cl.flights, req.AccountID)
+	if flight.err == nil && !flight.invalidated {
+		cl.store(req.AccountID, flight.resp)
+	}
+	cl.mu.Unlock()
+
+	close(flight.done)
+
+	return flight.result()
+}
+
+// store caches the account in resp. cl.mu must be held.
+func (cl *cacheLayer) store(accountID string, resp *OperationResponse) {
+	if resp == nil || resp.Account == nil || resp.Account.Data == nil {
+		return
+	}
+
+	cl.cache.Set(accountID, newCacheEntry(resp, cl.now()))
+}
+
+// newCacheEntry returns an entry holding a copy of the account in resp, which must not be nil
+func newCacheEntry(resp *OperationResponse, storedAt time.Time) CacheEntry {
+	entry := CacheEntry{
+		Response:     resp.Account.Clone(),
+		StoredAt:     storedAt,
+		ETag:         resp.ETag,
+		LastModified: resp.LastModified,
+	}
+
+	if resp.Account.Data.Version != nil {
+		entry.Version = *resp.Account.Data.Version
+	}
+
+	return entry
+}
+
+// operationResponse returns a copy of the cached account as a fetch response
+func (entry CacheEntry) operationResponse() *OperationResponse {
+	return &OperationResponse{Account: entry.Response.Clone(), ETag: entry.ETag, LastModified: entry.LastModified}
+}
+
+// invalidate removes accountID from the cache and stops in-flight fetches from caching it
+func (cl *cacheLayer) invalidate(accountID string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if flight, ok := cl.flights[accountID]; ok {
+		flight.invalidated = true
+	}
+
+	cl.cache.Delete(accountID)
+}
+
+// wait blocks until the flight lands or ctx ends, whichever is first
+func (f *fetchFlight) wait(ctx context.Context) (*OperationResponse, error) {
+	select {
+	case <-f.done:
+		return f.result()
+	case <-ctx.Done():
+		return nil, newInternalError("gave up waiting for fetch of the same account", ctx.Err())
+	}
+}
+
+// result returns a copy of the flight's response so that callers sharing it cannot affect one another
+func (f *fetchFlight) result() (*OperationResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if f.resp == nil {
+		return nil, nil
+	}
+
+	resp := *f.resp
+	resp.Account = f.resp.Account.Clone()
+	return &resp, nil
+}
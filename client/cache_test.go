@@ -0,0 +1,335 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCache_invalidSettings(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cache  Cache
+		ttl    time.Duration
+		errMsg string
+	}{
+		{name: "nil cache", cache: nil, ttl: time.Minute, errMsg: "input error - cache cannot be nil"},
+		{name: "zero ttl", cache: NewLRUCache(1), ttl: 0, errMsg: "input error - cache ttl must be positive"},
+		{name: "negative ttl", cache: NewLRUCache(1), ttl: -time.Second, errMsg: "input error - cache ttl must be positive"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			_, err := NewClient("http://0.0.0.0:8080", &mockRoundTripper{}, WithCache(tc.cache, tc.ttl))
+			assert.Equal(t, tc.errMsg, err.Error())
+		})
+	}
+}
+
+func TestLRUCache_evictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRUCache(2)
+
+	lru.Set("a", CacheEntry{Version: 1})
+	lru.Set("b", CacheEntry{Version: 1})
+
+	// reading a makes b the least recently used
+	_, ok := lru.Get("a")
+	assert.True(t, ok)
+
+	lru.Set("c", CacheEntry{Version: 1})
+	assert.Equal(t, 2, lru.Len())
+
+	_, ok = lru.Get("b")
+	assert.False(t, ok)
+
+	_, ok = lru.Get("a")
+	assert.True(t, ok)
+
+	_, ok = lru.Get("c")
+	assert.True(t, ok)
+}
+
+func TestLRUCache_setReplacesAndDeleteRemoves(t *testing.T) {
+	lru := NewLRUCache(2)
+
+	lru.Set("a", CacheEntry{Version: 1})
+	lru.Set("a", CacheEntry{Version: 2})
+	assert.Equal(t, 1, lru.Len())
+
+	entry, ok := lru.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), entry.Version)
+
+	lru.Delete("a")
+	lru.Delete("missing")
+	assert.Equal(t, 0, lru.Len())
+}
+
+func TestCache_fetchServesFreshEntriesAndRefetchesStaleOnes(t *testing.T) {
+	var calls int
+	countingFetch := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			calls++
+			return &OperationResponse{Account: &accounts.Response{Data: &accounts.AccountData{ID: req.AccountID, Version: ptrInt64(3)}}}, nil
+		}
+	}
+
+	lru := NewLRUCache(10)
+	clock := &fakeClock{now: getDummyTime()}
+	cl := newCacheLayer(lru, time.Minute)
+	cl.now = clock.Now
+	handler := chain(nil, cl.middleware, countingFetch)
+
+	for i := 0; i < 3; i++ {
+		resp, err := handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+		assert.NoError(t, err)
+		assert.Equal(t, "a", resp.Account.Data.ID)
+	}
+
+	assert.Equal(t, 1, calls)
+
+	entry, ok := lru.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), entry.Version)
+	assert.Equal(t, getDummyTime(), entry.StoredAt)
+
+	clock.Advance(time.Minute)
+
+	_, err := handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCache_fetchReturnsCopies(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountIDA))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil, WithCache(NewLRUCache(10), time.Minute))
+	assert.NoError(t, err)
+
+	first, err := c.Fetch(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	first.Data.Attributes.Country = ptrStr("FR")
+
+	second, err := c.Fetch(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.Equal(t, "GB", *second.Data.Attributes.Country)
+	assert.Len(t, api.Requests(), 1)
+}
+
+func TestCache_errorsAreNotCached(t *testing.T) {
+	api := fakeapi.NewServer()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil, WithCache(NewLRUCache(10), time.Minute))
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Fetch(context.Background(), testMissingAccountID)
+		assert.Equal(t, 404, StatusCode(err))
+	}
+
+	assert.Len(t, api.Requests(), 2)
+}
+
+func TestCache_concurrentFetchesShareOneRequest(t *testing.T) {
+	release := make(chan struct{})
+	var calls int
+	var mu sync.Mutex
+
+	blockingFetch := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+
+			<-release
+			return &OperationResponse{Account: &accounts.Response{Data: &accounts.AccountData{ID: req.AccountID}}}, nil
+		}
+	}
+
+	cl := newCacheLayer(NewLRUCache(10), time.Minute)
+	handler := chain(nil, cl.middleware, blockingFetch)
+
+	const callers = 5
+	results := make(chan *OperationResponse, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			resp, err := handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+			assert.NoError(t, err)
+			results <- resp
+		}()
+	}
+
+	// wait for every caller to join the flight before letting it land
+	assert.Eventually(t, func() bool {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		return cl.flights["a"] != nil
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	var responses []*OperationResponse
+	for i := 0; i < callers; i++ {
+		resp := <-results
+		assert.Equal(t, "a", resp.Account.Data.ID)
+		responses = append(responses, resp)
+	}
+
+	assert.Equal(t, 1, calls)
+	// every caller gets its own copy
+	assert.NotSame(t, responses[0].Account, responses[1].Account)
+}
+
+func TestCache_waitingCallerGivesUpWhenContextEnds(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	blockingFetch := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			<-release
+			return &OperationResponse{}, nil
+		}
+	}
+
+	cl := newCacheLayer(NewLRUCache(10), time.Minute)
+	handler := chain(nil, cl.middleware, blockingFetch)
+
+	go func() {
+		_, _ = handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+	}()
+
+	assert.Eventually(t, func() bool {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		return cl.flights["a"] != nil
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := handler(ctx, &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+	assert.Equal(t, "internal error - gave up waiting for fetch of the same account: context canceled", err.Error())
+}
+
+func TestCache_waitingCallerRefetchesWhenFirstCallerCancels(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+
+	// the first fetch blocks until its caller gives up, later ones succeed
+	fetch := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			mu.Lock()
+			calls++
+			first := calls == 1
+			mu.Unlock()
+
+			if first {
+				<-ctx.Done()
+				return nil, newInternalError("failed to send http request", ctx.Err())
+			}
+
+			return &OperationResponse{Account: &accounts.Response{Data: &accounts.AccountData{ID: req.AccountID}}}, nil
+		}
+	}
+
+	cl := newCacheLayer(NewLRUCache(10), time.Minute)
+	handler := chain(nil, cl.middleware, fetch)
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := handler(firstCtx, &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+		firstErr <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		return cl.flights["a"] != nil
+	}, time.Second, time.Millisecond)
+
+	second := make(chan *OperationResponse, 1)
+	go func() {
+		resp, err := handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+		assert.NoError(t, err)
+		second <- resp
+	}()
+
+	// give the second caller time to join the flight before the first gives up
+	time.Sleep(20 * time.Millisecond)
+	cancelFirst()
+
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	select {
+	case resp := <-second:
+		assert.Equal(t, "a", resp.Account.Data.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("second caller did not get the account")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, calls)
+}
+
+func TestCache_writesInvalidate(t *testing.T) {
+	server := httptest.NewServer(fakeapi.NewServer(testAccount(testAccountIDA)))
+	defer server.Close()
+
+	lru := NewLRUCache(10)
+	c, err := NewClient(server.URL, nil, WithCache(lru, time.Minute))
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lru.Len())
+
+	err = c.Delete(context.Background(), testAccountIDA, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, lru.Len())
+
+	_, err = c.Fetch(context.Background(), testAccountIDA)
+	assert.Equal(t, 404, StatusCode(err))
+
+	// a stale entry for an account that is then created through the client is dropped
+	lru.Set(testAccountIDA, CacheEntry{Response: &accounts.Response{Data: &accounts.AccountData{ID: testAccountIDA}}, StoredAt: time.Now()})
+	_, err = c.Create(context.Background(), testAccount(testAccountIDA))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, lru.Len())
+}
+
+func TestCache_fetchRacingWriteIsNotCached(t *testing.T) {
+	lru := NewLRUCache(10)
+	cl := newCacheLayer(lru, time.Minute)
+
+	var handler Next
+	racingFetch := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			if req.Operation == OperationFetch {
+				// the account is deleted while the fetch is on the wire
+				_, _ = handler(ctx, &OperationRequest{Operation: OperationDelete, AccountID: req.AccountID})
+				return &OperationResponse{Account: &accounts.Response{Data: &accounts.AccountData{ID: req.AccountID}}}, nil
+			}
+
+			return &OperationResponse{}, nil
+		}
+	}
+	handler = chain(nil, cl.middleware, racingFetch)
+
+	resp, err := handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", resp.Account.Data.ID)
+	assert.Equal(t, 0, lru.Len())
+}
@@ -0,0 +1,200 @@
+// Package chaos provides a fault injecting http.RoundTripper for testing how code using the client copes
+// with an unreliable API, e.g. that circuit breaking and error handling work as intended.
+//
+// Faults are injected by rules, each of which fires with a probability and can carry on for a burst of requests,
+// or by a script that lists the fault, if any, for each request in turn:
+//
+//	transport, err := chaos.New(
+//		http.DefaultTransport,
+//		chaos.WithSeed(42),
+//		chaos.WithRule(chaos.Rule{Fault: chaos.ServerError(), Probability: 0.05, Burst: 10}),
+//		chaos.WithFault(0.1, chaos.Latency(200*time.Millisecond)),
+//	)
+//	c, err := client.NewClient(host, transport)
+//
+// Random choices come from a source seeded with WithSeed, so a run can be reproduced with the same seed
+// and the same sequence of requests.
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Rule injects Fault into requests at random
+type Rule struct {
+	Fault Fault
+	// Probability is the chance, from 0 to 1, of the rule firing for each request
+	Probability float64
+	// Burst is the number of requests in a row that get the fault once the rule fires, default 1
+	Burst int
+	// Match, if set, limits the rule to the requests it returns true for
+	Match func(req *http.Request) bool
+}
+
+// Option configures a Transport
+type Option func(*Transport) error
+
+// WithSeed seeds the random source so that runs are reproducible, by default the current time is used
+func WithSeed(seed int64) Option {
+	return func(t *Transport) error {
+		t.seed = seed
+		return nil
+	}
+}
+
+// WithRule adds a rule. Rules are tried in the order they were added and at most one fault is injected per request.
+func WithRule(rule Rule) Option {
+	return func(t *Transport) error {
+		if rule.Fault == nil {
+			return errors.New("chaos: rule fault cannot be nil")
+		}
+
+		if rule.Probability < 0 || rule.Probability > 1 {
+			return errors.New("chaos: rule probability must be between 0 and 1")
+		}
+
+		if rule.Burst < 0 {
+			return errors.New("chaos: rule burst cannot be negative")
+		}
+
+		if rule.Burst == 0 {
+			rule.Burst = 1
+		}
+
+		t.rules = append(t.rules, rule)
+		return nil
+	}
+}
+
+// WithFault adds a rule injecting fault into each request with the given probability
+func WithFault(probability float64, fault Fault) Option {
+	return WithRule(Rule{Fault: fault, Probability: probability})
+}
+
+// WithScript injects faults[i] into the i-th request, a nil fault lets the request through untouched.
+// Rules only apply once the script has run out.
+func WithScript(faults ...Fault) Option {
+	return func(t *Transport) error {
+		t.script = append(t.script, faults...)
+		return nil
+	}
+}
+
+// Transport is an http.RoundTripper that injects faults into requests before handing them to the next RoundTripper.
+// It is safe for concurrent use, though the order concurrent requests draw random numbers in is not reproducible.
+type Transport struct {
+	next   http.RoundTripper
+	seed   int64
+	rules  []Rule
+	script []Fault
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	disabled bool
+	// burstLeft is the number of requests left in the current burst of each rule
+	burstLeft []int
+	injected  map[string]int
+}
+
+// New returns a Transport sending requests that are not faulted, and those that faults pass on, to next.
+// A nil next means http.DefaultTransport.
+func New(next http.RoundTripper, opts ...Option) (*Transport, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &Transport{next: next, seed: time.Now().UnixNano(), injected: map[string]int{}}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+
+	t.rng = rand.New(rand.NewSource(t.seed))
+	t.burstLeft = make([]int, len(t.rules))
+	return t, nil
+}
+
+// Seed returns the seed of the random source, so that a failing run can be repeated
+func (t *Transport) Seed() int64 {
+	return t.seed
+}
+
+// SetEnabled turns fault injection on or off, e.g. to check that a client recovers once the faults stop.
+// The script and any burst in progress pick up where they left off when it is turned back on.
+func (t *Transport) SetEnabled(enabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.disabled = !enabled
+}
+
+// Injected returns the number of times each fault has been injected, by fault name
+func (t *Transport) Injected() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	injected := make(map[string]int, len(t.injected))
+	for name, n := range t.injected {
+		injected[name] = n
+	}
+
+	return injected
+}
+
+// RoundTrip injects the fault chosen for req, if any, or else sends it with the next RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault := t.choose(req)
+	if fault == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	return fault.Inject(req, t.next)
+}
+
+// choose returns the fault to inject into req, nil for none
+func (t *Transport) choose(req *http.Request) Fault {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.disabled {
+		return nil
+	}
+
+	if len(t.script) > 0 {
+		fault := t.script[0]
+		t.script = t.script[1:]
+		return t.record(fault)
+	}
+
+	for idx, rule := range t.rules {
+		if rule.Match != nil && !rule.Match(req) {
+			continue
+		}
+
+		if t.burstLeft[idx] > 0 {
+			t.burstLeft[idx]--
+			return t.record(rule.Fault)
+		}
+
+		if t.rng.Float64() < rule.Probability {
+			t.burstLeft[idx] = rule.Burst - 1
+			return t.record(rule.Fault)
+		}
+	}
+
+	return nil
+}
+
+// record counts fault as injected and returns it. t.mu must be held.
+func (t *Transport) record(fault Fault) Fault {
+	if fault != nil {
+		t.injected[fault.Name()]++
+	}
+
+	return fault
+}
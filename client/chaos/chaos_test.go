@@ -0,0 +1,143 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingFault is a Fault that answers every request it is injected into with a 418
+type countingFault struct {
+	name string
+}
+
+func (cf countingFault) Name() string {
+	return cf.name
+}
+
+func (cf countingFault) Inject(req *http.Request, _ http.RoundTripper) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody, Request: req, Header: http.Header{"X-Fault": []string{cf.name}}}, nil
+}
+
+// okTransport answers every request with a 200
+type okTransport struct{}
+
+func (okTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req, Header: http.Header{}}, nil
+}
+
+// faultsFor sends n requests through transport and returns the fault injected into each, "" for none
+func faultsFor(t *testing.T, transport http.RoundTripper, method string, n int) []string {
+	faults := make([]string, 0, n)
+	for idx := 0; idx < n; idx++ {
+		req := httptest.NewRequest(method, "http://api/v1/organisation/accounts", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		faults = append(faults, resp.Header.Get("X-Fault"))
+	}
+
+	return faults
+}
+
+func TestTransport_script(t *testing.T) {
+	a, b := countingFault{name: "a"}, countingFault{name: "b"}
+	transport, err := New(okTransport{}, WithScript(a, nil, b, b), WithFault(1, countingFault{name: "rule"}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "", "b", "b", "rule"}, faultsFor(t, transport, http.MethodGet, 5))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "rule": 1}, transport.Injected())
+}
+
+func TestTransport_seededRulesAreReproducible(t *testing.T) {
+	newTransport := func(seed int64) *Transport {
+		transport, err := New(
+			okTransport{},
+			WithSeed(seed),
+			WithRule(Rule{Fault: countingFault{name: "burst"}, Probability: 0.1, Burst: 3}),
+			WithFault(0.3, countingFault{name: "flaky"}),
+		)
+		assert.NoError(t, err)
+		return transport
+	}
+
+	first := faultsFor(t, newTransport(7), http.MethodGet, 200)
+	assert.Equal(t, first, faultsFor(t, newTransport(7), http.MethodGet, 200))
+	assert.NotEqual(t, first, faultsFor(t, newTransport(8), http.MethodGet, 200))
+	assert.Equal(t, int64(7), newTransport(7).Seed())
+
+	// every burst lasts three requests
+	transport := newTransport(7)
+	assert.Empty(t, transport.Injected())
+
+	faults := faultsFor(t, transport, http.MethodGet, 200)
+	for idx := 0; idx < len(faults); {
+		if faults[idx] != "burst" {
+			idx++
+			continue
+		}
+
+		end := idx
+		for end < len(faults) && faults[end] == "burst" {
+			end++
+		}
+
+		assert.Zero(t, (end-idx)%3, "burst at request %d lasted %d requests", idx, end-idx)
+		idx = end
+	}
+
+	assert.Equal(t, transport.Injected()["burst"]+transport.Injected()["flaky"], 200-countEmpty(faults))
+}
+
+func countEmpty(faults []string) int {
+	n := 0
+	for _, fault := range faults {
+		if fault == "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestTransport_probabilityMatchAndEnabled(t *testing.T) {
+	onlyDeletes := func(req *http.Request) bool { return req.Method == http.MethodDelete }
+	transport, err := New(
+		okTransport{},
+		WithRule(Rule{Fault: countingFault{name: "delete"}, Probability: 1, Match: onlyDeletes}),
+		WithFault(0, countingFault{name: "never"}),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"", ""}, faultsFor(t, transport, http.MethodGet, 2))
+	assert.Equal(t, []string{"delete", "delete"}, faultsFor(t, transport, http.MethodDelete, 2))
+
+	transport.SetEnabled(false)
+	assert.Equal(t, []string{""}, faultsFor(t, transport, http.MethodDelete, 1))
+
+	transport.SetEnabled(true)
+	assert.Equal(t, []string{"delete"}, faultsFor(t, transport, http.MethodDelete, 1))
+}
+
+func TestNew_invalidOptions(t *testing.T) {
+	testCases := []struct {
+		name   string
+		option Option
+		errMsg string
+	}{
+		{name: "nil fault", option: WithFault(0.5, nil), errMsg: "chaos: rule fault cannot be nil"},
+		{name: "probability above 1", option: WithFault(1.5, ServerError()), errMsg: "chaos: rule probability must be between 0 and 1"},
+		{name: "negative probability", option: WithFault(-0.1, ServerError()), errMsg: "chaos: rule probability must be between 0 and 1"},
+		{name: "negative burst", option: WithRule(Rule{Fault: ServerError(), Burst: -1}), errMsg: "chaos: rule burst cannot be negative"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			transport, err := New(nil, tc.option)
+			assert.Nil(t, transport)
+			assert.EqualError(t, err, tc.errMsg)
+		})
+	}
+}
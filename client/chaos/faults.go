@@ -0,0 +1,203 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Fault is a failure injected into a request
+type Fault interface {
+	// Name identifies the fault in the counts returned by Transport.Injected
+	Name() string
+	// Inject handles req in place of next, which it may or may not call
+	Inject(req *http.Request, next http.RoundTripper) (*http.Response, error)
+}
+
+// Latency delays the request by d before sending it, giving up early if the request's context ends
+func Latency(d time.Duration) Fault {
+	return latency{delay: d}
+}
+
+type latency struct {
+	delay time.Duration
+}
+
+func (l latency) Name() string {
+	return "latency"
+}
+
+func (l latency) Inject(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	if err := sleep(req.Context(), l.delay); err != nil {
+		return nil, err
+	}
+
+	return next.RoundTrip(req)
+}
+
+// sleep waits for d or until ctx ends
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ConnectionReset fails the request as if the connection was reset by the server, without sending it
+func ConnectionReset() Fault {
+	return connectionReset{}
+}
+
+type connectionReset struct{}
+
+func (connectionReset) Name() string {
+	return "connection_reset"
+}
+
+func (connectionReset) Inject(req *http.Request, _ http.RoundTripper) (*http.Response, error) {
+	return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+}
+
+// TruncatedBody sends the request but cuts the response body off half way,
+// reading it fails with io.ErrUnexpectedEOF as if the connection dropped
+func TruncatedBody() Fault {
+	return truncatedBody{}
+}
+
+type truncatedBody struct{}
+
+func (truncatedBody) Name() string {
+	return "truncated_body"
+}
+
+func (truncatedBody) Inject(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	resp, body, err := roundTripAndRead(req, next)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body[:len(body)/2]), errReader{err: io.ErrUnexpectedEOF}))
+	return resp, nil
+}
+
+// errReader fails every read with err
+type errReader struct {
+	err error
+}
+
+func (er errReader) Read([]byte) (int, error) {
+	return 0, er.err
+}
+
+// MalformedJSON sends the request but replaces the response body with the first half of it,
+// so the whole body can be read but is not valid JSON
+func MalformedJSON() Fault {
+	return malformedJSON{}
+}
+
+type malformedJSON struct{}
+
+func (malformedJSON) Name() string {
+	return "malformed_json"
+}
+
+func (malformedJSON) Inject(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	resp, body, err := roundTripAndRead(req, next)
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty body, e.g. of a 204, is still cut down to something that is not JSON
+	malformed := append(body[:len(body)/2:len(body)/2], '{')
+	resp.Body = io.NopCloser(bytes.NewReader(malformed))
+	resp.ContentLength = int64(len(malformed))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// roundTripAndRead sends req with next and reads the whole response body
+func roundTripAndRead(req *http.Request, next http.RoundTripper) (*http.Response, []byte, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, body, nil
+}
+
+// Status answers the request with statusCode and an API error body without sending it
+func Status(statusCode int) Fault {
+	return status{statusCode: statusCode}
+}
+
+// ServerError answers the request with a 503 Service Unavailable without sending it
+func ServerError() Fault {
+	return Status(http.StatusServiceUnavailable)
+}
+
+type status struct {
+	statusCode int
+	header     http.Header
+}
+
+func (s status) Name() string {
+	return "status_" + strconv.Itoa(s.statusCode)
+}
+
+func (s status) Inject(req *http.Request, _ http.RoundTripper) (*http.Response, error) {
+	body, err := json.Marshal(map[string]string{"error_message": fmt.Sprintf("injected %d %s", s.statusCode, strings.ToLower(http.StatusText(s.statusCode)))})
+	if err != nil {
+		return nil, err
+	}
+
+	header := s.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	header.Set("Content-Type", "application/vnd.api+json")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", s.statusCode, http.StatusText(s.statusCode)),
+		StatusCode:    s.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// TooManyRequests answers the request with a 429 Too Many Requests without sending it.
+// The response has a Retry-After header of retryAfter, rounded up to whole seconds, if it is positive.
+func TooManyRequests(retryAfter time.Duration) Fault {
+	fault := status{statusCode: http.StatusTooManyRequests}
+	if retryAfter > 0 {
+		seconds := int((retryAfter + time.Second - 1) / time.Second)
+		fault.header = http.Header{"Retry-After": []string{strconv.Itoa(seconds)}}
+	}
+
+	return fault
+}
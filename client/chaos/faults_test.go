@@ -0,0 +1,167 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAccountID = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+
+// newAccountServer returns a server that answers every fetch with the same account
+func newAccountServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := int64(0)
+		_ = json.NewEncoder(w).Encode(accounts.Response{Data: &accounts.AccountData{ID: testAccountID, Version: &version}})
+	}))
+}
+
+func TestFaults(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fault   Fault
+		timeout time.Duration
+		check   func(t *testing.T, err error)
+	}{
+		{
+			name:  "connection reset",
+			fault: ConnectionReset(),
+			check: func(t *testing.T, err error) {
+				assert.True(t, client.IsInternalError(err))
+				assert.ErrorIs(t, err, syscall.ECONNRESET)
+			},
+		},
+		{
+			name:  "truncated body",
+			fault: TruncatedBody(),
+			check: func(t *testing.T, err error) {
+				assert.True(t, client.IsInternalError(err))
+				assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			},
+		},
+		{
+			name:  "malformed json",
+			fault: MalformedJSON(),
+			check: func(t *testing.T, err error) {
+				assert.True(t, client.IsInternalError(err))
+				assert.Contains(t, err.Error(), "failed to unmarshal response body")
+			},
+		},
+		{
+			name:  "server error",
+			fault: ServerError(),
+			check: func(t *testing.T, err error) {
+				assert.True(t, client.IsAPIError(err))
+				assert.Equal(t, http.StatusServiceUnavailable, client.StatusCode(err))
+				assert.Contains(t, err.Error(), "injected 503 service unavailable")
+			},
+		},
+		{
+			name:  "too many requests",
+			fault: TooManyRequests(1500 * time.Millisecond),
+			check: func(t *testing.T, err error) {
+				assert.Equal(t, http.StatusTooManyRequests, client.StatusCode(err))
+			},
+		},
+		{
+			name:    "latency beyond the deadline",
+			fault:   Latency(time.Second),
+			timeout: 20 * time.Millisecond,
+			check: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+		{
+			name:    "latency within the deadline",
+			fault:   Latency(time.Millisecond),
+			timeout: time.Second,
+			check: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	server := newAccountServer()
+	defer server.Close()
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			transport, err := New(nil, WithScript(tc.fault))
+			assert.NoError(t, err)
+
+			c, err := client.NewClient(server.URL, transport)
+			assert.NoError(t, err)
+
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			_, err = c.Fetch(ctx, testAccountID)
+			tc.check(t, err)
+			assert.Equal(t, map[string]int{tc.fault.Name(): 1}, transport.Injected())
+
+			// the script has run out so the next request gets through
+			_, err = c.Fetch(context.Background(), testAccountID)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTooManyRequests_retryAfter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://api/", nil)
+
+	resp, err := TooManyRequests(1500*time.Millisecond).Inject(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+	assert.Equal(t, "status_429", TooManyRequests(0).Name())
+
+	resp, err = TooManyRequests(0).Inject(req, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Retry-After"))
+}
+
+func TestServerErrorBurst_tripsCircuitBreaker(t *testing.T) {
+	server := newAccountServer()
+	defer server.Close()
+
+	transport, err := New(nil, WithSeed(1), WithRule(Rule{Fault: ServerError(), Probability: 1, Burst: 5}))
+	assert.NoError(t, err)
+
+	c, err := client.NewClient(
+		server.URL,
+		transport,
+		client.WithCircuitBreaker(client.CircuitBreakerConfig{ConsecutiveFailures: 5, OpenTimeout: 50 * time.Millisecond}),
+	)
+	assert.NoError(t, err)
+
+	for idx := 0; idx < 5; idx++ {
+		_, err = c.Fetch(context.Background(), testAccountID)
+		assert.Equal(t, http.StatusServiceUnavailable, client.StatusCode(err))
+	}
+
+	// the breaker is open so requests fail fast without reaching the transport
+	_, err = c.Fetch(context.Background(), testAccountID)
+	assert.True(t, errors.Is(err, client.ErrCircuitOpen))
+	assert.Equal(t, 5, transport.Injected()["status_503"])
+
+	// once the faults stop the breaker lets a probe through and closes again
+	transport.SetEnabled(false)
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = c.Fetch(context.Background(), testAccountID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,375 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitWindow              = time.Minute
+	defaultCircuitMinRequests         = 10
+	defaultCircuitFailureRate         = 0.5
+	defaultCircuitConsecutiveFailures = 5
+	defaultCircuitOpenTimeout         = 30 * time.Second
+	defaultCircuitHalfOpenRequests    = 1
+
+	// circuitWindowBuckets is the number of buckets the sliding window is divided into
+	circuitWindowBuckets = 10
+)
+
+// ErrCircuitOpen is returned, wrapped in a client error, when a request is rejected because
+// the circuit breaker for its endpoint is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker for a single endpoint
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to test whether the endpoint has recovered
+	CircuitHalfOpen
+
+	circuitClosedStr   = "closed"
+	circuitOpenStr     = "open"
+	circuitHalfOpenStr = "half-open"
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return circuitClosedStr
+	case CircuitOpen:
+		return circuitOpenStr
+	case CircuitHalfOpen:
+		return circuitHalfOpenStr
+	default:
+		return ""
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breaker. Zero values are replaced with defaults.
+type CircuitBreakerConfig struct {
+	// Window is the length of the sliding window that failures are counted over, default 1 minute.
+	// It is split into 10 buckets so it must be at least 10 nanoseconds.
+	Window time.Duration
+	// MinRequests is the number of requests there must be in the window before the failure rate is considered, default 10
+	MinRequests int
+	// FailureRateThreshold trips the breaker once this fraction of requests in the window have failed, default 0.5
+	FailureRateThreshold float64
+	// ConsecutiveFailures trips the breaker once this many requests in a row have failed, default 5
+	ConsecutiveFailures int
+	// OpenTimeout is how long the breaker stays open before letting probe requests through, default 30 seconds
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the number of probe requests that must succeed to close the breaker again, default 1
+	HalfOpenMaxRequests int
+	// OnStateChange, if set, is called with the base URL of an endpoint whenever its breaker changes state
+	OnStateChange func(endpoint string, from, to CircuitState)
+}
+
+// WithCircuitBreaker makes the client stop sending requests to an endpoint that keeps failing.
+// Each endpoint is tracked separately, so with WithEndpoints requests go to the other endpoints while the
+// breaker of one is open. Requests that no endpoint will accept fail fast with an error wrapping ErrCircuitOpen.
+func WithCircuitBreaker(cbConfig CircuitBreakerConfig) Option {
+	return func(cfg *config) error {
+		if cbConfig.Window < 0 || cbConfig.OpenTimeout < 0 || cbConfig.MinRequests < 0 ||
+			cbConfig.ConsecutiveFailures < 0 || cbConfig.HalfOpenMaxRequests < 0 {
+			return newInputError("circuit breaker settings cannot be negative", nil)
+		}
+
+		// the window is split into buckets of at least a nanosecond each
+		if cbConfig.Window != 0 && cbConfig.Window < circuitWindowBuckets {
+			return newInputError(fmt.Sprintf("circuit breaker window must be 0 or at least %s", time.Duration(circuitWindowBuckets)), nil)
+		}
+
+		if cbConfig.FailureRateThreshold < 0 || cbConfig.FailureRateThreshold > 1 {
+			return newInputError("circuit breaker failure rate threshold must be between 0 and 1", nil)
+		}
+
+		cfg.circuitBreaker = newCircuitBreaker(cbConfig)
+		return nil
+	}
+}
+
+// circuitBreaker tracks the health of each endpoint and decides whether requests may be sent to it
+type circuitBreaker struct {
+	cfg CircuitBreakerConfig
+	now func() time.Time
+	// baseURLs are the endpoints the client sends requests to, set by NewClient
+	baseURLs []string
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointCircuit
+}
+
+// endpointCircuit is the circuit breaker state of a single endpoint
+type endpointCircuit struct {
+	state               CircuitState
+	openedAt            time.Time
+	consecutiveFailures int
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+	buckets             [circuitWindowBuckets]windowBucket
+}
+
+// windowBucket counts the outcomes of requests that completed during one slice of the sliding window
+type windowBucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+// circuitOutcome is how a request affects the circuit breaker
+type circuitOutcome int
+
+const (
+	circuitSuccess circuitOutcome = iota
+	circuitFailure
+	// circuitIgnored is used for requests that say nothing about the health of the endpoint e.g. cancelled requests
+	circuitIgnored
+)
+
+// stateChange is a transition that must be reported to CircuitBreakerConfig.OnStateChange
+type stateChange struct {
+	endpoint string
+	from, to CircuitState
+}
+
+// newCircuitBreaker returns a circuitBreaker with defaults applied to cfg
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	if cfg.Window == 0 {
+		cfg.Window = defaultCircuitWindow
+	}
+
+	if cfg.MinRequests == 0 {
+		cfg.MinRequests = defaultCircuitMinRequests
+	}
+
+	if cfg.FailureRateThreshold == 0 {
+		cfg.FailureRateThreshold = defaultCircuitFailureRate
+	}
+
+	if cfg.ConsecutiveFailures == 0 {
+		cfg.ConsecutiveFailures = defaultCircuitConsecutiveFailures
+	}
+
+	if cfg.OpenTimeout == 0 {
+		cfg.OpenTimeout = defaultCircuitOpenTimeout
+	}
+
+	if cfg.HalfOpenMaxRequests == 0 {
+		cfg.HalfOpenMaxRequests = defaultCircuitHalfOpenRequests
+	}
+
+	return &circuitBreaker{
+		cfg:       cfg,
+		now:       time.Now,
+		endpoints: map[string]*endpointCircuit{},
+	}
+}
+
+// middleware rejects operations while the breaker of every endpoint is open, so that they fail before using up
+// any rate limit budget. Whether a request may go to a particular endpoint is decided as it is sent.
+func (cb *circuitBreaker) middleware(next Next) Next {
+	return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+		if cb.rejectsAll() {
+			return nil, newUnavailableError("requests to every endpoint are being rejected", ErrCircuitOpen)
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// rejectsAll reports whether the breaker of every endpoint is open and not yet letting probe requests through
+func (cb *circuitBreaker) rejectsAll() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	for _, endpoint := range cb.baseURLs {
+		ec, ok := cb.endpoints[endpoint]
+		if !ok || ec.state != CircuitOpen || cb.now().Sub(ec.openedAt) >= cb.cfg.OpenTimeout {
+			return false
+		}
+	}
+
+	return len(cb.baseURLs) > 0
+}
+
+// state returns the current state of the breaker for endpoint
+func (cb *circuitBreaker) state(endpoint string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.endpoint(endpoint).state
+}
+
+// allow decides whether a request may be sent to endpoint.
+// If it may, the returned function must be called with the outcome of the request.
+func (cb *circuitBreaker) allow(endpoint string) (func(circuitOutcome), error) {
+	cb.mu.Lock()
+
+	var changes []stateChange
+	ec := cb.endpoint(endpoint)
+
+	if ec.state == CircuitOpen {
+		if cb.now().Sub(ec.openedAt) < cb.cfg.OpenTimeout {
+			cb.mu.Unlock()
+			return nil, ErrCircuitOpen
+		}
+
+		changes = append(changes, cb.transition(endpoint, ec, CircuitHalfOpen))
+	}
+
+	if ec.state == CircuitHalfOpen {
+		if ec.halfOpenInFlight >= cb.cfg.HalfOpenMaxRequests {
+			cb.mu.Unlock()
+			cb.notify(changes)
+			return nil, ErrCircuitOpen
+		}
+
+		ec.halfOpenInFlight++
+	}
+
+	admittedIn := ec.state
+	cb.mu.Unlock()
+	cb.notify(changes)
+
+	return func(outcome circuitOutcome) {
+		cb.record(endpoint, admittedIn, outcome)
+	}, nil
+}
+
+// record updates the breaker for endpoint with the outcome of a request that was admitted in state admittedIn
+func (cb *circuitBreaker) record(endpoint string, admittedIn CircuitState, outcome circuitOutcome) {
+	cb.mu.Lock()
+
+	var changes []stateChange
+	ec := cb.endpoint(endpoint)
+
+	switch {
+	case admittedIn == CircuitHalfOpen && ec.state == CircuitHalfOpen:
+		ec.halfOpenInFlight--
+
+		switch outcome {
+		case circuitFailure:
+			changes = append(changes, cb.transition(endpoint, ec, CircuitOpen))
+		case circuitSuccess:
+			ec.halfOpenSuccesses++
+			if ec.halfOpenSuccesses >= cb.cfg.HalfOpenMaxRequests {
+				changes = append(changes, cb.transition(endpoint, ec, CircuitClosed))
+			}
+		}
+	case admittedIn == CircuitClosed && ec.state == CircuitClosed:
+		if outcome == circuitIgnored {
+			break
+		}
+
+		cb.addToWindow(ec, outcome)
+		if cb.shouldTrip(ec) {
+			changes = append(changes, cb.transition(endpoint, ec, CircuitOpen))
+		}
+	}
+
+	cb.mu.Unlock()
+	cb.notify(changes)
+}
+
+// endpoint returns the state of endpoint, creating it if it does not yet exist. cb.mu must be held.
+func (cb *circuitBreaker) endpoint(endpoint string) *endpointCircuit {
+	ec, ok := cb.endpoints[endpoint]
+	if !ok {
+		ec = &endpointCircuit{}
+		cb.endpoints[endpoint] = ec
+	}
+
+	return ec
+}
+
+// transition moves ec to state to and resets the counters belonging to the state it leaves. cb.mu must be held.
+func (cb *circuitBreaker) transition(endpoint string, ec *endpointCircuit, to CircuitState) stateChange {
+	change := stateChange{endpoint: endpoint, from: ec.state, to: to}
+
+	ec.state = to
+	ec.consecutiveFailures = 0
+	ec.halfOpenInFlight = 0
+	ec.halfOpenSuccesses = 0
+	ec.buckets = [circuitWindowBuckets]windowBucket{}
+
+	if to == CircuitOpen {
+		ec.openedAt = cb.now()
+	}
+
+	return change
+}
+
+// addToWindow counts outcome in the current bucket of the sliding window. cb.mu must be held.
+func (cb *circuitBreaker) addToWindow(ec *endpointCircuit, outcome circuitOutcome) {
+	bucketWidth := cb.cfg.Window / circuitWindowBuckets
+	now := cb.now()
+	start := now.Truncate(bucketWidth)
+	bucket := &ec.buckets[(start.UnixNano()/int64(bucketWidth))%circuitWindowBuckets]
+
+	// a bucket left over from a previous pass of the window is stale
+	if !bucket.start.Equal(start) {
+		*bucket = windowBucket{start: start}
+	}
+
+	if outcome == circuitFailure {
+		bucket.failures++
+		ec.consecutiveFailures++
+	} else {
+		bucket.successes++
+		ec.consecutiveFailures = 0
+	}
+}
+
+// shouldTrip reports whether ec has seen enough failures to open. cb.mu must be held.
+func (cb *circuitBreaker) shouldTrip(ec *endpointCircuit) bool {
+	if ec.consecutiveFailures >= cb.cfg.ConsecutiveFailures {
+		return true
+	}
+
+	windowStart := cb.now().Add(-cb.cfg.Window)
+	var total, failures int
+	for _, bucket := range ec.buckets {
+		if bucket.start.After(windowStart) {
+			total += bucket.successes + bucket.failures
+			failures += bucket.failures
+		}
+	}
+
+	return total >= cb.cfg.MinRequests && float64(failures)/float64(total) >= cb.cfg.FailureRateThreshold
+}
+
+// notify reports changes to the OnStateChange callback. It must be called without holding cb.mu
+// so that callbacks are free to use the client.
+func (cb *circuitBreaker) notify(changes []stateChange) {
+	if cb.cfg.OnStateChange == nil {
+		return
+	}
+
+	for _, change := range changes {
+		cb.cfg.OnStateChange(change.endpoint, change.from, change.to)
+	}
+}
+
+// classifyCircuitOutcome decides how the result of a http request reflects on the health of its endpoint.
+// Server errors, rate limiting and failures to get a response at all count against the endpoint while
+// requests that callers cancelled are ignored. Any other answer, e.g. a 404 or 409, shows the endpoint is healthy.
+func classifyCircuitOutcome(ctx context.Context, resp *http.Response, err error) circuitOutcome {
+	if ctx.Err() != nil {
+		return circuitIgnored
+	}
+
+	if endpointFailed(resp, err) {
+		return circuitFailure
+	}
+
+	return circuitSuccess
+}
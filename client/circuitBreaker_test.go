@@ -0,0 +1,291 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a controllable time source for the circuit breaker
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time {
+	return fc.now
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.now = fc.now.Add(d)
+}
+
+func newTestCircuitBreaker(cfg CircuitBreakerConfig) (*circuitBreaker, *fakeClock) {
+	clock := &fakeClock{now: getDummyTime()}
+	cb := newCircuitBreaker(cfg)
+	cb.now = clock.Now
+
+	return cb, clock
+}
+
+// sendThroughBreaker admits a request to endpoint and records outcome for it
+func sendThroughBreaker(t *testing.T, cb *circuitBreaker, endpoint string, outcome circuitOutcome) {
+	done, err := cb.allow(endpoint)
+	assert.NoError(t, err)
+	done(outcome)
+}
+
+func TestWithCircuitBreaker_invalidConfigReturnsError(t *testing.T) {
+	_, err := NewClient("http://0.0.0.0:8080", nil, WithCircuitBreaker(CircuitBreakerConfig{Window: -time.Second}))
+	assert.Equal(t, "input error - circuit breaker settings cannot be negative", err.Error())
+
+	_, err = NewClient("http://0.0.0.0:8080", nil, WithCircuitBreaker(CircuitBreakerConfig{Window: 9 * time.Nanosecond}))
+	assert.Equal(t, "input error - circuit breaker window must be 0 or at least 10ns", err.Error())
+
+	_, err = NewClient("http://0.0.0.0:8080", nil, WithCircuitBreaker(CircuitBreakerConfig{FailureRateThreshold: 1.5}))
+	assert.Equal(t, "input error - circuit breaker failure rate threshold must be between 0 and 1", err.Error())
+}
+
+func TestCircuitBreaker_tripsOnConsecutiveFailures(t *testing.T) {
+	cb, _ := newTestCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 3, MinRequests: 100})
+
+	sendThroughBreaker(t, cb, primaryURL, circuitFailure)
+	sendThroughBreaker(t, cb, primaryURL, circuitFailure)
+	sendThroughBreaker(t, cb, primaryURL, circuitSuccess)
+	sendThroughBreaker(t, cb, primaryURL, circuitFailure)
+	sendThroughBreaker(t, cb, primaryURL, circuitFailure)
+	assert.Equal(t, CircuitClosed, cb.state(primaryURL))
+
+	sendThroughBreaker(t, cb, primaryURL, circuitFailure)
+	assert.Equal(t, CircuitOpen, cb.state(primaryURL))
+
+	_, err := cb.allow(primaryURL)
+	assert.Equal(t, ErrCircuitOpen, err)
+}
+
+func TestCircuitBreaker_tripsOnFailureRate(t *testing.T) {
+	cb, clock := newTestCircuitBreaker(CircuitBreakerConfig{
+		Window:               10 * time.Second,
+		MinRequests:          4,
+		FailureRateThreshold: 0.5,
+		ConsecutiveFailures:  100,
+	})
+
+	sendThroughBreaker(t, cb, secondaryURL, circuitFailure)
+	sendThroughBreaker(t, cb, secondaryURL, circuitSuccess)
+	sendThroughBreaker(t, cb, secondaryURL, circuitFailure)
+	assert.Equal(t, CircuitClosed, cb.state(secondaryURL), "too few requests to consider the failure rate")
+
+	sendThroughBreaker(t, cb, secondaryURL, circuitSuccess)
+	assert.Equal(t, CircuitOpen, cb.state(secondaryURL))
+
+	// failures that have slid out of the window no longer count
+	cb, clock = newTestCircuitBreaker(CircuitBreakerConfig{
+		Window:               10 * time.Second,
+		MinRequests:          4,
+		FailureRateThreshold: 0.5,
+		ConsecutiveFailures:  100,
+	})
+
+	sendThroughBreaker(t, cb, secondaryURL, circuitFailure)
+	sendThroughBreaker(t, cb, secondaryURL, circuitFailure)
+	clock.Advance(11 * time.Second)
+	sendThroughBreaker(t, cb, secondaryURL, circuitSuccess)
+	sendThroughBreaker(t, cb, secondaryURL, circuitSuccess)
+	sendThroughBreaker(t, cb, secondaryURL, circuitSuccess)
+	sendThroughBreaker(t, cb, secondaryURL, circuitFailure)
+	assert.Equal(t, CircuitClosed, cb.state(secondaryURL))
+}
+
+func TestCircuitBreaker_countsEndpointsSeparately(t *testing.T) {
+	cb, _ := newTestCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 1})
+
+	sendThroughBreaker(t, cb, secondaryURL, circuitFailure)
+	assert.Equal(t, CircuitOpen, cb.state(secondaryURL))
+	assert.Equal(t, CircuitClosed, cb.state(primaryURL))
+
+	_, err := cb.allow(primaryURL)
+	assert.NoError(t, err)
+}
+
+func TestCircuitBreaker_halfOpenProbes(t *testing.T) {
+	var changes []string
+	cb, clock := newTestCircuitBreaker(CircuitBreakerConfig{
+		ConsecutiveFailures: 1,
+		OpenTimeout:         time.Second,
+		HalfOpenMaxRequests: 2,
+		OnStateChange: func(endpoint string, from, to CircuitState) {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", endpoint, from, to))
+		},
+	})
+
+	sendThroughBreaker(t, cb, primaryURL, circuitFailure)
+
+	// a failed probe re-opens the breaker
+	clock.Advance(time.Second)
+	sendThroughBreaker(t, cb, primaryURL, circuitFailure)
+	assert.Equal(t, CircuitOpen, cb.state(primaryURL))
+
+	// probes are limited while half-open and enough successes close the breaker
+	clock.Advance(time.Second)
+	first, err := cb.allow(primaryURL)
+	assert.NoError(t, err)
+	second, err := cb.allow(primaryURL)
+	assert.NoError(t, err)
+
+	_, err = cb.allow(primaryURL)
+	assert.Equal(t, ErrCircuitOpen, err)
+
+	first(circuitSuccess)
+	assert.Equal(t, CircuitHalfOpen, cb.state(primaryURL))
+	second(circuitSuccess)
+	assert.Equal(t, CircuitClosed, cb.state(primaryURL))
+
+	assert.Equal(
+		t,
+		[]string{
+			primaryURL + ": closed -> open",
+			primaryURL + ": open -> half-open",
+			primaryURL + ": half-open -> open",
+			primaryURL + ": open -> half-open",
+			primaryURL + ": half-open -> closed",
+		},
+		changes,
+	)
+}
+
+func TestCircuitBreaker_ignoredOutcomesDoNotCount(t *testing.T) {
+	cb, _ := newTestCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 1})
+
+	sendThroughBreaker(t, cb, primaryURL, circuitIgnored)
+	assert.Equal(t, CircuitClosed, cb.state(primaryURL))
+}
+
+func TestClassifyCircuitOutcome(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		resp     *http.Response
+		err      error
+		expected circuitOutcome
+	}{
+		{name: "success", ctx: context.Background(), resp: &http.Response{StatusCode: http.StatusOK}, expected: circuitSuccess},
+		{name: "not found", ctx: context.Background(), resp: &http.Response{StatusCode: http.StatusNotFound}, expected: circuitSuccess},
+		{name: "server error", ctx: context.Background(), resp: &http.Response{StatusCode: http.StatusInternalServerError}, expected: circuitFailure},
+		{name: "rate limited", ctx: context.Background(), resp: &http.Response{StatusCode: http.StatusTooManyRequests}, expected: circuitFailure},
+		{name: "transport failure", ctx: context.Background(), err: newInternalError("failed to send http request", nil), expected: circuitFailure},
+		{name: "cancelled", ctx: cancelled, err: newInternalError("failed to send http request", context.Canceled), expected: circuitIgnored},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.expected, classifyCircuitOutcome(tc.ctx, tc.resp, tc.err))
+		})
+	}
+}
+
+func TestWithCircuitBreaker_clientFailsFastOnceOpen(t *testing.T) {
+	requests := 0
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"error_message": "unavailable"}`)),
+			}, nil
+		},
+	}
+
+	var changes []string
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithCircuitBreaker(CircuitBreakerConfig{
+		ConsecutiveFailures: 2,
+		OnStateChange: func(endpoint string, from, to CircuitState) {
+			changes = append(changes, fmt.Sprintf("%s %s->%s", endpoint, from, to))
+		},
+	}))
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+		assert.Equal(t, "api error - failed to fetch account, status code 503: unavailable", err.Error())
+	}
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, "unavailable error - requests to every endpoint are being rejected: circuit breaker is open", err.Error())
+
+	// the breaker belongs to the endpoint so every operation is rejected
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []string{"http://0.0.0.0:8080 closed->open"}, changes)
+}
+
+func TestWithCircuitBreaker_otherEndpointsKeepServing(t *testing.T) {
+	ert := &endpointsRoundTripper{handlers: map[string]func(req *http.Request) (*http.Response, error){
+		"primary:8080":   answerWith(http.StatusServiceUnavailable, `{"error_message": "unavailable"}`),
+		"secondary:8080": answerWith(http.StatusOK, fetchedAccountBody),
+	}}
+
+	var changes []string
+	c, err := NewClient(primaryURL, ert,
+		WithEndpoints(EndpointsConfig{Endpoints: []string{secondaryURL}, HealthCheckInterval: -1, Cooldown: time.Minute}),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			ConsecutiveFailures: 1,
+			OnStateChange: func(endpoint string, from, to CircuitState) {
+				changes = append(changes, fmt.Sprintf("%s %s->%s", endpoint, from, to))
+			},
+		}),
+	)
+	assert.NoError(t, err)
+
+	clock := &fakeClock{now: getDummyTime()}
+	c.endpoints.now = clock.Now
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"primary:8080", "secondary:8080"}, ert.sentTo())
+
+	// the primary is back at the front once its cooldown is over, but its breaker is still open so it is skipped
+	clock.Advance(2 * time.Minute)
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"secondary:8080"}, ert.sentTo())
+
+	assert.Equal(t, []string{primaryURL + " closed->open"}, changes)
+	assert.Equal(t, CircuitOpen, c.circuitBreaker.state(primaryURL))
+	assert.Equal(t, CircuitClosed, c.circuitBreaker.state(secondaryURL))
+}
+
+func TestWithCircuitBreaker_healthChecksBypassBreaker(t *testing.T) {
+	requests := 0
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"error_message": "unavailable"}`)),
+			}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 1}))
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.False(t, errors.Is(err, ErrCircuitOpen))
+
+	_, err = c.Health(context.Background())
+	assert.False(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 2, requests)
+}
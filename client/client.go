@@ -3,44 +3,130 @@ package client
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"time"
 )
 
-const basev1AccountsPath = "/v1/organisation/accounts"
+const (
+	basev1AccountsPath = "/v1/organisation/accounts"
+
+	// accountPathTemplate identifies requests for a single account regardless of its ID
+	accountPathTemplate = basev1AccountsPath + "/{id}"
+)
 
 // Client functions to provide a programmatic interface to the fake account API via it's methods
 type Client struct {
 	httpClient *http.Client
 	host       string
+	tracer     Tracer
+	metrics    Metrics
+
+	// endpoints are the deployments of the API requests are spread over, nil if there is only host
+	endpoints *endpointSet
+
+	// circuitBreaker decides whether requests may be sent to each endpoint, nil if circuit breaking is off
+	circuitBreaker *circuitBreaker
+
+	// handler is the head of the middleware chain that every operation is sent through
+	handler Next
 }
 
 // NewClient returns a pointer to a new instance of the fake account API client.
 // if transport == nil, we use http.DefaultTransport as RoundTripper
-func NewClient(host string, transport http.RoundTripper) (*Client, error) {
+// opts can be used to switch on optional behaviour such as logging, tracing, metrics, middleware and circuit breaking
+func NewClient(host string, transport http.RoundTripper, opts ...Option) (*Client, error) {
 	parsedURL, err := url.Parse(host)
 	if err != nil || parsedURL.Host == "" {
 		return nil, newInputError("invalid host", err)
 	}
 
+	primary := fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+
+	var cfg config
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// the proxy and TLS are applied to the transport itself, so they go underneath the decorators.
+	// The proxy comes first so that transports rebuilt by the TLS options keep it.
+	if cfg.proxy != nil {
+		if transport, err = cfg.proxy.wrap(transport); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.tls != nil {
+		if transport, err = cfg.tls.wrap(transport); err != nil {
+			return nil, err
+		}
+	}
+
 	if transport == nil {
 		transport = http.DefaultTransport
 	}
 
+	if cfg.metrics != nil {
+		transport = &metricsTransportDecorator{
+			transport: transport,
+			metrics:   cfg.metrics,
+		}
+	}
+
+	if cfg.logger != nil {
+		transport = &loggingTransportDecorator{
+			transport: transport,
+			logger:    cfg.logger,
+		}
+	}
+
 	// decorate transport to add required headers functionality
 	transport = &requiredHeadersTransportDecorator{
 		host:      host,
 		transport: transport,
 	}
 
-	return &Client{
+	cfg.setDefaults()
+
+	c := &Client{
 		httpClient: &http.Client{
 			Transport: transport,
 		},
-		host: fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host),
-	}, nil
+		host:    primary,
+		tracer:  cfg.tracer,
+		metrics: cfg.metrics,
+	}
+
+	baseURLs := []string{primary}
+	if cfg.endpoints != nil {
+		for _, endpoint := range cfg.endpoints.Endpoints {
+			// validated by WithEndpoints
+			base, _ := baseURL(endpoint)
+			for _, seen := range baseURLs {
+				if base == seen {
+					return nil, newInputError(fmt.Sprintf("duplicate endpoint %q", endpoint), nil)
+				}
+			}
+
+			baseURLs = append(baseURLs, base)
+		}
+
+		c.endpoints = newEndpointSet(*cfg.endpoints, baseURLs)
+		c.endpoints.check = c.checkEndpoint
+	}
+
+	if cfg.circuitBreaker != nil {
+		cfg.circuitBreaker.baseURLs = baseURLs
+		c.circuitBreaker = cfg.circuitBreaker
+	}
+
+	c.handler = chain(c.dispatch, cfg.operationMiddlewares()...)
+
+	return c, nil
 }
 
 // requiredHeadersTransportDecorator is a custom RoundTripper that decorates the RoundTripper in its transport field
@@ -68,33 +154,136 @@ func (drt *requiredHeadersTransportDecorator) RoundTrip(req *http.Request) (*htt
 
 // get creates and sends an HTTP GET request
 func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
-	return c.createAndDo(ctx, path, http.MethodGet, nil)
+	return c.createAndDo(ctx, path, http.MethodGet, nil, nil)
+}
+
+// getWithHeader creates and sends an HTTP GET request carrying the given headers
+func (c *Client) getWithHeader(ctx context.Context, path string, header http.Header) (*http.Response, error) {
+	return c.createAndDo(ctx, path, http.MethodGet, nil, header)
 }
 
 // post creates and sends an HTTP POST request
 func (c *Client) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
-	return c.createAndDo(ctx, path, http.MethodPost, body)
+	return c.createAndDo(ctx, path, http.MethodPost, body, nil)
 }
 
 // delete creates and sends an HTTP DELETE request
 func (c *Client) delete(ctx context.Context, path string) (*http.Response, error) {
-	return c.createAndDo(ctx, path, http.MethodDelete, nil)
+	return c.createAndDo(ctx, path, http.MethodDelete, nil, nil)
+}
+
+// CreateAndDo is a helper function that creates a request and then calls the Do method on the httpClient.
+// With several endpoints the request is sent to each in turn until one answers, as far as that is safe.
+func (c *Client) createAndDo(ctx context.Context, path, method string, body []byte, header http.Header) (*http.Response, error) {
+	breaker := c.circuitBreaker
+	info, ok := operationInfoFromContext(ctx)
+	if !ok {
+		info = newOperationInfo("", pathTemplate(path))
+		ctx = withOperationInfo(ctx, info)
+
+		// requests made outside of an operation, such as health checks, bypass the circuit breaker
+		breaker = nil
+	}
+
+	if c.endpoints == nil {
+		return c.send(ctx, info, breaker, c.host, path, method, body, header)
+	}
+
+	baseURLs := c.endpoints.order()
+	failover := canFailover(ctx, method)
+	for idx, baseURL := range baseURLs {
+		start := time.Now()
+		resp, err := c.send(ctx, info, breaker, baseURL, path, method, body, header)
+
+		// nothing was sent to an endpoint whose circuit breaker is open so the next one can always be tried
+		if errors.Is(err, ErrCircuitOpen) {
+			if idx == len(baseURLs)-1 {
+				return nil, err
+			}
+
+			continue
+		}
+
+		// requests the caller gave up on say nothing about the endpoint
+		if ctx.Err() != nil {
+			return resp, err
+		}
+
+		if !endpointFailed(resp, err) {
+			c.endpoints.succeeded(baseURL, time.Since(start))
+			return resp, nil
+		}
+
+		c.endpoints.failed(baseURL)
+		if !failover || idx == len(baseURLs)-1 {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+
+	// unreachable, there is always at least one endpoint
+	return nil, newInternalError("no endpoint to send request to", nil)
+}
+
+// send sends a request to the endpoint at baseURL, unless breaker is set and rejects requests to the endpoint
+func (c *Client) send(ctx context.Context, info *operationInfo, breaker *circuitBreaker, baseURL, path, method string, body []byte, header http.Header) (*http.Response, error) {
+	done := func(circuitOutcome) {}
+	if breaker != nil {
+		var err error
+		if done, err = breaker.allow(baseURL); err != nil {
+			return nil, newUnavailableError(fmt.Sprintf("requests to %s are being rejected", baseURL), err)
+		}
+	}
+
+	httpReq, err := c.newRequest(ctx, info, baseURL, path, method, body, header)
+	if err != nil {
+		done(circuitIgnored)
+		return nil, err
+	}
+
+	resp, err := c.do(info, httpReq)
+	done(classifyCircuitOutcome(ctx, resp, err))
+
+	return resp, err
 }
 
-// CreateAndDo is a helper function that creates a request and then calls the Do method on the httpClient
-func (c *Client) createAndDo(ctx context.Context, path, method string, body []byte) (*http.Response, error) {
-	// create request
-	url := fmt.Sprintf("%s%s", c.host, path)
+// newRequest creates the next http request of the operation described by info, sending it to the endpoint at baseURL
+func (c *Client) newRequest(ctx context.Context, info *operationInfo, baseURL, path, method string, body []byte, header http.Header) (*http.Request, error) {
+	ctx = context.WithValue(ctx, failoverAttemptKey{}, info.nextRequest())
+
+	url := fmt.Sprintf("%s%s", baseURL, path)
 	httpReq, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, newInternalError("failed to create http request", err)
 	}
 
-	// send request
+	for key, values := range header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+
+	httpReq.Header.Set(requestIDHeader, info.requestID)
+	if key := idempotencyKeyFromContext(ctx); key != "" {
+		httpReq.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	injectTraceParent(ctx, httpReq.Header)
+
+	return httpReq, nil
+}
+
+// do sends httpReq, recording the status code of the response in info
+func (c *Client) do(info *operationInfo, httpReq *http.Request) (*http.Response, error) {
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, newInternalError("failed to send http request", err)
 	}
 
+	info.setStatusCode(resp.StatusCode)
+
 	return resp, nil
 }
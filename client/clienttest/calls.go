@@ -0,0 +1,104 @@
+package clienttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Call is a recorded call to a Fake. Args are the arguments after the context, in order.
+type Call struct {
+	Method Method
+	Args   []interface{}
+}
+
+func (c Call) String() string {
+	args := make([]string, 0, len(c.Args))
+	for _, arg := range c.Args {
+		args = append(args, formatArg(arg))
+	}
+
+	return fmt.Sprintf("%s(%s)", c.Method, strings.Join(args, ", "))
+}
+
+// formatArg formats arg for failure messages, structs are written as JSON so that pointer fields show their values
+func formatArg(arg interface{}) string {
+	switch arg.(type) {
+	case string, uint, int:
+		return fmt.Sprint(arg)
+	}
+
+	raw, err := json.Marshal(arg)
+	if err != nil {
+		return fmt.Sprintf("%+v", arg)
+	}
+
+	return string(raw)
+}
+
+// Calls returns the recorded calls to method in the order they were made, or every call if method is empty
+func (f *Fake) Calls(method Method) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []Call
+	for _, call := range f.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// AssertCalled fails t unless method was called with exactly args, compared with reflect.DeepEqual
+func (f *Fake) AssertCalled(t testing.TB, method Method, args ...interface{}) bool {
+	t.Helper()
+
+	calls := f.Calls(method)
+	for _, call := range calls {
+		if reflect.DeepEqual(call.Args, args) {
+			return true
+		}
+	}
+
+	want := Call{Method: method, Args: args}
+	if len(calls) == 0 {
+		t.Errorf("expected call %s but %s was never called", want, method)
+		return false
+	}
+
+	var made strings.Builder
+	for _, call := range calls {
+		fmt.Fprintf(&made, "\n  %s", call)
+	}
+
+	t.Errorf("expected call %s but the calls made were:%s", want, made.String())
+	return false
+}
+
+// AssertNotCalled fails t if method was called
+func (f *Fake) AssertNotCalled(t testing.TB, method Method) bool {
+	t.Helper()
+
+	if calls := f.Calls(method); len(calls) > 0 {
+		t.Errorf("expected no calls to %s but got %d, the first was %s", method, len(calls), calls[0])
+		return false
+	}
+
+	return true
+}
+
+// AssertNumberOfCalls fails t unless method was called exactly n times
+func (f *Fake) AssertNumberOfCalls(t testing.TB, method Method, n int) bool {
+	t.Helper()
+
+	if got := len(f.Calls(method)); got != n {
+		t.Errorf("expected %d calls to %s but got %d", n, method, got)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,684 @@
+// Package clienttest provides Fake, a programmable test double for client.AccountsAPI,
+// so that code using the client can be tested without an API or a hand written RoundTripper.
+//
+// By default a Fake behaves like an in-memory account API: created accounts can be fetched, listed and deleted,
+// duplicates and stale versions are rejected with the same errors as the client returns. On top of that:
+//
+//   - stubs replace an operation entirely, e.g. f.FetchFunc = func(...) {...}
+//   - FailNext and FailAlways inject errors into an operation
+//   - every call is recorded and can be checked with Calls, AssertCalled, AssertNotCalled and AssertNumberOfCalls
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/accounts/ndjson"
+	"github.com/OJOMB/form3-fake-account-client/client"
+)
+
+// Method names an operation of client.AccountsAPI
+type Method string
+
+const (
+	MethodCreate           Method = "Create"
+	MethodCreateMany       Method = "CreateMany"
+	MethodFetch            Method = "Fetch"
+	MethodFetchConditional Method = "FetchConditional"
+	MethodList             Method = "List"
+	MethodListAll          Method = "ListAll"
+	MethodDelete           Method = "Delete"
+	MethodDeleteMany       Method = "DeleteMany"
+	MethodExport           Method = "Export"
+	MethodImport           Method = "Import"
+	MethodHealth           Method = "Health"
+	MethodWaitUntilHealthy Method = "WaitUntilHealthy"
+)
+
+// defaultPageSize is the page size used by List when none is given, as in the API
+const defaultPageSize = 100
+
+var _ client.AccountsAPI = (*Fake)(nil)
+
+// Fake is an in-memory client.AccountsAPI. The zero value is not usable, create one with NewFake.
+// Stubs must be set before the Fake is used, everything else is safe for concurrent use.
+type Fake struct {
+	// CreateFunc, if set, is called by Create instead of the in-memory behaviour. The same goes for the other stubs.
+	CreateFunc           func(ctx context.Context, account accounts.AccountData) (*accounts.Response, error)
+	CreateManyFunc       func(ctx context.Context, accts []accounts.AccountData, opts client.BulkOptions) ([]client.CreateResult, error)
+	FetchFunc            func(ctx context.Context, accountID string) (*accounts.Response, error)
+	FetchConditionalFunc func(ctx context.Context, accountID string) (*accounts.Response, bool, error)
+	ListFunc             func(ctx context.Context, opts client.ListOptions) (*accounts.ListResponse, error)
+	ListAllFunc          func(ctx context.Context, opts client.ListOptions, fn func(account accounts.AccountData) error) error
+	DeleteFunc           func(ctx context.Context, accountID string, version uint) error
+	DeleteManyFunc       func(ctx context.Context, req client.DeleteManyRequest, opts client.BulkOptions) (*client.DeleteSummary, error)
+	ExportFunc           func(ctx context.Context, w io.Writer, filter client.ListFilter) (int, error)
+	ImportFunc           func(ctx context.Context, r io.Reader, opts client.ImportOptions) (*client.ImportSummary, error)
+	HealthFunc           func(ctx context.Context) (*client.Health, error)
+	WaitUntilHealthyFunc func(ctx context.Context, interval time.Duration) error
+
+	mu       sync.Mutex
+	accounts map[string]accounts.AccountData
+	order    []string
+	calls    []Call
+	failures map[Method][]failure
+}
+
+// failure is an injected error, times is the number of calls left to fail or -1 to fail every call
+type failure struct {
+	err error
+	// times is the number of calls left to fail, or -1 to fail every call
+	times int
+}
+
+// NewFake returns a Fake holding accts
+func NewFake(accts ...accounts.AccountData) *Fake {
+	f := &Fake{accounts: map[string]accounts.AccountData{}, failures: map[Method][]failure{}}
+	for _, account := range accts {
+		f.put(account)
+	}
+
+	return f
+}
+
+// Put stores account as is, replacing any account with the same ID, without recording a call
+func (f *Fake) Put(account accounts.AccountData) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.put(account)
+}
+
+// Accounts returns every stored account in the order they were first stored
+func (f *Fake) Accounts() []accounts.AccountData {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	accts := make([]accounts.AccountData, 0, len(f.order))
+	for _, id := range f.order {
+		accts = append(accts, f.accounts[id].Clone())
+	}
+
+	return accts
+}
+
+// FailNext makes the next times calls to method return err.
+// times must be at least 1, FailNext panics otherwise. Use FailAlways to make every call fail.
+func (f *Fake) FailNext(method Method, err error, times int) {
+	if times < 1 {
+		panic(fmt.Sprintf("clienttest: FailNext times must be at least 1, got %d", times))
+	}
+
+	f.addFailure(method, failure{err: err, times: times})
+}
+
+// FailAlways makes every call to method return err until Reset is called
+func (f *Fake) FailAlways(method Method, err error) {
+	f.addFailure(method, failure{err: err, times: -1})
+}
+
+// addFailure queues fail for method behind the failures already injected into it
+func (f *Fake) addFailure(method Method, fail failure) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures[method] = append(f.failures[method], fail)
+}
+
+// Reset clears recorded calls and injected errors, stored accounts and stubs are kept
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+	f.failures = map[Method][]failure{}
+}
+
+// begin records a call to method and returns the error injected into it, if any
+func (f *Fake) begin(method Method, args ...interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+
+	queue := f.failures[method]
+	if len(queue) == 0 {
+		return nil
+	}
+
+	err := queue[0].err
+	if queue[0].times > 0 {
+		queue[0].times--
+		if queue[0].times == 0 {
+			queue = queue[1:]
+		}
+	}
+
+	f.failures[method] = queue
+	return err
+}
+
+// put stores account, defaulting its version to 0. f.mu must be held.
+func (f *Fake) put(account accounts.AccountData) {
+	if account.Version == nil {
+		version := int64(0)
+		account.Version = &version
+	}
+
+	if _, ok := f.accounts[account.ID]; !ok {
+		f.order = append(f.order, account.ID)
+	}
+
+	f.accounts[account.ID] = account.Clone()
+}
+
+// Create stores account, failing with a 409 API error if an account with the same ID exists
+func (f *Fake) Create(ctx context.Context, account accounts.AccountData) (*accounts.Response, error) {
+	if err := f.begin(MethodCreate, account); err != nil {
+		return nil, err
+	}
+
+	if f.CreateFunc != nil {
+		return f.CreateFunc(ctx, account)
+	}
+
+	return f.create(ctx, account)
+}
+
+func (f *Fake) create(ctx context.Context, account accounts.AccountData) (*accounts.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, client.NewInternalError("failed to send http request", err)
+	}
+
+	if account.ID == "" {
+		return nil, client.NewAPIError(http.StatusBadRequest, "failed to create account, status code 400")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.accounts[account.ID]; ok {
+		return nil, client.NewAPIError(http.StatusConflict, "failed to create account, status code 409")
+	}
+
+	account.Version = nil
+	f.put(account)
+
+	created := f.accounts[account.ID].Clone()
+	return &accounts.Response{Data: &created}, nil
+}
+
+// CreateMany creates each account in turn
+func (f *Fake) CreateMany(ctx context.Context, accts []accounts.AccountData, opts client.BulkOptions) ([]client.CreateResult, error) {
+	if err := f.begin(MethodCreateMany, accts, opts); err != nil {
+		return nil, err
+	}
+
+	if f.CreateManyFunc != nil {
+		return f.CreateManyFunc(ctx, accts, opts)
+	}
+
+	results := make([]client.CreateResult, len(accts))
+	stopped := false
+	for idx, account := range accts {
+		results[idx].Account = account
+		if stopped {
+			results[idx].Err = client.ErrSkipped
+			continue
+		}
+
+		results[idx].Response, results[idx].Err = f.create(ctx, account)
+		stopped = results[idx].Err != nil && opts.StopOnError
+	}
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+// Fetch returns the account with accountID, failing with a 404 API error if there is none
+func (f *Fake) Fetch(ctx context.Context, accountID string) (*accounts.Response, error) {
+	if err := f.begin(MethodFetch, accountID); err != nil {
+		return nil, err
+	}
+
+	if f.FetchFunc != nil {
+		return f.FetchFunc(ctx, accountID)
+	}
+
+	return f.fetch(ctx, accountID)
+}
+
+func (f *Fake) fetch(ctx context.Context, accountID string) (*accounts.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, client.NewInternalError("failed to send http request", err)
+	}
+
+	if accountID == "" {
+		return nil, client.NewInputError("accountID cannot be empty", nil)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	account, ok := f.accounts[accountID]
+	if !ok {
+		return nil, client.NewAPIError(http.StatusNotFound, "failed to fetch account, status code 404")
+	}
+
+	account = account.Clone()
+	return &accounts.Response{Data: &account}, nil
+}
+
+// FetchConditional fetches the account, which is never reported as unchanged
+func (f *Fake) FetchConditional(ctx context.Context, accountID string) (*accounts.Response, bool, error) {
+	if err := f.begin(MethodFetchConditional, accountID); err != nil {
+		return nil, false, err
+	}
+
+	if f.FetchConditionalFunc != nil {
+		return f.FetchConditionalFunc(ctx, accountID)
+	}
+
+	resp, err := f.fetch(ctx, accountID)
+	return resp, false, err
+}
+
+// List returns a page of the accounts matching opts.Filter, in the order they were created
+func (f *Fake) List(ctx context.Context, opts client.ListOptions) (*accounts.ListResponse, error) {
+	if err := f.begin(MethodList, opts); err != nil {
+		return nil, err
+	}
+
+	if f.ListFunc != nil {
+		return f.ListFunc(ctx, opts)
+	}
+
+	return f.list(ctx, opts)
+}
+
+func (f *Fake) list(ctx context.Context, opts client.ListOptions) (*accounts.ListResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, client.NewInternalError("failed to send http request", err)
+	}
+
+	if opts.PageNumber < 0 || opts.PageSize < 0 {
+		return nil, client.NewInputError("page number and page size cannot be negative", nil)
+	}
+
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var matching []accounts.AccountData
+	for _, id := range f.order {
+		if account, ok := f.accounts[id]; ok && matchesFilter(account, opts.Filter) {
+			matching = append(matching, account.Clone())
+		}
+	}
+
+	lastPage := 0
+	if len(matching) > 0 {
+		lastPage = (len(matching) - 1) / pageSize
+	}
+
+	page := &accounts.ListResponse{
+		Data: []accounts.AccountData{},
+		Links: &accounts.Links{
+			First: pageLink(0, pageSize),
+			Last:  pageLink(lastPage, pageSize),
+			Self:  pageLink(opts.PageNumber, pageSize),
+		},
+	}
+
+	if opts.PageNumber < lastPage {
+		page.Links.Next = pageLink(opts.PageNumber+1, pageSize)
+	}
+
+	if opts.PageNumber > 0 {
+		page.Links.Prev = pageLink(opts.PageNumber-1, pageSize)
+	}
+
+	if start := opts.PageNumber * pageSize; start < len(matching) {
+		end := start + pageSize
+		if end > len(matching) {
+			end = len(matching)
+		}
+
+		page.Data = append(page.Data, matching[start:end]...)
+	}
+
+	return page, nil
+}
+
+// pageLink returns the link to a page of accounts, as the API would
+func pageLink(number, size int) string {
+	return fmt.Sprintf("/v1/organisation/accounts?page%%5Bnumber%%5D=%d&page%%5Bsize%%5D=%d", number, size)
+}
+
+// matchesFilter reports whether account matches every non-empty field of filter
+func matchesFilter(account accounts.AccountData, filter client.ListFilter) bool {
+	var attrs accounts.AccountAttributes
+	if account.Attributes != nil {
+		attrs = *account.Attributes
+	}
+
+	var country string
+	if attrs.Country != nil {
+		country = *attrs.Country
+	}
+
+	checks := []struct{ want, got string }{
+		{filter.BankIDCode, attrs.BankIDCode},
+		{filter.BankID, attrs.BankID},
+		{filter.AccountNumber, attrs.AccountNumber},
+		{filter.Iban, attrs.Iban},
+		{filter.CustomerID, attrs.CustomerID},
+		{filter.Country, country},
+		{filter.OrganisationID, account.OrganisationID},
+	}
+
+	for _, check := range checks {
+		if check.want != "" && check.want != check.got {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ListAll calls fn with every account matching opts.Filter, starting from opts.PageNumber
+func (f *Fake) ListAll(ctx context.Context, opts client.ListOptions, fn func(account accounts.AccountData) error) error {
+	if err := f.begin(MethodListAll, opts); err != nil {
+		return err
+	}
+
+	if f.ListAllFunc != nil {
+		return f.ListAllFunc(ctx, opts, fn)
+	}
+
+	return f.listAll(ctx, opts, fn)
+}
+
+func (f *Fake) listAll(ctx context.Context, opts client.ListOptions, fn func(account accounts.AccountData) error) error {
+	for {
+		page, err := f.list(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		for _, account := range page.Data {
+			if err := fn(account); err != nil {
+				return err
+			}
+		}
+
+		if page.Links.Next == "" {
+			return nil
+		}
+
+		opts.PageNumber++
+	}
+}
+
+// Delete removes the account, failing with a 404 API error if there is none and a 409 if version is not its current version
+func (f *Fake) Delete(ctx context.Context, accountID string, version uint) error {
+	if err := f.begin(MethodDelete, accountID, version); err != nil {
+		return err
+	}
+
+	if f.DeleteFunc != nil {
+		return f.DeleteFunc(ctx, accountID, version)
+	}
+
+	return f.delete(ctx, accountID, version)
+}
+
+func (f *Fake) delete(ctx context.Context, accountID string, version uint) error {
+	if err := ctx.Err(); err != nil {
+		return client.NewInternalError("failed to send http request", err)
+	}
+
+	if accountID == "" {
+		return client.NewInputError("accountID cannot be empty", nil)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	account, ok := f.accounts[accountID]
+	if !ok {
+		return client.NewAPIError(http.StatusNotFound, "failed to delete account, status code 404")
+	}
+
+	if uint(*account.Version) != version {
+		return client.NewAPIError(http.StatusConflict, "failed to delete account, status code 409")
+	}
+
+	delete(f.accounts, accountID)
+	for idx, id := range f.order {
+		if id == accountID {
+			f.order = append(f.order[:idx:idx], f.order[idx+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// DeleteMany deletes the current version of each selected account in turn
+func (f *Fake) DeleteMany(ctx context.Context, req client.DeleteManyRequest, opts client.BulkOptions) (*client.DeleteSummary, error) {
+	if err := f.begin(MethodDeleteMany, req, opts); err != nil {
+		return nil, err
+	}
+
+	if f.DeleteManyFunc != nil {
+		return f.DeleteManyFunc(ctx, req, opts)
+	}
+
+	selections := 0
+	for _, selected := range []bool{len(req.IDs) > 0, req.Filter != nil, req.AllAccounts} {
+		if selected {
+			selections++
+		}
+	}
+
+	if selections != 1 {
+		return nil, client.NewInputError("exactly one of IDs, Filter or AllAccounts must be provided", nil)
+	}
+
+	if req.Filter != nil && *req.Filter == (client.ListFilter{}) {
+		return nil, client.NewInputError("filter must set at least one field, use AllAccounts to delete every account", nil)
+	}
+
+	filter := req.Filter
+	if req.AllAccounts {
+		filter = &client.ListFilter{}
+	}
+
+	var selected []accounts.AccountData
+	summary := &client.DeleteSummary{DryRun: req.DryRun}
+	if filter != nil {
+		err := f.listAll(ctx, client.ListOptions{Filter: *filter}, func(account accounts.AccountData) error {
+			selected = append(selected, account)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range req.IDs {
+		resp, err := f.fetch(ctx, id)
+		switch {
+		case client.StatusCode(err) == http.StatusNotFound:
+			summary.NotFound = append(summary.NotFound, id)
+		case err != nil:
+			summary.Failed = append(summary.Failed, client.DeleteFailure{ID: id, Err: err})
+		default:
+			selected = append(selected, *resp.Data)
+		}
+	}
+
+	for _, account := range selected {
+		version := uint(*account.Version)
+		if req.DryRun {
+			summary.Deleted = append(summary.Deleted, client.DeletedAccount{ID: account.ID, Version: version})
+			continue
+		}
+
+		err := f.delete(ctx, account.ID, version)
+		switch {
+		case err == nil:
+			summary.Deleted = append(summary.Deleted, client.DeletedAccount{ID: account.ID, Version: version})
+		case client.StatusCode(err) == http.StatusNotFound:
+			summary.NotFound = append(summary.NotFound, account.ID)
+		case client.StatusCode(err) == http.StatusConflict:
+			summary.Conflicted = append(summary.Conflicted, account.ID)
+		default:
+			summary.Failed = append(summary.Failed, client.DeleteFailure{ID: account.ID, Err: err})
+		}
+	}
+
+	return summary, nil
+}
+
+// Export writes the accounts matching filter to w as newline delimited JSON
+func (f *Fake) Export(ctx context.Context, w io.Writer, filter client.ListFilter) (int, error) {
+	if err := f.begin(MethodExport, filter); err != nil {
+		return 0, err
+	}
+
+	if f.ExportFunc != nil {
+		return f.ExportFunc(ctx, w, filter)
+	}
+
+	enc := ndjson.NewEncoder(w)
+	exported := 0
+	err := f.listAll(ctx, client.ListOptions{Filter: filter}, func(account accounts.AccountData) error {
+		if err := enc.Encode(account); err != nil {
+			return client.NewInternalError("failed to write account", err)
+		}
+
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	if err := enc.Flush(); err != nil {
+		return exported, client.NewInternalError("failed to write accounts", err)
+	}
+
+	return exported, nil
+}
+
+// Import creates the accounts read from r, which holds newline delimited JSON.
+// opts is recorded but otherwise ignored, in particular no checkpoint is read or written.
+func (f *Fake) Import(ctx context.Context, r io.Reader, opts client.ImportOptions) (*client.ImportSummary, error) {
+	if err := f.begin(MethodImport, opts); err != nil {
+		return nil, err
+	}
+
+	if f.ImportFunc != nil {
+		return f.ImportFunc(ctx, r, opts)
+	}
+
+	summary := &client.ImportSummary{}
+	dec := ndjson.NewDecoder(r)
+	for {
+		account, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return summary, nil
+		}
+
+		var lerr *ndjson.LineError
+		if errors.As(err, &lerr) {
+			summary.Failed = append(summary.Failed, client.ImportFailure{Line: lerr.Line, Err: client.NewInputError("invalid account", lerr.Err)})
+			continue
+		}
+
+		if err != nil {
+			return summary, client.NewInternalError("failed to read accounts", err)
+		}
+
+		account.Version = nil
+		account.CreatedOn = nil
+		account.ModifiedOn = nil
+
+		_, err = f.create(ctx, account)
+		switch {
+		case err == nil:
+			summary.Imported++
+		case client.StatusCode(err) == http.StatusConflict:
+			summary.AlreadyExisted++
+		case ctx.Err() != nil:
+			return summary, ctx.Err()
+		default:
+			summary.Failed = append(summary.Failed, client.ImportFailure{Line: dec.Line(), ID: account.ID, Err: err})
+		}
+	}
+}
+
+// Health reports the API as up
+func (f *Fake) Health(ctx context.Context) (*client.Health, error) {
+	if err := f.begin(MethodHealth); err != nil {
+		return nil, err
+	}
+
+	if f.HealthFunc != nil {
+		return f.HealthFunc(ctx)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, client.NewInternalError("failed to send http request", err)
+	}
+
+	return &client.Health{Status: client.HealthStatusUp}, nil
+}
+
+// WaitUntilHealthy calls Health every interval until it reports the API as up or ctx is done,
+// so errors injected into Health delay it
+func (f *Fake) WaitUntilHealthy(ctx context.Context, interval time.Duration) error {
+	if err := f.begin(MethodWaitUntilHealthy, interval); err != nil {
+		return err
+	}
+
+	if f.WaitUntilHealthyFunc != nil {
+		return f.WaitUntilHealthyFunc(ctx, interval)
+	}
+
+	if interval <= 0 {
+		return client.NewInputError("interval must be positive", nil)
+	}
+
+	for {
+		health, err := f.Health(ctx)
+		if err == nil && health.Up() {
+			return nil
+		}
+
+		if err == nil {
+			err = fmt.Errorf("status is %q", health.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return client.NewUnavailableError("account API did not become healthy", fmt.Errorf("%w, last check: %v", ctx.Err(), err))
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package clienttest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAccount(n int, country string) accounts.AccountData {
+	return accounts.AccountData{
+		ID:             fmt.Sprintf("00000000-0000-0000-0000-%012d", n),
+		OrganisationID: "caca9817-6936-4da4-96e7-9ce93206070f",
+		Type:           "accounts",
+		Attributes:     &accounts.AccountAttributes{Country: &country, Name: []string{"Samantha Holder"}},
+	}
+}
+
+// recordingT is a testing.TB that records failures instead of failing the test
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (rt *recordingT) Helper() {}
+
+func (rt *recordingT) Errorf(format string, args ...interface{}) {
+	rt.errors = append(rt.errors, fmt.Sprintf(format, args...))
+}
+
+func TestFake_inMemoryBehaviour(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(testAccount(1, "GB"))
+
+	created, err := f.Create(ctx, testAccount(2, "FR"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), *created.Data.Version)
+
+	_, err = f.Create(ctx, testAccount(2, "FR"))
+	assert.True(t, client.IsAPIError(err))
+	assert.Equal(t, http.StatusConflict, client.StatusCode(err))
+
+	fetched, err := f.Fetch(ctx, testAccount(2, "FR").ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Data, fetched.Data)
+
+	_, unchanged, err := f.FetchConditional(ctx, testAccount(1, "GB").ID)
+	assert.NoError(t, err)
+	assert.False(t, unchanged)
+
+	_, err = f.Fetch(ctx, "missing")
+	assert.Equal(t, http.StatusNotFound, client.StatusCode(err))
+
+	_, err = f.Fetch(ctx, "")
+	assert.True(t, client.IsInputError(err))
+
+	// returned accounts are copies
+	*fetched.Data.Attributes.Country = "DE"
+	assert.Equal(t, "FR", *f.Accounts()[1].Attributes.Country)
+
+	err = f.Delete(ctx, testAccount(1, "GB").ID, 1)
+	assert.Equal(t, http.StatusConflict, client.StatusCode(err))
+
+	assert.NoError(t, f.Delete(ctx, testAccount(1, "GB").ID, 0))
+	assert.Equal(t, []accounts.AccountData{*created.Data}, f.Accounts())
+
+	err = f.Delete(ctx, testAccount(1, "GB").ID, 0)
+	assert.Equal(t, http.StatusNotFound, client.StatusCode(err))
+}
+
+func TestFake_list(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	for n := 0; n < 5; n++ {
+		country := "GB"
+		if n%2 == 1 {
+			country = "FR"
+		}
+
+		f.Put(testAccount(n, country))
+	}
+
+	page, err := f.List(ctx, client.ListOptions{PageSize: 2, PageNumber: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []accounts.AccountData{f.Accounts()[2], f.Accounts()[3]}, page.Data)
+	assert.NotEmpty(t, page.Links.Next)
+	assert.NotEmpty(t, page.Links.Prev)
+
+	var ids []string
+	err = f.ListAll(ctx, client.ListOptions{PageSize: 1, Filter: client.ListFilter{Country: "GB"}}, func(account accounts.AccountData) error {
+		ids = append(ids, account.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testAccount(0, "").ID, testAccount(2, "").ID, testAccount(4, "").ID}, ids)
+
+	page, err = f.List(ctx, client.ListOptions{PageNumber: 3})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Data)
+
+	_, err = f.List(ctx, client.ListOptions{PageSize: -1})
+	assert.True(t, client.IsInputError(err))
+}
+
+func TestFake_bulkOperations(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(testAccount(1, "GB"))
+
+	results, err := f.CreateMany(ctx, []accounts.AccountData{testAccount(1, "GB"), testAccount(2, "GB"), testAccount(3, "FR")}, client.BulkOptions{StopOnError: true})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, client.StatusCode(results[0].Err))
+	assert.ErrorIs(t, results[1].Err, client.ErrSkipped)
+	assert.ErrorIs(t, results[2].Err, client.ErrSkipped)
+
+	results, err = f.CreateMany(ctx, []accounts.AccountData{testAccount(2, "GB"), testAccount(3, "FR")}, client.BulkOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+
+	var dump bytes.Buffer
+	exported, err := f.Export(ctx, &dump, client.ListFilter{Country: "GB"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, exported)
+
+	summary, err := f.DeleteMany(ctx, client.DeleteManyRequest{Filter: &client.ListFilter{Country: "GB"}, DryRun: true}, client.BulkOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, summary.Deleted, 2)
+	assert.Len(t, f.Accounts(), 3)
+
+	summary, err = f.DeleteMany(ctx, client.DeleteManyRequest{IDs: []string{testAccount(1, "").ID, testAccount(2, "").ID, "missing"}}, client.BulkOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []client.DeletedAccount{{ID: testAccount(1, "").ID}, {ID: testAccount(2, "").ID}}, summary.Deleted)
+	assert.Equal(t, []string{"missing"}, summary.NotFound)
+
+	_, err = f.DeleteMany(ctx, client.DeleteManyRequest{}, client.BulkOptions{})
+	assert.True(t, client.IsInputError(err))
+
+	_, err = f.DeleteMany(ctx, client.DeleteManyRequest{Filter: &client.ListFilter{}}, client.BulkOptions{})
+	assert.True(t, client.IsInputError(err))
+
+	dump.WriteString("not json\n")
+	imported, err := f.Import(ctx, &dump, client.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported.Imported)
+	assert.Len(t, imported.Failed, 1)
+	assert.Equal(t, 3, imported.Failed[0].Line)
+	assert.Len(t, f.Accounts(), 3)
+}
+
+func TestFake_stubsAndInjectedErrors(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(testAccount(1, "GB"))
+
+	unavailable := client.NewUnavailableError("circuit breaker is open", nil)
+	f.FailNext(MethodFetch, unavailable, 2)
+
+	for idx := 0; idx < 2; idx++ {
+		_, err := f.Fetch(ctx, testAccount(1, "").ID)
+		assert.ErrorIs(t, err, unavailable)
+	}
+
+	_, err := f.Fetch(ctx, testAccount(1, "").ID)
+	assert.NoError(t, err)
+
+	boom := errors.New("boom")
+	f.FailAlways(MethodDelete, boom)
+	for idx := 0; idx < 3; idx++ {
+		assert.ErrorIs(t, f.Delete(ctx, testAccount(1, "").ID, 0), boom)
+	}
+
+	f.Reset()
+	assert.Empty(t, f.Calls(""))
+
+	stubbed := testAccount(9, "DE")
+	f.FetchFunc = func(ctx context.Context, accountID string) (*accounts.Response, error) {
+		return &accounts.Response{Data: &stubbed}, nil
+	}
+
+	resp, err := f.Fetch(ctx, "anything")
+	assert.NoError(t, err)
+	assert.Equal(t, &stubbed, resp.Data)
+
+	// injected errors take precedence over stubs
+	f.FailNext(MethodFetch, boom, 1)
+	_, err = f.Fetch(ctx, "anything")
+	assert.ErrorIs(t, err, boom)
+
+	assert.NoError(t, f.Delete(ctx, testAccount(1, "").ID, 0))
+}
+
+func TestFake_failNextRejectsNonPositiveTimes(t *testing.T) {
+	f := NewFake()
+
+	assert.Panics(t, func() { f.FailNext(MethodFetch, errors.New("boom"), 0) })
+	assert.Panics(t, func() { f.FailNext(MethodFetch, errors.New("boom"), -1) })
+
+	_, err := f.Fetch(context.Background(), testAccount(1, "").ID)
+	assert.Equal(t, http.StatusNotFound, client.StatusCode(err))
+}
+
+func TestFake_callAssertions(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(testAccount(1, "GB"))
+
+	_, _ = f.Fetch(ctx, testAccount(1, "").ID)
+	_ = f.Delete(ctx, testAccount(1, "").ID, 0)
+	_ = f.Delete(ctx, testAccount(1, "").ID, 0)
+
+	assert.Equal(t, []Call{
+		{Method: MethodFetch, Args: []interface{}{testAccount(1, "").ID}},
+		{Method: MethodDelete, Args: []interface{}{testAccount(1, "").ID, uint(0)}},
+		{Method: MethodDelete, Args: []interface{}{testAccount(1, "").ID, uint(0)}},
+	}, f.Calls(""))
+
+	assert.True(t, f.AssertCalled(t, MethodDelete, testAccount(1, "").ID, uint(0)))
+	assert.True(t, f.AssertNumberOfCalls(t, MethodDelete, 2))
+	assert.True(t, f.AssertNotCalled(t, MethodCreate))
+
+	rt := &recordingT{TB: t}
+	assert.False(t, f.AssertCalled(rt, MethodDelete, testAccount(1, "").ID, uint(1)))
+	assert.False(t, f.AssertCalled(rt, MethodCreate, testAccount(1, "GB")))
+	assert.False(t, f.AssertNotCalled(rt, MethodFetch))
+	assert.False(t, f.AssertNumberOfCalls(rt, MethodFetch, 2))
+
+	assert.Equal(t, []string{
+		"expected call Delete(" + testAccount(1, "").ID + ", 1) but the calls made were:\n" +
+			"  Delete(" + testAccount(1, "").ID + ", 0)\n" +
+			"  Delete(" + testAccount(1, "").ID + ", 0)",
+		`expected call Create({"attributes":{"country":"GB","name":["Samantha Holder"]},"id":"` + testAccount(1, "").ID +
+			`","organisation_id":"caca9817-6936-4da4-96e7-9ce93206070f","type":"accounts"}) but Create was never called`,
+		"expected no calls to Fetch but got 1, the first was Fetch(" + testAccount(1, "").ID + ")",
+		"expected 2 calls to Fetch but got 1",
+	}, rt.errors)
+}
+
+func TestFake_health(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+
+	health, err := f.Health(ctx)
+	assert.NoError(t, err)
+	assert.True(t, health.Up())
+
+	// the API comes up after two failed checks
+	f.FailNext(MethodHealth, client.NewAPIError(http.StatusServiceUnavailable, "health check failed, status code 503"), 2)
+	assert.NoError(t, f.WaitUntilHealthy(ctx, time.Millisecond))
+	f.AssertNumberOfCalls(t, MethodHealth, 4)
+
+	f.FailAlways(MethodHealth, client.NewAPIError(http.StatusServiceUnavailable, "health check failed, status code 503"))
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	err = f.WaitUntilHealthy(timeoutCtx, time.Millisecond)
+	assert.True(t, client.IsUnavailableError(err))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,322 @@
+// Package fakeapi provides Server, an in-memory account API served over HTTP, for tests of code that talks to the API,
+// e.g. command line tools and the client itself. It keeps to the contract checked by client/apitest.
+// Unlike clienttest.Fake it does not depend on the client, so the client's own tests can use it too.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/google/uuid"
+)
+
+const (
+	accountsPath = "/v1/organisation/accounts"
+	healthPath   = "/v1/health"
+
+	defaultPageSize = 100
+)
+
+// Server is an in-memory account API. Serve it with httptest.NewServer.
+// Accounts can be created, fetched with ETags, listed with filters and pages, and deleted by version,
+// with the same status codes as the real API for invalid IDs and accounts, duplicates and stale versions.
+type Server struct {
+	mu       sync.Mutex
+	accounts map[string]accounts.AccountData
+	// order is the IDs of the stored accounts in the order they were first stored, which lists follow
+	order    []string
+	requests []string
+	// failures maps a http method, or "" for every method, to the status its requests are answered with
+	failures map[string]int
+	now      func() time.Time
+}
+
+// NewServer returns a Server storing accts, see Put
+func NewServer(accts ...accounts.AccountData) *Server {
+	s := &Server{accounts: map[string]accounts.AccountData{}, failures: map[string]int{}, now: time.Now}
+	for _, account := range accts {
+		s.Put(account)
+	}
+
+	return s
+}
+
+// Put stores account as it is, replacing any account with the same ID, e.g. to seed the API or to change
+// an account behind the client's back. The version defaults to 0.
+func (s *Server) Put(account accounts.AccountData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(account.Clone())
+}
+
+// put stores account. s.mu must be held.
+func (s *Server) put(account accounts.AccountData) {
+	if account.Version == nil {
+		version := int64(0)
+		account.Version = &version
+	}
+
+	if _, ok := s.accounts[account.ID]; !ok {
+		s.order = append(s.order, account.ID)
+	}
+
+	s.accounts[account.ID] = account
+}
+
+// Account returns the stored account with accountID, false if there is none
+func (s *Server) Account(accountID string) (accounts.AccountData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[accountID]
+	return account.Clone(), ok
+}
+
+// Accounts returns the stored accounts in the order they were first stored
+func (s *Server) Accounts() []accounts.AccountData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accts := make([]accounts.AccountData, 0, len(s.order))
+	for _, id := range s.order {
+		accts = append(accts, s.accounts[id].Clone())
+	}
+
+	return accts
+}
+
+// Requests returns the method and path of every request received so far, e.g. "GET /v1/organisation/accounts"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+// FailWith makes the server answer every request with method, or every request at all if method is "",
+// with statusCode and an API error. A statusCode of 0 stops the failures.
+func (s *Server) FailWith(method string, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if statusCode == 0 {
+		delete(s.failures, method)
+		return
+	}
+
+	s.failures[method] = statusCode
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	for _, method := range []string{r.Method, ""} {
+		if statusCode, ok := s.failures[method]; ok {
+			writeJSON(w, statusCode, accounts.ApiError{ErrMsg: "forced failure"})
+			return
+		}
+	}
+
+	switch {
+	case r.URL.Path == healthPath && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "up"})
+	case r.URL.Path == accountsPath && r.Method == http.MethodPost:
+		s.create(w, r)
+	case r.URL.Path == accountsPath && r.Method == http.MethodGet:
+		s.list(w, r)
+	case strings.HasPrefix(r.URL.Path, accountsPath+"/"):
+		s.serveAccount(w, r, strings.TrimPrefix(r.URL.Path, accountsPath+"/"))
+	case r.URL.Path == accountsPath || r.URL.Path == healthPath:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// writeJSON writes body as the JSON response with statusCode
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// create stores the account in the request body at version 0
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var req accounts.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Data == nil {
+		writeJSON(w, http.StatusBadRequest, accounts.ApiError{ErrMsg: "invalid request body"})
+		return
+	}
+
+	account := *req.Data
+	if msg := validate(account); msg != "" {
+		writeJSON(w, http.StatusBadRequest, accounts.ApiError{ErrMsg: "validation failure: " + msg})
+		return
+	}
+
+	if _, ok := s.accounts[account.ID]; ok {
+		writeJSON(w, http.StatusConflict, accounts.ApiError{ErrMsg: "Account cannot be created as it violates a duplicate constraint"})
+		return
+	}
+
+	version, now := int64(0), s.now().UTC()
+	account.Version, account.CreatedOn, account.ModifiedOn = &version, &now, &now
+	s.put(account)
+
+	writeJSON(w, http.StatusCreated, accounts.Response{Data: &account, Links: &accounts.Links{Self: accountsPath + "/" + account.ID}})
+}
+
+// validate returns what is wrong with an account to be created, or "" if it can be stored
+func validate(account accounts.AccountData) string {
+	switch {
+	case !isUUID(account.ID):
+		return "id must be a uuid"
+	case !isUUID(account.OrganisationID):
+		return "organisation_id must be a uuid"
+	case account.Type != "accounts":
+		return `type must be "accounts"`
+	case account.Attributes == nil || account.Attributes.Country == nil || *account.Attributes.Country == "":
+		return "country is required"
+	case len(account.Attributes.Name) == 0:
+		return "name is required"
+	default:
+		return ""
+	}
+}
+
+func isUUID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// list returns the page of accounts matching the filters selected by the query
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var matched []accounts.AccountData
+	for _, id := range s.order {
+		if account := s.accounts[id]; matchesFilters(account, query) {
+			matched = append(matched, account)
+		}
+	}
+
+	pageNumber, err := strconv.Atoi(query.Get("page[number]"))
+	if query.Get("page[number]") == "" {
+		pageNumber, err = 0, nil
+	}
+
+	pageSize, sizeErr := strconv.Atoi(query.Get("page[size]"))
+	if query.Get("page[size]") == "" {
+		pageSize, sizeErr = defaultPageSize, nil
+	}
+
+	if err != nil || sizeErr != nil || pageNumber < 0 || pageSize < 1 {
+		writeJSON(w, http.StatusBadRequest, accounts.ApiError{ErrMsg: "invalid page"})
+		return
+	}
+
+	page := accounts.ListResponse{Data: []accounts.AccountData{}, Links: &accounts.Links{Self: r.URL.String()}}
+	for idx := pageNumber * pageSize; idx < len(matched) && idx < (pageNumber+1)*pageSize; idx++ {
+		page.Data = append(page.Data, matched[idx])
+	}
+
+	if (pageNumber+1)*pageSize < len(matched) {
+		next := r.URL.Query()
+		next.Set("page[number]", strconv.Itoa(pageNumber+1))
+		next.Set("page[size]", strconv.Itoa(pageSize))
+		page.Links.Next = accountsPath + "?" + next.Encode()
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// matchesFilters reports whether account matches every filter[field] parameter of query
+func matchesFilters(account accounts.AccountData, query map[string][]string) bool {
+	var attributes accounts.AccountAttributes
+	if account.Attributes != nil {
+		attributes = *account.Attributes
+	}
+
+	var country string
+	if attributes.Country != nil {
+		country = *attributes.Country
+	}
+
+	fields := map[string]string{
+		"bank_id_code":   attributes.BankIDCode,
+		"bank_id":        attributes.BankID,
+		"account_number": attributes.AccountNumber,
+		"iban":           attributes.Iban,
+		"customer_id":    attributes.CustomerID,
+		"country":        country,
+	}
+
+	for field, value := range fields {
+		if want, ok := query[fmt.Sprintf("filter[%s]", field)]; ok && len(want) > 0 && want[0] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// serveAccount fetches or deletes the account with accountID
+func (s *Server) serveAccount(w http.ResponseWriter, r *http.Request, accountID string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !isUUID(accountID) {
+		writeJSON(w, http.StatusBadRequest, accounts.ApiError{ErrMsg: "id is not a valid uuid"})
+		return
+	}
+
+	account, ok := s.accounts[accountID]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, accounts.ApiError{ErrMsg: fmt.Sprintf("record %s does not exist", accountID)})
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		s.delete(w, r, account)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%d"`, *account.Version)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, accounts.Response{Data: &account, Links: &accounts.Links{Self: r.URL.Path}})
+}
+
+// delete removes account if the request names its current version
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, account accounts.AccountData) {
+	if r.URL.Query().Get("version") != strconv.FormatInt(*account.Version, 10) {
+		writeJSON(w, http.StatusConflict, accounts.ApiError{ErrMsg: "invalid version"})
+		return
+	}
+
+	delete(s.accounts, account.ID)
+	for idx, id := range s.order {
+		if id == account.ID {
+			s.order = append(s.order[:idx:idx], s.order[idx+1:]...)
+			break
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
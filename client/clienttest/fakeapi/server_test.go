@@ -0,0 +1,169 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testOrgID = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+	testIDA   = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+	testIDB   = "0b2ae3a4-4e44-4f6f-9a0e-b5a47d83a5c1"
+)
+
+func testAccount(id, country string) accounts.AccountData {
+	return accounts.AccountData{
+		ID:             id,
+		OrganisationID: testOrgID,
+		Type:           "accounts",
+		Attributes:     &accounts.AccountAttributes{Country: &country, Name: []string{"Jane Doe"}},
+	}
+}
+
+// do sends a request to s and returns the response status and body
+func do(s *Server, method, target, body string, header http.Header) (int, http.Header, string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec.Code, rec.Header(), rec.Body.String()
+}
+
+// requestBody returns the body of a request to create account
+func requestBody(account accounts.AccountData) string {
+	raw, _ := json.Marshal(accounts.Request{Data: &account})
+	return string(raw)
+}
+
+func TestServer_statusCodes(t *testing.T) {
+	unnamed := testAccount(testIDB, "GB")
+	unnamed.Attributes.Name = nil
+
+	testCases := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{name: "create", method: http.MethodPost, target: accountsPath, body: requestBody(testAccount(testIDB, "GB")), expectedStatus: http.StatusCreated},
+		{name: "create duplicate", method: http.MethodPost, target: accountsPath, body: requestBody(testAccount(testIDA, "GB")), expectedStatus: http.StatusConflict},
+		{name: "create without name", method: http.MethodPost, target: accountsPath, body: requestBody(unnamed), expectedStatus: http.StatusBadRequest},
+		{name: "create invalid body", method: http.MethodPost, target: accountsPath, body: "{", expectedStatus: http.StatusBadRequest},
+		{name: "fetch", method: http.MethodGet, target: accountsPath + "/" + testIDA, expectedStatus: http.StatusOK},
+		{name: "fetch missing", method: http.MethodGet, target: accountsPath + "/" + testIDB, expectedStatus: http.StatusNotFound},
+		{name: "fetch invalid id", method: http.MethodGet, target: accountsPath + "/a", expectedStatus: http.StatusBadRequest},
+		{name: "delete stale version", method: http.MethodDelete, target: accountsPath + "/" + testIDA + "?version=1", expectedStatus: http.StatusConflict},
+		{name: "delete", method: http.MethodDelete, target: accountsPath + "/" + testIDA + "?version=0", expectedStatus: http.StatusNoContent},
+		{name: "invalid page", method: http.MethodGet, target: accountsPath + "?page[size]=0", expectedStatus: http.StatusBadRequest},
+		{name: "health", method: http.MethodGet, target: healthPath, expectedStatus: http.StatusOK},
+		{name: "unsupported method", method: http.MethodPut, target: accountsPath, expectedStatus: http.StatusMethodNotAllowed},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			s := NewServer(testAccount(testIDA, "GB"))
+
+			status, _, body := do(s, tc.method, tc.target, tc.body, nil)
+			assert.Equal(t, tc.expectedStatus, status, body)
+		})
+	}
+}
+
+func TestServer_createSetsReadOnlyFields(t *testing.T) {
+	s := NewServer()
+
+	account := testAccount(testIDA, "GB")
+	account.Version = ptrInt64(7)
+
+	status, _, body := do(s, http.MethodPost, accountsPath, requestBody(account), nil)
+	assert.Equal(t, http.StatusCreated, status)
+
+	var resp accounts.Response
+	assert.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, int64(0), *resp.Data.Version)
+	assert.NotNil(t, resp.Data.CreatedOn)
+	assert.Equal(t, accountsPath+"/"+testIDA, resp.Links.Self)
+
+	stored, ok := s.Account(testIDA)
+	assert.True(t, ok)
+	assert.Equal(t, *resp.Data, stored)
+}
+
+func TestServer_fetchHonoursETags(t *testing.T) {
+	s := NewServer(testAccount(testIDA, "GB"))
+
+	status, header, _ := do(s, http.MethodGet, accountsPath+"/"+testIDA, "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `"0"`, header.Get("ETag"))
+
+	status, _, _ = do(s, http.MethodGet, accountsPath+"/"+testIDA, "", http.Header{"If-None-Match": {`"0"`}})
+	assert.Equal(t, http.StatusNotModified, status)
+
+	changed := testAccount(testIDA, "GB")
+	changed.Version = ptrInt64(1)
+	s.Put(changed)
+
+	status, _, _ = do(s, http.MethodGet, accountsPath+"/"+testIDA, "", http.Header{"If-None-Match": {`"0"`}})
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestServer_listFiltersAndPages(t *testing.T) {
+	s := NewServer(testAccount(testIDA, "GB"), testAccount(testIDB, "FR"), testAccount("5a5f0d8c-2b4d-4f0a-8f0e-2a8c6e8b7d10", "GB"))
+
+	_, _, body := do(s, http.MethodGet, accountsPath+"?filter[country]=GB&page[size]=1", "", nil)
+
+	var page accounts.ListResponse
+	assert.NoError(t, json.Unmarshal([]byte(body), &page))
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, testIDA, page.Data[0].ID)
+
+	_, _, body = do(s, http.MethodGet, page.Links.Next, "", nil)
+
+	page = accounts.ListResponse{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &page))
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, "5a5f0d8c-2b4d-4f0a-8f0e-2a8c6e8b7d10", page.Data[0].ID)
+	assert.Empty(t, page.Links.Next)
+}
+
+func TestServer_failWith(t *testing.T) {
+	s := NewServer(testAccount(testIDA, "GB"))
+	s.FailWith(http.MethodGet, http.StatusInternalServerError)
+
+	status, _, _ := do(s, http.MethodGet, accountsPath+"/"+testIDA, "", nil)
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	status, _, _ = do(s, http.MethodDelete, accountsPath+"/"+testIDA+"?version=1", "", nil)
+	assert.Equal(t, http.StatusConflict, status)
+
+	s.FailWith("", http.StatusServiceUnavailable)
+	status, _, _ = do(s, http.MethodDelete, accountsPath+"/"+testIDA+"?version=1", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	s.FailWith("", 0)
+	s.FailWith(http.MethodGet, 0)
+	status, _, _ = do(s, http.MethodGet, accountsPath+"/"+testIDA, "", nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	assert.Equal(t, []string{
+		"GET " + accountsPath + "/" + testIDA,
+		"DELETE " + accountsPath + "/" + testIDA,
+		"DELETE " + accountsPath + "/" + testIDA,
+		"GET " + accountsPath + "/" + testIDA,
+	}, s.Requests())
+}
+
+func ptrInt64(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// defaultConditionalCapacity is the number of accounts whose validators are remembered
+// when conditional requests are used without a cache
+const defaultConditionalCapacity = 1000
+
+// WithConditionalRequests makes Fetch send If-None-Match and If-Modified-Since headers for accounts it has
+// fetched before, using the ETag and Last-Modified headers the API returned with them. When the API answers
+// 304 Not Modified the previously fetched account is returned without being downloaded again.
+// Validators are kept in the cache configured with WithCache, or in an internal LRUCache if there is none.
+func WithConditionalRequests() Option {
+	return func(cfg *config) error {
+		cfg.conditional = &conditionalLayer{now: time.Now}
+		return nil
+	}
+}
+
+// FetchConditional fetches an account like Fetch, and also reports whether the API confirmed with
+// 304 Not Modified that the account is unchanged since it was last fetched.
+// Without WithConditionalRequests no validators are sent and unchanged is always false.
+// With WithCache, accounts served from the cache without asking the API are not reported as unchanged.
+func (c *Client) FetchConditional(ctx context.Context, accountID string) (resp *accounts.Response, unchanged bool, err error) {
+	opResp, err := c.invoke(ctx, &OperationRequest{Operation: OperationFetch, AccountID: accountID})
+	if err != nil {
+		return nil, false, err
+	}
+
+	account, err := fetchedAccount(opResp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return account, opResp.NotModified, nil
+}
+
+// conditionalLayer is the middleware that turns fetches of previously seen accounts into conditional requests
+type conditionalLayer struct {
+	store Cache
+	now   func() time.Time
+	// ownsStore is false when store belongs to the cache layer, which then takes care of
+	// writing and invalidating entries so that it can keep them consistent with concurrent writes
+	ownsStore bool
+}
+
+// middleware adds validators to fetches and answers 304s with the stored account
+func (cond *conditionalLayer) middleware(next Next) Next {
+	return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+		switch req.Operation {
+		case OperationFetch:
+			return cond.fetch(ctx, req, next)
+		case OperationCreate, OperationDelete:
+			// validators of an account that no longer exists are useless so there is no need to keep them
+			if cond.ownsStore {
+				cond.store.Delete(req.AccountID)
+			}
+
+			return next(ctx, req)
+		default:
+			return next(ctx, req)
+		}
+	}
+}
+
+// fetch sends the validators stored for the account, if any, and keeps the validators that come back
+func (cond *conditionalLayer) fetch(ctx context.Context, req *OperationRequest, next Next) (*OperationResponse, error) {
+	entry, ok := cond.store.Get(req.AccountID)
+
+	// validators set by an earlier middleware take precedence over those we remember, and validators
+	// are only worth sending when there is a stored account to answer a 304 with
+	sent := ok && entry.Response != nil && (entry.ETag != "" || entry.LastModified != "") && req.IfNoneMatch == "" && req.IfModifiedSince == ""
+	if sent {
+		conditionalReq := *req
+		conditionalReq.IfNoneMatch = entry.ETag
+		conditionalReq.IfModifiedSince = entry.LastModified
+		req = &conditionalReq
+	}
+
+	resp, err := next(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp == nil {
+		return nil, nil
+	}
+
+	if resp.NotModified {
+		if !sent || resp.Account != nil {
+			return resp, nil
+		}
+
+		// the API has confirmed our copy is current so it counts as freshly fetched
+		if cond.ownsStore {
+			entry.StoredAt = cond.now()
+			cond.store.Set(req.AccountID, entry)
+		}
+
+		unchanged := entry.operationResponse()
+		unchanged.NotModified = true
+		return unchanged, nil
+	}
+
+	if cond.ownsStore && resp.Account != nil && resp.Account.Data != nil && (resp.ETag != "" || resp.LastModified != "") {
+		cond.store.Set(req.AccountID, newCacheEntry(resp, cond.now()))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,235 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchConditional_withoutCache(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountIDA))
+
+	var ifNoneMatch []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		api.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil, WithConditionalRequests())
+	assert.NoError(t, err)
+
+	first, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.False(t, unchanged)
+	assert.Equal(t, testAccountIDA, first.Data.ID)
+
+	second, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.True(t, unchanged)
+	assert.Equal(t, first, second)
+	assert.NotSame(t, first, second)
+
+	// the account changes so the API sends it in full
+	changed := testAccount(testAccountIDA)
+	changed.Version = ptrInt64(1)
+	api.Put(changed)
+
+	third, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.False(t, unchanged)
+	assert.Equal(t, int64(1), *third.Data.Version)
+
+	fourth, err := c.Fetch(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.Equal(t, third, fourth)
+
+	assert.Equal(t, []string{"", `"0"`, `"0"`, `"1"`}, ifNoneMatch)
+}
+
+func TestFetch_return304WithoutStoredAccountFails(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountIDA))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	// a middleware sends validators of its own but has no copy of the account to answer a 304 with
+	validators := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			withValidators := *req
+			withValidators.IfNoneMatch = `"0"`
+			return next(ctx, &withValidators)
+		}
+	}
+
+	c, err := NewClient(server.URL, nil, WithMiddleware(validators), WithConditionalRequests())
+	assert.NoError(t, err)
+
+	resp, err := c.Fetch(context.Background(), testAccountIDA)
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+
+	resp, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.False(t, unchanged)
+}
+
+func TestFetchConditional_withoutOptionNeverUnchanged(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountIDA))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+		assert.NoError(t, err)
+		assert.False(t, unchanged)
+	}
+}
+
+func TestFetchConditional_deleteForgetsValidators(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountIDA))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil, WithConditionalRequests())
+	assert.NoError(t, err)
+
+	_, _, err = c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), testAccountIDA, 0)
+	assert.NoError(t, err)
+
+	_, err = c.Create(context.Background(), testAccount(testAccountIDA))
+	assert.NoError(t, err)
+
+	// the recreated account has the same ETag but must not be treated as unchanged
+	_, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.False(t, unchanged)
+}
+
+func TestFetchConditional_revalidatesStaleCacheEntries(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountIDA))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	lru := NewLRUCache(10)
+
+	// the conditional option comes first to check that it finds the cache regardless of option order
+	c, err := NewClient(server.URL, nil, WithConditionalRequests(), WithCache(lru, time.Minute))
+	assert.NoError(t, err)
+
+	_, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.False(t, unchanged)
+
+	entry, ok := lru.Get(testAccountIDA)
+	assert.True(t, ok)
+	assert.Equal(t, `"0"`, entry.ETag)
+
+	// fresh entries are served without asking the API
+	_, unchanged, err = c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.False(t, unchanged)
+	assert.Len(t, api.Requests(), 1)
+
+	// make the entry stale
+	entry.StoredAt = entry.StoredAt.Add(-time.Hour)
+	lru.Set(testAccountIDA, entry)
+
+	resp, unchanged, err := c.FetchConditional(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.True(t, unchanged)
+	assert.Equal(t, testAccountIDA, resp.Data.ID)
+	assert.Len(t, api.Requests(), 2)
+
+	// the 304 counts as a fresh fetch
+	refreshed, ok := lru.Get(testAccountIDA)
+	assert.True(t, ok)
+	assert.True(t, refreshed.StoredAt.After(entry.StoredAt))
+	assert.Equal(t, `"0"`, refreshed.ETag)
+
+	_, err = c.Fetch(context.Background(), testAccountIDA)
+	assert.NoError(t, err)
+	assert.Len(t, api.Requests(), 2)
+}
+
+func TestFetch_return304SendsValidatorsAndReportsNotModified(t *testing.T) {
+	var gotReq *http.Request
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			gotReq = req
+			return &http.Response{
+				StatusCode: http.StatusNotModified,
+				Header:     http.Header{"Etag": []string{`"3"`}, "Last-Modified": []string{"Mon, 01 Jan 2024 00:00:00 GMT"}},
+				Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+			}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	resp, err := c.fetchAccount(context.Background(), &OperationRequest{
+		Operation:       OperationFetch,
+		AccountID:       "a",
+		IfNoneMatch:     `"3"`,
+		IfModifiedSince: "Mon, 01 Jan 2024 00:00:00 GMT",
+	})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		&OperationResponse{ETag: `"3"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT", NotModified: true},
+		resp,
+	)
+
+	assert.Equal(t, `"3"`, gotReq.Header.Get("If-None-Match"))
+	assert.Equal(t, "Mon, 01 Jan 2024 00:00:00 GMT", gotReq.Header.Get("If-Modified-Since"))
+}
+
+func TestConditional_lastModifiedOnly(t *testing.T) {
+	var calls []*OperationRequest
+	lastModified := "Mon, 01 Jan 2024 00:00:00 GMT"
+
+	api := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			calls = append(calls, req)
+			if req.IfModifiedSince == lastModified {
+				return &OperationResponse{NotModified: true}, nil
+			}
+
+			return &OperationResponse{
+				Account:      &accounts.Response{Data: &accounts.AccountData{ID: req.AccountID}},
+				LastModified: lastModified,
+			}, nil
+		}
+	}
+
+	cond := &conditionalLayer{store: NewLRUCache(10), now: time.Now, ownsStore: true}
+	handler := chain(nil, cond.middleware, api)
+
+	resp, err := handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+	assert.NoError(t, err)
+	assert.False(t, resp.NotModified)
+
+	resp, err = handler(context.Background(), &OperationRequest{Operation: OperationFetch, AccountID: "a"})
+	assert.NoError(t, err)
+	assert.True(t, resp.NotModified)
+	assert.Equal(t, "a", resp.Account.Data.ID)
+
+	assert.Len(t, calls, 2)
+	assert.Equal(t, "", calls[1].IfNoneMatch)
+	assert.Equal(t, lastModified, calls[1].IfModifiedSince)
+}
@@ -13,8 +13,22 @@ import (
 // Create attempts to create a new account
 // https://api-docs.form3.tech/api.html#organisation-accounts-create
 func (c *Client) Create(ctx context.Context, account accounts.AccountData) (*accounts.Response, error) {
+	resp, err := c.invoke(ctx, &OperationRequest{Operation: OperationCreate, AccountID: account.ID, Account: &account})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Account, nil
+}
+
+// createAccount sends the request to create the account held by req to the API
+func (c *Client) createAccount(ctx context.Context, opReq *OperationRequest) (*OperationResponse, error) {
+	if opReq.Account == nil {
+		return nil, newInputError("account cannot be nil", nil)
+	}
+
 	// create request
-	req := accounts.NewRequest(account)
+	req := accounts.NewRequest(*opReq.Account)
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, newInternalError("failed to marshal input request", err)
@@ -41,7 +55,7 @@ func (c *Client) Create(ctx context.Context, account accounts.AccountData) (*acc
 			return nil, newInternalError("failed to unmarshal response body", err)
 		}
 
-		return nil, newApiError(fmt.Sprintf("failed to create account, status code %d", resp.StatusCode), apiError)
+		return nil, newApiStatusError(resp.StatusCode, fmt.Sprintf("failed to create account, status code %d", resp.StatusCode), apiError)
 	}
 
 	// handle success response
@@ -50,5 +64,5 @@ func (c *Client) Create(ctx context.Context, account accounts.AccountData) (*acc
 		return nil, newInternalError("failed to unmarshal response body", err)
 	}
 
-	return &createdAccountResp, nil
+	return &OperationResponse{Account: &createdAccountResp}, nil
 }
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// CreateResult is the outcome of creating a single account as part of CreateMany
+type CreateResult struct {
+	// Account is the account that was submitted
+	Account accounts.AccountData
+	// Response is the API response for the account, nil if it was not created
+	Response *accounts.Response
+	// Err is the reason the account was not created, ErrSkipped if it was never attempted
+	Err error
+}
+
+// CreateMany creates accounts concurrently, with at most opts.Concurrency requests in flight.
+// Every create is its own request through the client's middleware chain, so a rate limit spaces them out and
+// failures count towards the circuit breaker like those of single creates.
+// The results are in the same order as accounts. The returned error is only non-nil if ctx ended, or if
+// opts.StopOnError stopped the bulk create before every account was started. A failure of the last account
+// to start is only reported in its result.
+func (c *Client) CreateMany(ctx context.Context, accts []accounts.AccountData, opts BulkOptions) ([]CreateResult, error) {
+	results := make([]CreateResult, len(accts))
+	for idx, account := range accts {
+		results[idx].Account = account
+	}
+
+	create := func(ctx context.Context, idx int) error {
+		results[idx].Response, results[idx].Err = c.Create(ctx, accts[idx])
+		return results[idx].Err
+	}
+
+	skip := func(idx int, err error) {
+		results[idx].Err = err
+	}
+
+	if err := runBulk(ctx, len(accts), opts, create, skip); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoCreateRoundTripper responds to create requests by echoing the submitted account back,
+// or with a 409 for accounts whose ID is in conflicts
+func echoCreateRoundTripper(t *testing.T, conflicts map[string]bool, inFlight, maxInFlight *int32) *mockRoundTripper {
+	return &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			current := atomic.AddInt32(inFlight, 1)
+			defer atomic.AddInt32(inFlight, -1)
+
+			for {
+				max := atomic.LoadInt32(maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(maxInFlight, max, current) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+
+			var body accounts.Request
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+
+			if conflicts[body.Data.ID] {
+				return &http.Response{
+					StatusCode: http.StatusConflict,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(`{"error_message": "Account cannot be created as it violates a duplicate constraint"}`)),
+				}, nil
+			}
+
+			respBody, err := json.Marshal(accounts.Response{Data: body.Data})
+			assert.NoError(t, err)
+
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewBuffer(respBody)),
+			}, nil
+		},
+	}
+}
+
+func testAccounts(n int) []accounts.AccountData {
+	accts := make([]accounts.AccountData, n)
+	for idx := range accts {
+		accts[idx] = testAccount(fmt.Sprintf("00000000-0000-0000-0000-%012d", idx))
+	}
+
+	return accts
+}
+
+func TestCreateMany_returnsResultsInInputOrderWithBoundedConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	conflicts := map[string]bool{"00000000-0000-0000-0000-000000000007": true}
+
+	c, err := NewClient("http://0.0.0.0:8080", echoCreateRoundTripper(t, conflicts, &inFlight, &maxInFlight))
+	assert.NoError(t, err)
+
+	var progressMu sync.Mutex
+	var progress []BulkProgress
+	accts := testAccounts(20)
+
+	results, err := c.CreateMany(context.Background(), accts, BulkOptions{
+		Concurrency: 3,
+		Progress: func(p BulkProgress) {
+			progressMu.Lock()
+			defer progressMu.Unlock()
+			progress = append(progress, p)
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 20)
+
+	for idx, result := range results {
+		assert.Equal(t, accts[idx], result.Account)
+		if idx == 7 {
+			assert.Nil(t, result.Response)
+			assert.Equal(
+				t,
+				"api error - failed to create account, status code 409: Account cannot be created as it violates a duplicate constraint",
+				result.Err.Error(),
+			)
+			continue
+		}
+
+		assert.NoError(t, result.Err)
+		assert.Equal(t, accts[idx].ID, result.Response.Data.ID)
+	}
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(3))
+	assert.Len(t, progress, 20)
+	assert.Equal(t, BulkProgress{Completed: 20, Failed: 1, Total: 20}, progress[19])
+}
+
+func TestCreateMany_stopOnErrorSkipsRemainingAccounts(t *testing.T) {
+	var inFlight, maxInFlight int32
+	conflicts := map[string]bool{"00000000-0000-0000-0000-000000000002": true}
+
+	c, err := NewClient("http://0.0.0.0:8080", echoCreateRoundTripper(t, conflicts, &inFlight, &maxInFlight))
+	assert.NoError(t, err)
+
+	results, err := c.CreateMany(context.Background(), testAccounts(50), BulkOptions{Concurrency: 1, StopOnError: true})
+	assert.Equal(
+		t,
+		"api error - failed to create account, status code 409: Account cannot be created as it violates a duplicate constraint",
+		err.Error(),
+	)
+
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, err, results[2].Err)
+
+	// with a single worker at most one further account can have been handed out before the failure was seen
+	skipped := 0
+	for _, result := range results[3:] {
+		if result.Err == ErrSkipped {
+			skipped++
+		}
+	}
+
+	assert.GreaterOrEqual(t, skipped, 46)
+}
+
+func TestCreateMany_stopOnErrorWithLastAccountFailingSkipsNothing(t *testing.T) {
+	var inFlight, maxInFlight int32
+	conflicts := map[string]bool{"00000000-0000-0000-0000-000000000004": true}
+
+	c, err := NewClient("http://0.0.0.0:8080", echoCreateRoundTripper(t, conflicts, &inFlight, &maxInFlight))
+	assert.NoError(t, err)
+
+	results, err := c.CreateMany(context.Background(), testAccounts(5), BulkOptions{Concurrency: 1, StopOnError: true})
+	assert.NoError(t, err)
+
+	for _, result := range results[:4] {
+		assert.NoError(t, result.Err)
+	}
+
+	assert.Equal(t, http.StatusConflict, StatusCode(results[4].Err))
+}
+
+func TestCreateMany_cancelledContextSkipsRemainingAccounts(t *testing.T) {
+	var inFlight, maxInFlight int32
+	c, err := NewClient("http://0.0.0.0:8080", echoCreateRoundTripper(t, nil, &inFlight, &maxInFlight))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := c.CreateMany(ctx, testAccounts(5), BulkOptions{})
+	assert.Equal(t, context.Canceled, err)
+	for _, result := range results {
+		assert.Error(t, result.Err)
+		assert.Nil(t, result.Response)
+	}
+}
+
+func TestCreateMany_noAccounts(t *testing.T) {
+	c, err := NewClient("http://0.0.0.0:8080", &mockRoundTripper{})
+	assert.NoError(t, err)
+
+	results, err := c.CreateMany(context.Background(), nil, BulkOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
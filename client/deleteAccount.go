@@ -13,21 +13,27 @@ import (
 // Delete attempts to remove an existing account version
 // https://api-docs.form3.tech/api.html#organisation-accounts-fetch
 func (c *Client) Delete(ctx context.Context, accountID string, version uint) error {
-	if accountID == "" {
-		return newInputError("accountID cannot be empty", nil)
+	_, err := c.invoke(ctx, &OperationRequest{Operation: OperationDelete, AccountID: accountID, Version: version})
+	return err
+}
+
+// deleteAccount sends the request to remove the account version identified by req to the API
+func (c *Client) deleteAccount(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+	if req.AccountID == "" {
+		return nil, newInputError("accountID cannot be empty", nil)
 	}
 
 	// send request
-	path := fmt.Sprintf("%s/%s?version=%d", basev1AccountsPath, accountID, version)
+	path := fmt.Sprintf("%s/%s?version=%d", basev1AccountsPath, req.AccountID, req.Version)
 	resp, err := c.delete(ctx, path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// read response
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return newInternalError("failed to read response body", err)
+		return nil, newInternalError("failed to read response body", err)
 	}
 
 	defer resp.Body.Close()
@@ -38,10 +44,10 @@ func (c *Client) Delete(ctx context.Context, accountID string, version uint) err
 			// here the server has returned an error message in the response body
 			var apiError accounts.ApiError
 			if err := json.Unmarshal(respBody, &apiError); err != nil {
-				return newInternalError("failed to unmarshal response body", err)
+				return nil, newInternalError("failed to unmarshal response body", err)
 			}
 
-			return newApiError(fmt.Sprintf("failed to delete account, status code %d", resp.StatusCode), apiError)
+			return nil, newApiStatusError(resp.StatusCode, fmt.Sprintf("failed to delete account, status code %d", resp.StatusCode), apiError)
 		}
 
 		// since the server has not returned an error message
@@ -56,8 +62,12 @@ func (c *Client) Delete(ctx context.Context, accountID string, version uint) err
 			failureMessage = "received response with unexpected status code from server"
 		}
 
-		return newApiError(fmt.Sprintf("failed to delete account, status code %d: %s", resp.StatusCode, failureMessage), nil)
+		return nil, newApiStatusError(
+			resp.StatusCode,
+			fmt.Sprintf("failed to delete account, status code %d: %s", resp.StatusCode, failureMessage),
+			nil,
+		)
 	}
 
-	return nil
+	return &OperationResponse{}, nil
 }
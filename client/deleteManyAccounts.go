@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// DeleteManyRequest selects the accounts to remove with DeleteMany: by ID, with a list filter, or all of them
+type DeleteManyRequest struct {
+	// IDs are the accounts to delete, their current versions are looked up before deleting them
+	IDs []string
+	// Filter selects the accounts to delete from the account list, it must set at least one field
+	Filter *ListFilter
+	// AllAccounts deletes every account the API lists, in every organisation
+	AllAccounts bool
+	// DryRun reports what would be deleted without deleting anything
+	DryRun bool
+}
+
+// DeletedAccount identifies an account version that was, or in a dry run would have been, deleted
+type DeletedAccount struct {
+	ID      string
+	Version uint
+}
+
+// DeleteFailure is an account that could not be deleted for a reason other than it being missing or conflicted
+type DeleteFailure struct {
+	ID  string
+	Err error
+}
+
+// DeleteSummary reports the outcome of DeleteMany. Every list is in the order the accounts were selected.
+type DeleteSummary struct {
+	DryRun bool
+	// Deleted are the accounts that were deleted, or that would be deleted in a dry run
+	Deleted []DeletedAccount
+	// NotFound are the IDs of accounts that did not exist
+	NotFound []string
+	// Conflicted are the IDs of accounts that changed version between being looked up and being deleted
+	Conflicted []string
+	// Failed are the accounts that could not be looked up or deleted for any other reason
+	Failed []DeleteFailure
+}
+
+// validate checks that req selects accounts in exactly one way, and that a filter cannot match every account by mistake
+func (req DeleteManyRequest) validate() error {
+	selections := 0
+	for _, selected := range []bool{len(req.IDs) > 0, req.Filter != nil, req.AllAccounts} {
+		if selected {
+			selections++
+		}
+	}
+
+	if selections != 1 {
+		return newInputError("exactly one of IDs, Filter or AllAccounts must be provided", nil)
+	}
+
+	if req.Filter != nil && *req.Filter == (ListFilter{}) {
+		return newInputError("filter must set at least one field, use AllAccounts to delete every account", nil)
+	}
+
+	return nil
+}
+
+// deleteOutcome is the result for a single account in DeleteMany
+type deleteOutcome struct {
+	id       string
+	version  uint
+	deleted  bool
+	notFound bool
+	conflict bool
+	err      error
+}
+
+// DeleteMany deletes the accounts selected by req concurrently, with at most opts.Concurrency accounts worked on at once.
+// Each request goes through the client's middleware chain, so each delete is subject to circuit breaking and rate limiting.
+// The returned error is only non-nil if req is invalid, if the accounts could not be listed,
+// or if the bulk delete stopped early, in which case the summary covers the accounts that were processed.
+func (c *Client) DeleteMany(ctx context.Context, req DeleteManyRequest, opts BulkOptions) (*DeleteSummary, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	filter := req.Filter
+	if req.AllAccounts {
+		filter = &ListFilter{}
+	}
+
+	// versions of listed accounts are already known so only IDs need looking up
+	var selected []accounts.AccountData
+	if filter != nil {
+		err := c.ListAll(ctx, ListOptions{Filter: *filter}, func(account accounts.AccountData) error {
+			selected = append(selected, account)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	n := len(req.IDs) + len(selected)
+	outcomes := make([]deleteOutcome, n)
+
+	deleteOne := func(ctx context.Context, idx int) error {
+		var account accounts.AccountData
+		if filter != nil {
+			account = selected[idx]
+		} else {
+			account = accounts.AccountData{ID: req.IDs[idx]}
+		}
+
+		outcomes[idx] = c.deleteLatest(ctx, account, req.DryRun)
+		return outcomes[idx].err
+	}
+
+	skip := func(idx int, err error) {
+		outcomes[idx].err = err
+		if filter != nil {
+			outcomes[idx].id = selected[idx].ID
+		} else {
+			outcomes[idx].id = req.IDs[idx]
+		}
+	}
+
+	bulkErr := runBulk(ctx, n, opts, deleteOne, skip)
+
+	summary := &DeleteSummary{DryRun: req.DryRun}
+	for _, outcome := range outcomes {
+		switch {
+		case outcome.deleted:
+			summary.Deleted = append(summary.Deleted, DeletedAccount{ID: outcome.id, Version: outcome.version})
+		case outcome.notFound:
+			summary.NotFound = append(summary.NotFound, outcome.id)
+		case outcome.conflict:
+			summary.Conflicted = append(summary.Conflicted, outcome.id)
+		default:
+			summary.Failed = append(summary.Failed, DeleteFailure{ID: outcome.id, Err: outcome.err})
+		}
+	}
+
+	return summary, bulkErr
+}
+
+// deleteLatest deletes the current version of account, looking the version up first if account does not carry it.
+// Missing and conflicted accounts are reported in the outcome rather than as errors.
+func (c *Client) deleteLatest(ctx context.Context, account accounts.AccountData, dryRun bool) deleteOutcome {
+	outcome := deleteOutcome{id: account.ID}
+
+	if account.Version == nil {
+		fetched, err := c.Fetch(ctx, account.ID)
+		if StatusCode(err) == http.StatusNotFound {
+			outcome.notFound = true
+			return outcome
+		}
+
+		if err != nil {
+			outcome.err = err
+			return outcome
+		}
+
+		if fetched.Data == nil || fetched.Data.Version == nil {
+			outcome.err = newInternalError("fetched account has no version", nil)
+			return outcome
+		}
+
+		account.Version = fetched.Data.Version
+	}
+
+	outcome.version = uint(*account.Version)
+
+	if dryRun {
+		outcome.deleted = true
+		return outcome
+	}
+
+	err := c.Delete(ctx, account.ID, outcome.version)
+	switch StatusCode(err) {
+	case http.StatusNotFound:
+		outcome.notFound = true
+	case http.StatusConflict:
+		outcome.conflict = true
+	default:
+		outcome.deleted = err == nil
+		outcome.err = err
+	}
+
+	return outcome
+}
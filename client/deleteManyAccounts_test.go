@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteMany_invalidRequest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		req    DeleteManyRequest
+		errMsg string
+	}{
+		{name: "nothing selected", req: DeleteManyRequest{}, errMsg: "input error - exactly one of IDs, Filter or AllAccounts must be provided"},
+		{name: "IDs and filter", req: DeleteManyRequest{IDs: []string{"a"}, Filter: &ListFilter{Country: "GB"}}, errMsg: "input error - exactly one of IDs, Filter or AllAccounts must be provided"},
+		{name: "filter and all accounts", req: DeleteManyRequest{Filter: &ListFilter{Country: "GB"}, AllAccounts: true}, errMsg: "input error - exactly one of IDs, Filter or AllAccounts must be provided"},
+		{name: "empty filter", req: DeleteManyRequest{Filter: &ListFilter{}}, errMsg: "input error - filter must set at least one field, use AllAccounts to delete every account"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			transport := &mockRoundTripper{transportFunc: func(req *http.Request) (*http.Response, error) {
+				t.Errorf("unexpected request %s %s", req.Method, req.URL)
+				return nil, errors.New("unexpected request")
+			}}
+
+			c, err := NewClient("http://0.0.0.0:8080", transport)
+			assert.NoError(t, err)
+
+			_, err = c.DeleteMany(context.Background(), tc.req, BulkOptions{})
+			assert.Equal(t, tc.errMsg, err.Error())
+		})
+	}
+}
+
+func TestDeleteMany_allAccountsDeletesEveryAccount(t *testing.T) {
+	other := testAccount(testAccountIDB)
+	other.OrganisationID, other.Version = "org-2", ptrInt64(1)
+	api := fakeapi.NewServer(testAccount(testAccountIDA), other)
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err := c.DeleteMany(context.Background(), DeleteManyRequest{AllAccounts: true}, BulkOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, &DeleteSummary{Deleted: []DeletedAccount{{ID: testAccountIDA, Version: 0}, {ID: testAccountIDB, Version: 1}}}, summary)
+	assert.Empty(t, api.Accounts())
+}
+
+func TestDeleteMany_byIDsLooksUpVersions(t *testing.T) {
+	a, b, c := testAccount(testAccountIDA), testAccount(testAccountIDB), testAccount(testAccountIDC)
+	a.Version, c.Version = ptrInt64(2), ptrInt64(1)
+	api := fakeapi.NewServer(a, b, c)
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err := cl.DeleteMany(
+		context.Background(),
+		DeleteManyRequest{IDs: []string{testAccountIDA, testMissingAccountID, testAccountIDB, testAccountIDC}},
+		BulkOptions{Concurrency: 2},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		&DeleteSummary{
+			Deleted:  []DeletedAccount{{ID: testAccountIDA, Version: 2}, {ID: testAccountIDB, Version: 0}, {ID: testAccountIDC, Version: 1}},
+			NotFound: []string{testMissingAccountID},
+		},
+		summary,
+	)
+	assert.Empty(t, api.Accounts())
+}
+
+func TestDeleteMany_byFilterUsesListedVersions(t *testing.T) {
+	a, b, c := testAccount(testAccountIDA), testAccount(testAccountIDB), testAccount(testAccountIDC)
+	a.OrganisationID, b.OrganisationID, c.OrganisationID = "org-1", "org-2", "org-1"
+	c.Version = ptrInt64(3)
+	api := fakeapi.NewServer(a, b, c)
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err := cl.DeleteMany(
+		context.Background(),
+		DeleteManyRequest{Filter: &ListFilter{OrganisationID: "org-1"}},
+		BulkOptions{},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, &DeleteSummary{Deleted: []DeletedAccount{{ID: testAccountIDA, Version: 0}, {ID: testAccountIDC, Version: 3}}}, summary)
+	assert.Equal(t, []string{testAccountIDB}, accountIDs(api.Accounts()))
+
+	// versions came from the list so no account was fetched individually
+	for _, request := range api.Requests() {
+		assert.False(t, strings.HasPrefix(request, http.MethodGet+" "+basev1AccountsPath+"/"), request)
+	}
+}
+
+func TestDeleteMany_dryRunDeletesNothing(t *testing.T) {
+	account := testAccount(testAccountIDA)
+	account.Version = ptrInt64(4)
+	api := fakeapi.NewServer(account)
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err := c.DeleteMany(
+		context.Background(),
+		DeleteManyRequest{IDs: []string{testAccountIDA, testMissingAccountID}, DryRun: true},
+		BulkOptions{},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		&DeleteSummary{DryRun: true, Deleted: []DeletedAccount{{ID: testAccountIDA, Version: 4}}, NotFound: []string{testMissingAccountID}},
+		summary,
+	)
+	assert.Equal(t, []string{testAccountIDA}, accountIDs(api.Accounts()))
+}
+
+func TestDeleteMany_reportsConflictsAndFailures(t *testing.T) {
+	const conflictedID, brokenID, okID = testAccountIDA, testAccountIDB, testAccountIDC
+	api := fakeapi.NewServer(testAccount(conflictedID), testAccount(okID))
+
+	// bump the version of the conflicted account between it being looked up and deleted
+	// and fail every request for the broken account
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/"+brokenID) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error_message": "boom"}`))
+			return
+		}
+
+		if r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/"+conflictedID) {
+			account := testAccount(conflictedID)
+			account.Version = ptrInt64(1)
+			api.Put(account)
+		}
+
+		api.ServeHTTP(w, r)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err := c.DeleteMany(
+		context.Background(),
+		DeleteManyRequest{IDs: []string{conflictedID, brokenID, okID}},
+		BulkOptions{},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []DeletedAccount{{ID: okID, Version: 0}}, summary.Deleted)
+	assert.Equal(t, []string{conflictedID}, summary.Conflicted)
+	assert.Len(t, summary.Failed, 1)
+	assert.Equal(t, brokenID, summary.Failed[0].ID)
+	assert.Equal(t, "api error - failed to fetch account, status code 500: boom", summary.Failed[0].Err.Error())
+}
+
+func TestDeleteMany_stopOnErrorReportsSkippedAccountsAsFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error_message": "boom"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err := c.DeleteMany(
+		context.Background(),
+		DeleteManyRequest{IDs: []string{"a", "b", "c"}},
+		BulkOptions{Concurrency: 1, StopOnError: true},
+	)
+	assert.Equal(t, "api error - failed to fetch account, status code 500: boom", err.Error())
+
+	ids := make([]string, 0, len(summary.Failed))
+	skipped := 0
+	for _, failure := range summary.Failed {
+		ids = append(ids, failure.ID)
+		if failure.Err == ErrSkipped {
+			skipped++
+		}
+	}
+
+	sort.Strings(ids)
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	assert.GreaterOrEqual(t, skipped, 1)
+}
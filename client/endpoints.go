@@ -0,0 +1,371 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEndpointHealthCheckInterval = 10 * time.Second
+	defaultEndpointCooldown            = 30 * time.Second
+
+	// endpointHealthCheckTimeout bounds a single active health check
+	endpointHealthCheckTimeout = 5 * time.Second
+	// latencySmoothing is the weight of the latest sample in the moving average latency of an endpoint
+	latencySmoothing = 0.2
+
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// EndpointPolicy decides which endpoint of the API a request is sent to first
+type EndpointPolicy int
+
+const (
+	// EndpointFailover sends requests to the first healthy endpoint in the order given, e.g. primary then secondary
+	EndpointFailover EndpointPolicy = iota
+	// EndpointRoundRobin spreads requests evenly over the healthy endpoints
+	EndpointRoundRobin
+	// EndpointLowestLatency sends requests to the healthy endpoint that has been answering fastest
+	EndpointLowestLatency
+
+	endpointFailoverStr      = "failover"
+	endpointRoundRobinStr    = "round-robin"
+	endpointLowestLatencyStr = "lowest-latency"
+)
+
+func (p EndpointPolicy) String() string {
+	switch p {
+	case EndpointFailover:
+		return endpointFailoverStr
+	case EndpointRoundRobin:
+		return endpointRoundRobinStr
+	case EndpointLowestLatency:
+		return endpointLowestLatencyStr
+	default:
+		return ""
+	}
+}
+
+// EndpointsConfig configures a client that talks to several deployments of the API. Zero values are replaced with defaults.
+type EndpointsConfig struct {
+	// Endpoints are the base URLs of the other deployments, the host given to NewClient is the first endpoint
+	Endpoints []string
+	// Policy decides which healthy endpoint a request is sent to first, default EndpointFailover
+	Policy EndpointPolicy
+	// HealthCheckInterval is how often the health of each endpoint is checked while the client is in use,
+	// default 10 seconds. A negative interval turns health checks off, leaving only failed requests to mark endpoints unhealthy.
+	HealthCheckInterval time.Duration
+	// Cooldown is how long a failed endpoint is avoided unless a health check finds it healthy sooner, default 30 seconds
+	Cooldown time.Duration
+	// OnHealthChange, if set, is called whenever an endpoint becomes healthy or unhealthy
+	OnHealthChange func(endpoint string, healthy bool)
+}
+
+// WithEndpoints makes the client send requests to several deployments of the API, e.g. a primary and a secondary.
+// Endpoints are marked unhealthy when requests to them fail and checked through the health endpoint in the background.
+// A request that fails on one endpoint is only sent to the next if that is safe: for reads, and for writes
+// whose context carries an idempotency key, see WithIdempotencyKey. Other writes fail without being resent.
+func WithEndpoints(epConfig EndpointsConfig) Option {
+	return func(cfg *config) error {
+		if len(epConfig.Endpoints) == 0 {
+			return newInputError("at least one further endpoint is required", nil)
+		}
+
+		for _, endpoint := range epConfig.Endpoints {
+			if _, err := baseURL(endpoint); err != nil {
+				return newInputError(fmt.Sprintf("invalid endpoint %q", endpoint), err)
+			}
+		}
+
+		if epConfig.Policy.String() == "" {
+			return newInputError(fmt.Sprintf("unknown endpoint policy %d", epConfig.Policy), nil)
+		}
+
+		if epConfig.Cooldown < 0 {
+			return newInputError("endpoint cooldown cannot be negative", nil)
+		}
+
+		epConfig.Endpoints = append([]string(nil), epConfig.Endpoints...)
+		cfg.endpoints = &epConfig
+		return nil
+	}
+}
+
+// WithIdempotencyKey returns a copy of ctx that makes writes sent with it carry key in an Idempotency-Key header.
+// The API applies a write at most once per key, so the client may resend such writes to another endpoint.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+type idempotencyKeyKey struct{}
+
+// idempotencyKeyFromContext returns the idempotency key carried by ctx, or "" if there is none
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
+
+// baseURL returns the scheme and host of host, which must be an absolute URL
+func baseURL(host string) (string, error) {
+	parsedURL, err := url.Parse(host)
+	if err != nil {
+		return "", err
+	}
+
+	if parsedURL.Host == "" {
+		return "", fmt.Errorf("%q has no host", host)
+	}
+
+	return fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host), nil
+}
+
+// canFailover reports whether a request may be resent to another endpoint after failing on one
+func canFailover(ctx context.Context, method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || idempotencyKeyFromContext(ctx) != ""
+}
+
+// endpointFailed reports whether the outcome of a request shows that its endpoint is unhealthy.
+// As for the circuit breaker, failing to get a response, rate limiting and server errors count against the endpoint.
+func endpointFailed(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// endpointSet tracks the health and latency of the endpoints of the API and orders them for each request
+type endpointSet struct {
+	cfg EndpointsConfig
+	now func() time.Time
+	// check sends a health check to the endpoint at baseURL
+	check func(ctx context.Context, baseURL string) error
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	// next is the position of the endpoint that goes first for the next request under EndpointRoundRobin
+	next int
+}
+
+// endpoint is the state of a single deployment of the API
+type endpoint struct {
+	baseURL string
+	healthy bool
+	// avoidUntil is when a failed endpoint may be tried again without a health check finding it healthy
+	avoidUntil time.Time
+	// latency is the moving average response time, zero until the endpoint has answered
+	latency   time.Duration
+	lastCheck time.Time
+	checking  bool
+}
+
+// healthChange is a change that must be reported to EndpointsConfig.OnHealthChange
+type healthChange struct {
+	endpoint string
+	healthy  bool
+}
+
+// newEndpointSet returns an endpointSet for baseURLs with defaults applied to cfg
+func newEndpointSet(cfg EndpointsConfig, baseURLs []string) *endpointSet {
+	if cfg.HealthCheckInterval == 0 {
+		cfg.HealthCheckInterval = defaultEndpointHealthCheckInterval
+	}
+
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = defaultEndpointCooldown
+	}
+
+	es := &endpointSet{cfg: cfg, now: time.Now}
+	for _, baseURL := range baseURLs {
+		es.endpoints = append(es.endpoints, &endpoint{baseURL: baseURL, healthy: true})
+	}
+
+	return es
+}
+
+// available reports whether requests should be sent to ep. es.mu must be held.
+func (es *endpointSet) available(ep *endpoint, now time.Time) bool {
+	return ep.healthy || !now.Before(ep.avoidUntil)
+}
+
+// order returns the base URLs of the endpoints in the order a request should try them:
+// the available endpoints as the policy prefers them, followed by the rest as a last resort.
+// It also starts the health checks that are due.
+func (es *endpointSet) order() []string {
+	es.mu.Lock()
+
+	now := es.now()
+	var available, unavailable []*endpoint
+	for _, ep := range es.endpoints {
+		if es.available(ep, now) {
+			available = append(available, ep)
+		} else {
+			unavailable = append(unavailable, ep)
+		}
+	}
+
+	switch es.cfg.Policy {
+	case EndpointRoundRobin:
+		if len(available) > 0 {
+			first := es.next % len(available)
+			available = append(append([]*endpoint(nil), available[first:]...), available[:first]...)
+			es.next++
+		}
+	case EndpointLowestLatency:
+		// endpoints that have not answered yet go first so that their latency gets measured
+		sort.SliceStable(available, func(i, j int) bool {
+			return available[i].latency < available[j].latency
+		})
+	}
+
+	baseURLs := make([]string, 0, len(es.endpoints))
+	for _, ep := range append(available, unavailable...) {
+		baseURLs = append(baseURLs, ep.baseURL)
+	}
+
+	due := es.dueChecks(now)
+	es.mu.Unlock()
+
+	for _, baseURL := range due {
+		go es.runCheck(baseURL)
+	}
+
+	return baseURLs
+}
+
+// dueChecks marks the endpoints whose health check is due as being checked and returns them. es.mu must be held.
+func (es *endpointSet) dueChecks(now time.Time) []string {
+	if es.cfg.HealthCheckInterval < 0 || es.check == nil {
+		return nil
+	}
+
+	var due []string
+	for _, ep := range es.endpoints {
+		if !ep.checking && now.Sub(ep.lastCheck) >= es.cfg.HealthCheckInterval {
+			ep.checking = true
+			due = append(due, ep.baseURL)
+		}
+	}
+
+	return due
+}
+
+// runCheck checks the health of the endpoint at baseURL and records the result
+func (es *endpointSet) runCheck(baseURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), endpointHealthCheckTimeout)
+	defer cancel()
+
+	start := es.now()
+	err := es.check(ctx, baseURL)
+	latency := es.now().Sub(start)
+
+	es.mu.Lock()
+	ep := es.endpoint(baseURL)
+	ep.checking = false
+	ep.lastCheck = es.now()
+	es.mu.Unlock()
+
+	if err != nil {
+		es.failed(baseURL)
+		return
+	}
+
+	es.succeeded(baseURL, latency)
+}
+
+// succeeded records that the endpoint at baseURL answered in latency
+func (es *endpointSet) succeeded(baseURL string, latency time.Duration) {
+	es.mu.Lock()
+
+	var changes []healthChange
+	ep := es.endpoint(baseURL)
+	if !ep.healthy {
+		ep.healthy = true
+		changes = append(changes, healthChange{endpoint: baseURL, healthy: true})
+	}
+
+	if ep.latency == 0 {
+		ep.latency = latency
+	} else {
+		ep.latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(ep.latency))
+	}
+
+	es.mu.Unlock()
+	es.notify(changes)
+}
+
+// failed records that the endpoint at baseURL failed, so that it is avoided for the cooldown
+func (es *endpointSet) failed(baseURL string) {
+	es.mu.Lock()
+
+	var changes []healthChange
+	ep := es.endpoint(baseURL)
+	if ep.healthy {
+		ep.healthy = false
+		changes = append(changes, healthChange{endpoint: baseURL, healthy: false})
+	}
+
+	ep.avoidUntil = es.now().Add(es.cfg.Cooldown)
+
+	es.mu.Unlock()
+	es.notify(changes)
+}
+
+// endpoint returns the endpoint with baseURL, which must be one of the set. es.mu must be held.
+func (es *endpointSet) endpoint(baseURL string) *endpoint {
+	for _, ep := range es.endpoints {
+		if ep.baseURL == baseURL {
+			return ep
+		}
+	}
+
+	panic("client: unknown endpoint " + baseURL)
+}
+
+// notify reports changes to the OnHealthChange callback. It must be called without holding es.mu
+// so that callbacks are free to use the client.
+func (es *endpointSet) notify(changes []healthChange) {
+	if es.cfg.OnHealthChange == nil {
+		return
+	}
+
+	for _, change := range changes {
+		es.cfg.OnHealthChange(change.endpoint, change.healthy)
+	}
+}
+
+// checkEndpoint asks the endpoint at baseURL for its health, returning an error unless it reports itself as up
+func (c *Client) checkEndpoint(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+healthPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed, status code %d", resp.StatusCode)
+	}
+
+	var health Health
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return err
+	}
+
+	if !health.Up() {
+		return fmt.Errorf("status is %q", health.Status)
+	}
+
+	return nil
+}
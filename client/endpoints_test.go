@@ -0,0 +1,283 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	primaryURL   = "http://primary:8080"
+	secondaryURL = "http://secondary:8080"
+)
+
+// endpointsRoundTripper answers requests with the handler of the host they are sent to and records the hosts in order
+type endpointsRoundTripper struct {
+	mu       sync.Mutex
+	hosts    []string
+	requests []*http.Request
+	handlers map[string]func(req *http.Request) (*http.Response, error)
+}
+
+func (ert *endpointsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ert.mu.Lock()
+	ert.hosts = append(ert.hosts, req.URL.Host)
+	ert.requests = append(ert.requests, req)
+	handler := ert.handlers[req.URL.Host]
+	ert.mu.Unlock()
+
+	return handler(req)
+}
+
+func (ert *endpointsRoundTripper) sentTo() []string {
+	ert.mu.Lock()
+	defer ert.mu.Unlock()
+
+	hosts := ert.hosts
+	ert.hosts = nil
+	return hosts
+}
+
+func answerWith(statusCode int, body string) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: statusCode, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+	}
+}
+
+func refuseConnection(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+const fetchedAccountBody = `{"data":{"id":"1dfaf917-c6d6-4e18-b7e7-972e66492976"}}`
+
+// newEndpointsClient returns a client for the primary and secondary endpoints without active health checks
+func newEndpointsClient(t *testing.T, ert *endpointsRoundTripper, policy EndpointPolicy) (*Client, *fakeClock) {
+	c, err := NewClient(primaryURL, ert, WithEndpoints(EndpointsConfig{
+		Endpoints:           []string{secondaryURL},
+		Policy:              policy,
+		HealthCheckInterval: -1,
+		Cooldown:            time.Minute,
+	}))
+	assert.NoError(t, err)
+
+	clock := &fakeClock{now: getDummyTime()}
+	c.endpoints.now = clock.Now
+
+	return c, clock
+}
+
+func TestWithEndpoints_invalidConfigReturnsError(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cfg         EndpointsConfig
+		expectedErr string
+	}{
+		{name: "no endpoints", cfg: EndpointsConfig{}, expectedErr: "input error - at least one further endpoint is required"},
+		{name: "invalid endpoint", cfg: EndpointsConfig{Endpoints: []string{"secondary"}}, expectedErr: `input error - invalid endpoint "secondary": "secondary" has no host`},
+		{name: "unknown policy", cfg: EndpointsConfig{Endpoints: []string{secondaryURL}, Policy: 7}, expectedErr: "input error - unknown endpoint policy 7"},
+		{name: "negative cooldown", cfg: EndpointsConfig{Endpoints: []string{secondaryURL}, Cooldown: -time.Second}, expectedErr: "input error - endpoint cooldown cannot be negative"},
+		{name: "duplicate endpoint", cfg: EndpointsConfig{Endpoints: []string{primaryURL + "/v1"}}, expectedErr: `input error - duplicate endpoint "http://primary:8080/v1"`},
+	}
+
+	for idx, tc := range testCases {
+		_, err := NewClient(primaryURL, nil, WithEndpoints(tc.cfg))
+		assert.EqualError(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
+
+func TestEndpoints_readsFailOverAndAvoidFailedEndpointForCooldown(t *testing.T) {
+	ert := &endpointsRoundTripper{handlers: map[string]func(req *http.Request) (*http.Response, error){
+		"primary:8080":   answerWith(http.StatusServiceUnavailable, `{"error_message":"down for maintenance"}`),
+		"secondary:8080": answerWith(http.StatusOK, fetchedAccountBody),
+	}}
+
+	var changes []string
+	c, clock := newEndpointsClient(t, ert, EndpointFailover)
+	c.endpoints.cfg.OnHealthChange = func(endpoint string, healthy bool) {
+		changes = append(changes, fmt.Sprintf("%s healthy=%t", endpoint, healthy))
+	}
+
+	resp, err := c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+	assert.Equal(t, "1dfaf917-c6d6-4e18-b7e7-972e66492976", resp.Data.ID)
+	assert.Equal(t, []string{"primary:8080", "secondary:8080"}, ert.sentTo())
+	assert.Equal(t, []string{"http://primary:8080 healthy=false"}, changes)
+
+	// both attempts belong to the same operation
+	assert.Equal(t, ert.requests[0].Header.Get(requestIDHeader), ert.requests[1].Header.Get(requestIDHeader))
+
+	// the primary is avoided while it cools down
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"secondary:8080"}, ert.sentTo())
+
+	// and tried again once it has, becoming healthy when it answers
+	ert.handlers["primary:8080"] = answerWith(http.StatusOK, fetchedAccountBody)
+	clock.Advance(time.Minute)
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"primary:8080"}, ert.sentTo())
+	assert.Equal(t, []string{"http://primary:8080 healthy=false", "http://primary:8080 healthy=true"}, changes)
+}
+
+func TestEndpoints_allEndpointsFailing(t *testing.T) {
+	ert := &endpointsRoundTripper{handlers: map[string]func(req *http.Request) (*http.Response, error){
+		"primary:8080":   refuseConnection,
+		"secondary:8080": answerWith(http.StatusBadGateway, `{"error_message":"bad gateway"}`),
+	}}
+
+	c, _ := newEndpointsClient(t, ert, EndpointFailover)
+
+	// the last endpoint's answer is returned
+	_, err := c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.Equal(t, http.StatusBadGateway, StatusCode(err))
+	assert.Equal(t, []string{"primary:8080", "secondary:8080"}, ert.sentTo())
+
+	// with every endpoint cooling down they are still tried as a last resort
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.Equal(t, http.StatusBadGateway, StatusCode(err))
+	assert.Equal(t, []string{"primary:8080", "secondary:8080"}, ert.sentTo())
+}
+
+func TestEndpoints_writesOnlyFailOverWithIdempotencyKey(t *testing.T) {
+	ert := &endpointsRoundTripper{handlers: map[string]func(req *http.Request) (*http.Response, error){
+		"primary:8080":   refuseConnection,
+		"secondary:8080": answerWith(http.StatusCreated, fetchedAccountBody),
+	}}
+
+	c, clock := newEndpointsClient(t, ert, EndpointFailover)
+	account := accounts.AccountData{ID: "1dfaf917-c6d6-4e18-b7e7-972e66492976"}
+
+	_, err := c.Create(context.Background(), account)
+	assert.EqualError(t, err, "internal error - failed to send http request: Post \"http://primary:8080/v1/organisation/accounts\": connection refused")
+	assert.Equal(t, []string{"primary:8080"}, ert.sentTo())
+
+	clock.Advance(time.Minute)
+	ctx := WithIdempotencyKey(context.Background(), "create-1dfaf917")
+	_, err = c.Create(ctx, account)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"primary:8080", "secondary:8080"}, ert.sentTo())
+
+	for _, req := range ert.requests[1:] {
+		assert.Equal(t, "create-1dfaf917", req.Header.Get(idempotencyKeyHeader))
+	}
+
+	clock.Advance(time.Minute)
+	err = c.Delete(context.Background(), account.ID, 0)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"primary:8080"}, ert.sentTo())
+}
+
+func TestEndpoints_roundRobin(t *testing.T) {
+	ert := &endpointsRoundTripper{handlers: map[string]func(req *http.Request) (*http.Response, error){
+		"primary:8080":   answerWith(http.StatusOK, fetchedAccountBody),
+		"secondary:8080": answerWith(http.StatusOK, fetchedAccountBody),
+	}}
+
+	c, _ := newEndpointsClient(t, ert, EndpointRoundRobin)
+	for idx := 0; idx < 4; idx++ {
+		_, err := c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"primary:8080", "secondary:8080", "primary:8080", "secondary:8080"}, ert.sentTo())
+}
+
+func TestEndpointSet_lowestLatency(t *testing.T) {
+	es := newEndpointSet(EndpointsConfig{Policy: EndpointLowestLatency, HealthCheckInterval: -1}, []string{primaryURL, secondaryURL, "http://tertiary:8080"})
+
+	// endpoints that have not answered yet go first
+	es.succeeded(primaryURL, 50*time.Millisecond)
+	assert.Equal(t, []string{secondaryURL, "http://tertiary:8080", primaryURL}, es.order())
+
+	es.succeeded(secondaryURL, 10*time.Millisecond)
+	es.succeeded("http://tertiary:8080", 30*time.Millisecond)
+	assert.Equal(t, []string{secondaryURL, "http://tertiary:8080", primaryURL}, es.order())
+
+	// the average moves towards slower answers
+	for idx := 0; idx < 10; idx++ {
+		es.succeeded(secondaryURL, 100*time.Millisecond)
+	}
+
+	assert.Equal(t, []string{"http://tertiary:8080", primaryURL, secondaryURL}, es.order())
+
+	// failed endpoints go last whatever their latency
+	es.failed("http://tertiary:8080")
+	assert.Equal(t, []string{primaryURL, secondaryURL, "http://tertiary:8080"}, es.order())
+}
+
+func TestEndpointSet_healthChecksRestoreEndpoints(t *testing.T) {
+	changes := make(chan string, 10)
+	es := newEndpointSet(EndpointsConfig{
+		HealthCheckInterval: time.Millisecond,
+		Cooldown:            time.Hour,
+		OnHealthChange: func(endpoint string, healthy bool) {
+			changes <- fmt.Sprintf("%s healthy=%t", endpoint, healthy)
+		},
+	}, []string{primaryURL, secondaryURL})
+
+	var mu sync.Mutex
+	checked := map[string]int{}
+	es.check = func(ctx context.Context, baseURL string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		checked[baseURL]++
+		return nil
+	}
+
+	es.failed(primaryURL)
+	assert.Equal(t, "http://primary:8080 healthy=false", <-changes)
+	assert.Equal(t, []string{secondaryURL, primaryURL}, es.order())
+
+	// the check started by order finds the primary healthy long before its cooldown is over
+	select {
+	case change := <-changes:
+		assert.Equal(t, "http://primary:8080 healthy=true", change)
+	case <-time.After(5 * time.Second):
+		t.Fatal("primary was not checked")
+	}
+
+	assert.Equal(t, []string{primaryURL, secondaryURL}, es.order())
+
+	// the secondary is checked in the background too, and may finish after the primary
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return checked[secondaryURL] >= 1
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestClient_checkEndpoint(t *testing.T) {
+	testCases := []struct {
+		name        string
+		statusCode  int
+		body        string
+		expectedErr string
+	}{
+		{name: "up", statusCode: http.StatusOK, body: `{"status":"up"}`},
+		{name: "down", statusCode: http.StatusOK, body: `{"status":"down"}`, expectedErr: `status is "down"`},
+		{name: "unavailable", statusCode: http.StatusServiceUnavailable, body: ``, expectedErr: "health check failed, status code 503"},
+	}
+
+	for idx, tc := range testCases {
+		c, err := NewClient(primaryURL, healthRoundTripper(tc.statusCode, tc.body))
+		assert.NoError(t, err)
+
+		err = c.checkEndpoint(context.Background(), secondaryURL)
+		if tc.expectedErr == "" {
+			assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		} else {
+			assert.EqualError(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		}
+	}
+}
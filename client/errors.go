@@ -1,6 +1,9 @@
 package client
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type clientErrType int
 
@@ -8,16 +11,19 @@ const (
 	apiError clientErrType = iota
 	internalError
 	inputError
+	unavailableError
 
-	apiErrorStr      = "api error"
-	internalErrorStr = "internal error"
-	inputErrorStr    = "input error"
+	apiErrorStr         = "api error"
+	internalErrorStr    = "internal error"
+	inputErrorStr       = "input error"
+	unavailableErrorStr = "unavailable error"
 )
 
 var clientErrors = map[clientErrType]string{
-	apiError:      apiErrorStr,
-	internalError: internalErrorStr,
-	inputError:    inputErrorStr,
+	apiError:         apiErrorStr,
+	internalError:    internalErrorStr,
+	inputError:       inputErrorStr,
+	unavailableError: unavailableErrorStr,
 }
 
 // clientError is an error type that is used to represent errors that occur in the client
@@ -25,6 +31,9 @@ type clientError struct {
 	code clientErrType
 	msg  string
 	err  error
+
+	// statusCode is the status code of the API response that caused an apiError, if there was one
+	statusCode int
 }
 
 // newInternalError is a helper function that constructs a new clientError of code inputError with the given message and error.
@@ -37,11 +46,21 @@ func newApiError(msg string, err error) *clientError {
 	return &clientError{code: apiError, msg: msg, err: err}
 }
 
+// newApiStatusError is a helper function that constructs a new clientError of code apiError caused by an API response with the given status code.
+func newApiStatusError(statusCode int, msg string, err error) *clientError {
+	return &clientError{code: apiError, msg: msg, err: err, statusCode: statusCode}
+}
+
 // newInputError is a helper function that constructs a new clientError of code inputError with the given message and error.
 func newInputError(msg string, err error) *clientError {
 	return &clientError{code: inputError, msg: msg, err: err}
 }
 
+// newUnavailableError is a helper function that constructs a new clientError of code unavailableError with the given message and error.
+func newUnavailableError(msg string, err error) *clientError {
+	return &clientError{code: unavailableError, msg: msg, err: err}
+}
+
 func (cerr *clientError) Error() string {
 	errMsg := fmt.Sprintf("%s - %v", clientErrors[cerr.code], cerr.msg)
 	if cerr.err != nil {
@@ -50,3 +69,67 @@ func (cerr *clientError) Error() string {
 
 	return errMsg
 }
+
+// Unwrap returns the error underlying cerr so that it can be inspected with errors.Is and errors.As
+func (cerr *clientError) Unwrap() error {
+	return cerr.err
+}
+
+// StatusCode returns the HTTP status code of the API response that caused err,
+// or 0 if err was not caused by an API response
+func StatusCode(err error) int {
+	var cerr *clientError
+	if errors.As(err, &cerr) {
+		return cerr.statusCode
+	}
+
+	return 0
+}
+
+// IsAPIError reports whether err was caused by the API rejecting a request
+func IsAPIError(err error) bool {
+	return hasErrType(err, apiError)
+}
+
+// IsInternalError reports whether err was caused by a problem in the client or on the way to the API
+func IsInternalError(err error) bool {
+	return hasErrType(err, internalError)
+}
+
+// IsInputError reports whether err was caused by invalid input from the caller
+func IsInputError(err error) bool {
+	return hasErrType(err, inputError)
+}
+
+// IsUnavailableError reports whether err was caused by the client refusing to send a request, e.g. because a circuit breaker is open
+func IsUnavailableError(err error) bool {
+	return hasErrType(err, unavailableError)
+}
+
+// hasErrType reports whether err is a clientError of type code
+func hasErrType(err error, code clientErrType) bool {
+	var cerr *clientError
+	return errors.As(err, &cerr) && cerr.code == code
+}
+
+// NewAPIError returns an error like the one the client returns when the API responds with statusCode,
+// so that test doubles such as clienttest.Fake can fail in the same way as the client
+func NewAPIError(statusCode int, msg string) error {
+	return newApiStatusError(statusCode, msg, nil)
+}
+
+// NewInternalError returns an error like the one the client returns when a request cannot be sent or its response read
+func NewInternalError(msg string, err error) error {
+	return newInternalError(msg, err)
+}
+
+// NewInputError returns an error like the one the client returns for invalid arguments
+func NewInputError(msg string, err error) error {
+	return newInputError(msg, err)
+}
+
+// NewUnavailableError returns an error like the one the client returns when it refuses to send a request,
+// e.g. because a circuit breaker is open
+func NewUnavailableError(msg string, err error) error {
+	return newUnavailableError(msg, err)
+}
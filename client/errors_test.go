@@ -1,6 +1,7 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -25,6 +26,12 @@ func TestNewApiError_constructsCorrectly(t *testing.T) {
 	assert.Equal(t, &clientError{code: apiError, msg: "test1", err: originalErr}, err)
 }
 
+func TestNewApiStatusError_constructsCorrectly(t *testing.T) {
+	originalErr := fmt.Errorf("test suffix")
+	err := newApiStatusError(404, "test", originalErr)
+	assert.Equal(t, &clientError{code: apiError, msg: "test", err: originalErr, statusCode: 404}, err)
+}
+
 func TestNewInputError_constructsCorrectly(t *testing.T) {
 	err := newInputError("test", nil)
 	assert.Equal(t, &clientError{code: inputError, msg: "test"}, err)
@@ -57,3 +64,73 @@ func TestInputError_ErrorMsgFormatsCorrectly(t *testing.T) {
 	err = newInputError("test1", fmt.Errorf("test1 suffix"))
 	assert.Equal(t, "input error - test1: test1 suffix", err.Error())
 }
+
+func TestNewUnavailableError_constructsCorrectly(t *testing.T) {
+	err := newUnavailableError("test", nil)
+	assert.Equal(t, &clientError{code: unavailableError, msg: "test"}, err)
+
+	originalErr := fmt.Errorf("test1 suffix")
+	err = newUnavailableError("test1", fmt.Errorf("test1 suffix"))
+	assert.Equal(t, &clientError{code: unavailableError, msg: "test1", err: originalErr}, err)
+}
+
+func TestUnavailableError_ErrorMsgFormatsCorrectly(t *testing.T) {
+	err := newUnavailableError("test", nil)
+	assert.Equal(t, "unavailable error - test", err.Error())
+
+	err = newUnavailableError("test1", fmt.Errorf("test1 suffix"))
+	assert.Equal(t, "unavailable error - test1: test1 suffix", err.Error())
+}
+
+func TestClientError_Unwrap(t *testing.T) {
+	originalErr := fmt.Errorf("original")
+	assert.True(t, errors.Is(newInternalError("test", originalErr), originalErr))
+	assert.Nil(t, newInputError("test", nil).Unwrap())
+}
+
+func TestStatusCode(t *testing.T) {
+	assert.Equal(t, 409, StatusCode(newApiStatusError(409, "test", nil)))
+	assert.Equal(t, 409, StatusCode(fmt.Errorf("wrapped: %w", newApiStatusError(409, "test", nil))))
+	assert.Equal(t, 0, StatusCode(newInternalError("test", nil)))
+	assert.Equal(t, 0, StatusCode(fmt.Errorf("test")))
+}
+
+func TestErrorKinds(t *testing.T) {
+	testCases := []struct {
+		name                                      string
+		err                                       error
+		isAPI, isInternal, isInput, isUnavailable bool
+	}{
+		{name: "api error", err: newApiStatusError(404, "test", nil), isAPI: true},
+		{name: "internal error", err: newInternalError("test", nil), isInternal: true},
+		{name: "input error", err: newInputError("test", nil), isInput: true},
+		{name: "unavailable error", err: newUnavailableError("test", ErrCircuitOpen), isUnavailable: true},
+		{name: "wrapped client error", err: fmt.Errorf("wrapped: %w", newInputError("test", nil)), isInput: true},
+		{name: "other error", err: fmt.Errorf("test")},
+		{name: "nil error", err: nil},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.isAPI, IsAPIError(tc.err))
+			assert.Equal(t, tc.isInternal, IsInternalError(tc.err))
+			assert.Equal(t, tc.isInput, IsInputError(tc.err))
+			assert.Equal(t, tc.isUnavailable, IsUnavailableError(tc.err))
+		})
+	}
+}
+
+func TestExportedErrorConstructors(t *testing.T) {
+	err := NewAPIError(409, "duplicate")
+	assert.True(t, IsAPIError(err))
+	assert.Equal(t, 409, StatusCode(err))
+	assert.Equal(t, "api error - duplicate", err.Error())
+
+	cause := errors.New("connection reset")
+	err = NewInternalError("failed to send http request", cause)
+	assert.True(t, IsInternalError(err))
+	assert.ErrorIs(t, err, cause)
+
+	assert.True(t, IsInputError(NewInputError("accountID cannot be empty", nil)))
+	assert.True(t, IsUnavailableError(NewUnavailableError("circuit breaker is open", nil)))
+}
@@ -13,17 +13,48 @@ import (
 // Fetch attempts to get an existing account
 // https://api-docs.form3.tech/api.html#organisation-accounts-fetch
 func (c *Client) Fetch(ctx context.Context, accountID string) (*accounts.Response, error) {
-	if accountID == "" {
+	resp, err := c.invoke(ctx, &OperationRequest{Operation: OperationFetch, AccountID: accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchedAccount(resp)
+}
+
+// fetchedAccount returns the account in a fetch response. It is missing when the API answered 304 Not Modified
+// to validators that no middleware holds a copy of the account for.
+func fetchedAccount(resp *OperationResponse) (*accounts.Response, error) {
+	if resp.NotModified && resp.Account == nil {
+		return nil, newInternalError("the API reported the account unchanged but there is no stored copy of it", nil)
+	}
+
+	return resp.Account, nil
+}
+
+// fetchAccount sends the request to get the account identified by req to the API
+func (c *Client) fetchAccount(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+	if req.AccountID == "" {
 		return nil, newInputError("accountID cannot be empty", nil)
 	}
 
+	header := http.Header{}
+	if req.IfNoneMatch != "" {
+		header.Set("If-None-Match", req.IfNoneMatch)
+	}
+
+	if req.IfModifiedSince != "" {
+		header.Set("If-Modified-Since", req.IfModifiedSince)
+	}
+
 	// send GET request to the accounts endpoint
-	path := fmt.Sprintf("%s/%s", basev1AccountsPath, accountID)
-	resp, err := c.get(ctx, path)
+	path := fmt.Sprintf("%s/%s", basev1AccountsPath, req.AccountID)
+	resp, err := c.getWithHeader(ctx, path, header)
 	if err != nil {
 		return nil, err
 	}
 
+	opResp := &OperationResponse{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+
 	// read response
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -32,6 +63,12 @@ func (c *Client) Fetch(ctx context.Context, accountID string) (*accounts.Respons
 
 	defer resp.Body.Close()
 
+	// handle unchanged account
+	if resp.StatusCode == http.StatusNotModified {
+		opResp.NotModified = true
+		return opResp, nil
+	}
+
 	// handle error response
 	if resp.StatusCode != http.StatusOK {
 		var apiError accounts.ApiError
@@ -39,7 +76,7 @@ func (c *Client) Fetch(ctx context.Context, accountID string) (*accounts.Respons
 			return nil, newInternalError("failed to unmarshal response body", err)
 		}
 
-		return nil, newApiError(fmt.Sprintf("failed to fetch account, status code %d", resp.StatusCode), apiError)
+		return nil, newApiStatusError(resp.StatusCode, fmt.Sprintf("failed to fetch account, status code %d", resp.StatusCode), apiError)
 	}
 
 	// handle success response
@@ -48,5 +85,6 @@ func (c *Client) Fetch(ctx context.Context, accountID string) (*accounts.Respons
 		return nil, newInternalError("failed to unmarshal response body", err)
 	}
 
-	return &fetchAccountResp, nil
+	opResp.Account = &fetchAccountResp
+	return opResp, nil
 }
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// healthPath is the API's health endpoint
+const healthPath = "/v1/health"
+
+// HealthStatus is the status the API reports for itself
+type HealthStatus string
+
+const (
+	HealthStatusUp   HealthStatus = "up"
+	HealthStatusDown HealthStatus = "down"
+)
+
+// Health is the API's report on its own health
+type Health struct {
+	Status HealthStatus `json:"status"`
+}
+
+// Up reports whether the API is ready to serve requests
+func (h *Health) Up() bool {
+	return h != nil && h.Status == HealthStatusUp
+}
+
+// Health asks the API for its health, e.g. for a readiness probe.
+// The check skips the client's middlewares, so an open circuit breaker or a rate limit cannot stop it
+// and it reports the API as it is now. A status other than 200 OK is returned as an API error.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	resp, err := c.get(ctx, healthPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// read response
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newInternalError("failed to read response body", err)
+	}
+
+	defer resp.Body.Close()
+
+	// handle error response
+	if resp.StatusCode != http.StatusOK {
+		return nil, newApiStatusError(resp.StatusCode, fmt.Sprintf("health check failed, status code %d", resp.StatusCode), nil)
+	}
+
+	// handle success response
+	var health Health
+	if err := json.Unmarshal(respBody, &health); err != nil {
+		return nil, newInternalError("failed to unmarshal response body", err)
+	}
+
+	return &health, nil
+}
+
+// WaitUntilHealthy checks the API's health every interval until it is up or ctx is done,
+// in which case an unavailable error describing the last check is returned
+func (c *Client) WaitUntilHealthy(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return newInputError("interval must be positive", nil)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		health, err := c.Health(ctx)
+		if err == nil && health.Up() {
+			return nil
+		}
+
+		if err == nil {
+			err = fmt.Errorf("status is %q", health.Status)
+		}
+
+		// a check cut short by ctx says less about the API than the one before it
+		if lastErr == nil || ctx.Err() == nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return newUnavailableError("account API did not become healthy", fmt.Errorf("%w, last check: %v", ctx.Err(), lastErr))
+		case <-ticker.C:
+		}
+	}
+}
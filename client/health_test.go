@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// healthRoundTripper answers health checks with statusCode and body
+func healthRoundTripper(statusCode int, body string) *mockRoundTripper {
+	return &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: statusCode,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			}, nil
+		},
+	}
+}
+
+func TestHealth(t *testing.T) {
+	testCases := []struct {
+		name           string
+		statusCode     int
+		body           string
+		expectedStatus HealthStatus
+		expectedUp     bool
+		expectedErr    string
+	}{
+		{name: "up", statusCode: http.StatusOK, body: `{"status":"up"}`, expectedStatus: HealthStatusUp, expectedUp: true},
+		{name: "down", statusCode: http.StatusOK, body: `{"status":"down"}`, expectedStatus: HealthStatusDown},
+		{name: "unknown status", statusCode: http.StatusOK, body: `{"status":"starting"}`, expectedStatus: "starting"},
+		{name: "unavailable", statusCode: http.StatusServiceUnavailable, body: ``, expectedErr: "api error - health check failed, status code 503"},
+		{name: "invalid body", statusCode: http.StatusOK, body: `up`, expectedErr: "internal error - failed to unmarshal response body"},
+	}
+
+	for idx, tc := range testCases {
+		c, err := NewClient("http://0.0.0.0:8080", healthRoundTripper(tc.statusCode, tc.body))
+		assert.NoError(t, err)
+
+		health, err := c.Health(context.Background())
+		if tc.expectedErr != "" {
+			assert.Nil(t, health, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+			assert.ErrorContains(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.Equal(t, tc.expectedStatus, health.Status, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.Equal(t, tc.expectedUp, health.Up(), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
+
+func TestHealth_sentToHealthEndpointBypassingMiddleware(t *testing.T) {
+	var path string
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			path = req.URL.Path
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(`{"status":"up"}`))}, nil
+		},
+	}
+
+	refuse := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			return nil, newUnavailableError("refused", nil)
+		}
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithMiddleware(refuse))
+	assert.NoError(t, err)
+
+	health, err := c.Health(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, health.Up())
+	assert.Equal(t, "/v1/health", path)
+}
+
+func TestWaitUntilHealthy_becomesHealthy(t *testing.T) {
+	var calls int32
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return nil, errors.New("connection refused")
+			}
+
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(`{"status":"up"}`))}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, c.WaitUntilHealthy(ctx, time.Millisecond))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestWaitUntilHealthy_FailurePaths(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mrt         *mockRoundTripper
+		interval    time.Duration
+		expectedErr string
+		unavailable bool
+	}{
+		{
+			name:        "never healthy",
+			mrt:         healthRoundTripper(http.StatusServiceUnavailable, ``),
+			interval:    time.Millisecond,
+			expectedErr: "account API did not become healthy: context deadline exceeded, last check: api error - health check failed, status code 503",
+			unavailable: true,
+		},
+		{
+			name:        "never up",
+			mrt:         healthRoundTripper(http.StatusOK, `{"status":"down"}`),
+			interval:    time.Millisecond,
+			expectedErr: `last check: status is "down"`,
+			unavailable: true,
+		},
+		{
+			name:        "invalid interval",
+			mrt:         healthRoundTripper(http.StatusOK, `{"status":"up"}`),
+			interval:    0,
+			expectedErr: "input error - interval must be positive",
+		},
+	}
+
+	for idx, tc := range testCases {
+		c, err := NewClient("http://0.0.0.0:8080", tc.mrt)
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err = c.WaitUntilHealthy(ctx, tc.interval)
+		cancel()
+
+		assert.ErrorContains(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.Equal(t, tc.unavailable, IsUnavailableError(err), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.Equal(t, tc.unavailable, errors.Is(err, context.DeadlineExceeded), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// ListFilter restricts a list of accounts to those matching every non-empty field
+// https://api-docs.form3.tech/api.html#organisation-accounts-list
+type ListFilter struct {
+	BankIDCode    string
+	BankID        string
+	AccountNumber string
+	Iban          string
+	CustomerID    string
+	Country       string
+
+	// OrganisationID is not supported as a filter by the API, so it is applied by the client to each page
+	// of results. Pages may therefore contain fewer accounts than the page size.
+	OrganisationID string
+}
+
+// ListOptions selects a page of accounts
+type ListOptions struct {
+	Filter ListFilter
+	// PageNumber is the zero based page to return
+	PageNumber int
+	// PageSize is the number of accounts per page, the API default is used when it is 0
+	PageSize int
+}
+
+// query returns lo encoded as API query parameters
+func (lo ListOptions) query() url.Values {
+	query := url.Values{}
+	query.Set("page[number]", strconv.Itoa(lo.PageNumber))
+	if lo.PageSize > 0 {
+		query.Set("page[size]", strconv.Itoa(lo.PageSize))
+	}
+
+	filters := map[string]string{
+		"bank_id_code":   lo.Filter.BankIDCode,
+		"bank_id":        lo.Filter.BankID,
+		"account_number": lo.Filter.AccountNumber,
+		"iban":           lo.Filter.Iban,
+		"customer_id":    lo.Filter.CustomerID,
+		"country":        lo.Filter.Country,
+	}
+
+	for field, value := range filters {
+		if value != "" {
+			query.Set(fmt.Sprintf("filter[%s]", field), value)
+		}
+	}
+
+	return query
+}
+
+// List attempts to get a page of accounts
+// https://api-docs.form3.tech/api.html#organisation-accounts-list
+func (c *Client) List(ctx context.Context, opts ListOptions) (*accounts.ListResponse, error) {
+	resp, err := c.invoke(ctx, &OperationRequest{Operation: OperationList, List: &opts})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Accounts, nil
+}
+
+// ListAll calls fn with every account matching opts.Filter, fetching pages of opts.PageSize accounts
+// starting from opts.PageNumber until there are none left. It stops at the first error returned by fn.
+// A page with no accounts, or one whose next link points back at itself, is treated as the last page.
+func (c *Client) ListAll(ctx context.Context, opts ListOptions, fn func(account accounts.AccountData) error) error {
+	// the organisation is filtered here rather than by List so that an empty page means the API has no more
+	// accounts, not that none of them belong to the organisation
+	organisationID := opts.Filter.OrganisationID
+	opts.Filter.OrganisationID = ""
+
+	for {
+		page, err := c.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		for _, account := range page.Data {
+			if organisationID != "" && account.OrganisationID != organisationID {
+				continue
+			}
+
+			if err := fn(account); err != nil {
+				return err
+			}
+		}
+
+		if isLastPage(page) {
+			return nil
+		}
+
+		opts.PageNumber++
+	}
+}
+
+// isLastPage reports whether there are no pages after page
+func isLastPage(page *accounts.ListResponse) bool {
+	if len(page.Data) == 0 || page.Links == nil || page.Links.Next == "" {
+		return true
+	}
+
+	if page.Links.Self != "" && page.Links.Next == page.Links.Self {
+		return true
+	}
+
+	return page.Links.Last != "" && page.Links.Self == page.Links.Last
+}
+
+// listAccounts sends the request to get the page of accounts described by req to the API
+func (c *Client) listAccounts(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+	var opts ListOptions
+	if req.List != nil {
+		opts = *req.List
+	}
+
+	if opts.PageNumber < 0 || opts.PageSize < 0 {
+		return nil, newInputError("page number and page size cannot be negative", nil)
+	}
+
+	// send GET request to the accounts endpoint
+	path := fmt.Sprintf("%s?%s", basev1AccountsPath, opts.query().Encode())
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// read response
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newInternalError("failed to read response body", err)
+	}
+
+	defer resp.Body.Close()
+
+	// handle error response
+	if resp.StatusCode != http.StatusOK {
+		var apiError accounts.ApiError
+		if err := json.Unmarshal(respBody, &apiError); err != nil {
+			return nil, newInternalError("failed to unmarshal response body", err)
+		}
+
+		return nil, newApiStatusError(resp.StatusCode, fmt.Sprintf("failed to list accounts, status code %d", resp.StatusCode), apiError)
+	}
+
+	// handle success response
+	var listAccountsResp accounts.ListResponse
+	if err := json.Unmarshal(respBody, &listAccountsResp); err != nil {
+		return nil, newInternalError("failed to unmarshal response body", err)
+	}
+
+	if opts.Filter.OrganisationID != "" {
+		matching := listAccountsResp.Data[:0]
+		for _, account := range listAccountsResp.Data {
+			if account.OrganisationID == opts.Filter.OrganisationID {
+				matching = append(matching, account)
+			}
+		}
+
+		listAccountsResp.Data = matching
+	}
+
+	return &OperationResponse{Accounts: &listAccountsResp}, nil
+}
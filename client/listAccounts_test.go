@@ -0,0 +1,296 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList_return200WithValidRespBody_SuccessPath(t *testing.T) {
+	respBody := `{
+		"data": [
+			{"id": "1dfaf917-c6d6-4e18-b7e7-972e66492976", "organisation_id": "caca9817-6936-4da4-96e7-9ce93206070f", "type": "accounts", "version": 0},
+			{"id": "2dfaf917-c6d6-4e18-b7e7-972e66492976", "organisation_id": "daca9817-6936-4da4-96e7-9ce93206070f", "type": "accounts", "version": 1}
+		],
+		"links": {
+			"first": "/v1/organisation/accounts?page%5Bnumber%5D=first&page%5Bsize%5D=2",
+			"last": "/v1/organisation/accounts?page%5Bnumber%5D=last&page%5Bsize%5D=2",
+			"next": "/v1/organisation/accounts?page%5Bnumber%5D=2&page%5Bsize%5D=2",
+			"prev": "/v1/organisation/accounts?page%5Bnumber%5D=0&page%5Bsize%5D=2",
+			"self": "/v1/organisation/accounts?page%5Bnumber%5D=1&page%5Bsize%5D=2"
+		}
+	}`
+
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, http.MethodGet, req.Method)
+			assert.Equal(t, "/v1/organisation/accounts", req.URL.Path)
+			assert.Equal(t, "1", req.URL.Query().Get("page[number]"))
+			assert.Equal(t, "2", req.URL.Query().Get("page[size]"))
+			assert.Equal(t, "GB", req.URL.Query().Get("filter[country]"))
+			assert.Equal(t, "GBDSC", req.URL.Query().Get("filter[bank_id_code]"))
+			assert.Empty(t, req.URL.Query().Get("filter[iban]"))
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(respBody)),
+			}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	resp, err := c.List(context.Background(), ListOptions{
+		Filter:     ListFilter{Country: "GB", BankIDCode: "GBDSC"},
+		PageNumber: 1,
+		PageSize:   2,
+	})
+	assert.NoError(t, err)
+
+	expectedResp := &accounts.ListResponse{
+		Data: []accounts.AccountData{
+			{
+				ID:             "1dfaf917-c6d6-4e18-b7e7-972e66492976",
+				OrganisationID: "caca9817-6936-4da4-96e7-9ce93206070f",
+				Type:           "accounts",
+				Version:        ptrInt64(0),
+			},
+			{
+				ID:             "2dfaf917-c6d6-4e18-b7e7-972e66492976",
+				OrganisationID: "daca9817-6936-4da4-96e7-9ce93206070f",
+				Type:           "accounts",
+				Version:        ptrInt64(1),
+			},
+		},
+		Links: &accounts.Links{
+			First: "/v1/organisation/accounts?page%5Bnumber%5D=first&page%5Bsize%5D=2",
+			Last:  "/v1/organisation/accounts?page%5Bnumber%5D=last&page%5Bsize%5D=2",
+			Next:  "/v1/organisation/accounts?page%5Bnumber%5D=2&page%5Bsize%5D=2",
+			Prev:  "/v1/organisation/accounts?page%5Bnumber%5D=0&page%5Bsize%5D=2",
+			Self:  "/v1/organisation/accounts?page%5Bnumber%5D=1&page%5Bsize%5D=2",
+		},
+	}
+
+	assert.Equal(t, expectedResp, resp)
+}
+
+func TestList_filtersByOrganisationClientSide(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(bytes.NewBufferString(`{"data": [
+					{"id": "1dfaf917-c6d6-4e18-b7e7-972e66492976", "organisation_id": "caca9817-6936-4da4-96e7-9ce93206070f"},
+					{"id": "2dfaf917-c6d6-4e18-b7e7-972e66492976", "organisation_id": "daca9817-6936-4da4-96e7-9ce93206070f"}
+				]}`)),
+			}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	resp, err := c.List(context.Background(), ListOptions{Filter: ListFilter{OrganisationID: "daca9817-6936-4da4-96e7-9ce93206070f"}})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, "2dfaf917-c6d6-4e18-b7e7-972e66492976", resp.Data[0].ID)
+}
+
+func TestList_FailurePaths(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        ListOptions
+		statusCode  int
+		respBody    string
+		expectedErr string
+	}{
+		{
+			name:        "negative page",
+			opts:        ListOptions{PageNumber: -1},
+			expectedErr: "input error - page number and page size cannot be negative",
+		},
+		{
+			name:        "bad request",
+			statusCode:  http.StatusBadRequest,
+			respBody:    `{"error_message": "invalid page size"}`,
+			expectedErr: "api error - failed to list accounts, status code 400: invalid page size",
+		},
+		{
+			name:        "invalid JSON",
+			statusCode:  http.StatusOK,
+			respBody:    `{"data": [}`,
+			expectedErr: "internal error - failed to unmarshal response body: invalid character '}' looking for beginning of value",
+		},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			mrt := &mockRoundTripper{
+				transportFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: tc.statusCode,
+						Body:       ioutil.NopCloser(bytes.NewBufferString(tc.respBody)),
+					}, nil
+				},
+			}
+
+			c, err := NewClient("http://0.0.0.0:8080", mrt)
+			assert.NoError(t, err)
+
+			resp, err := c.List(context.Background(), tc.opts)
+			assert.Nil(t, resp)
+			assert.Equal(t, tc.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestListAll_followsPagesUntilThereIsNoNextLink(t *testing.T) {
+	pages := []string{
+		`{"data": [{"id": "a"}, {"id": "b"}], "links": {"self": "p0", "next": "p1"}}`,
+		`{"data": [{"id": "c"}], "links": {"self": "p1", "next": "p2", "last": "p2"}}`,
+		`{"data": [{"id": "d"}], "links": {"self": "p2", "last": "p2"}}`,
+	}
+
+	requested := 0
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, fmt.Sprint(requested), req.URL.Query().Get("page[number]"))
+			page := pages[requested]
+			requested++
+
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(page))}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	var ids []string
+	err = c.ListAll(context.Background(), ListOptions{PageSize: 2}, func(account accounts.AccountData) error {
+		ids = append(ids, account.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+	assert.Equal(t, 3, requested)
+}
+
+func TestListAll_stopsWhenTheNextLinkDoesNotAdvance(t *testing.T) {
+	testCases := []struct {
+		name             string
+		pages            []string
+		expectedIDs      []string
+		expectedRequests int
+	}{
+		{
+			name: "next link points at the current page",
+			pages: []string{
+				`{"data": [{"id": "a"}], "links": {"self": "p0", "next": "p1"}}`,
+				`{"data": [{"id": "b"}], "links": {"self": "p1", "next": "p1"}}`,
+			},
+			expectedIDs:      []string{"a", "b"},
+			expectedRequests: 2,
+		},
+		{
+			name: "empty page with a next link",
+			pages: []string{
+				`{"data": [{"id": "a"}], "links": {"self": "p0", "next": "p1"}}`,
+				`{"data": [], "links": {"self": "p1", "next": "p2"}}`,
+			},
+			expectedIDs:      []string{"a"},
+			expectedRequests: 2,
+		},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			requested := 0
+			mrt := &mockRoundTripper{
+				transportFunc: func(req *http.Request) (*http.Response, error) {
+					// the server always returns a next link, repeating its final page once the others run out
+					page := tc.pages[len(tc.pages)-1]
+					if requested < len(tc.pages) {
+						page = tc.pages[requested]
+					}
+					requested++
+
+					return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(page))}, nil
+				},
+			}
+
+			c, err := NewClient("http://0.0.0.0:8080", mrt)
+			assert.NoError(t, err)
+
+			var ids []string
+			err = c.ListAll(context.Background(), ListOptions{}, func(account accounts.AccountData) error {
+				ids = append(ids, account.ID)
+				if len(ids) > 10 {
+					return fmt.Errorf("ListAll did not stop")
+				}
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIDs, ids)
+			assert.Equal(t, tc.expectedRequests, requested)
+		})
+	}
+}
+
+func TestListAll_filtersByOrganisationWithoutStoppingEarly(t *testing.T) {
+	pages := []string{
+		`{"data": [{"id": "a", "organisation_id": "other"}], "links": {"self": "p0", "next": "p1"}}`,
+		`{"data": [{"id": "b", "organisation_id": "mine"}], "links": {"self": "p1"}}`,
+	}
+
+	requested := 0
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			page := pages[requested]
+			requested++
+
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(page))}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	var ids []string
+	err = c.ListAll(context.Background(), ListOptions{Filter: ListFilter{OrganisationID: "mine"}}, func(account accounts.AccountData) error {
+		ids = append(ids, account.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, ids)
+	assert.Equal(t, 2, requested)
+}
+
+func TestListAll_stopsAtCallbackError(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data": [{"id": "a"}, {"id": "b"}], "links": {"self": "p0", "next": "p1"}}`)),
+			}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = c.ListAll(context.Background(), ListOptions{}, func(account accounts.AccountData) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const redactedValue = "[REDACTED]"
+
+// sensitiveLogKeys are the attribute keys whose values are never written to the log.
+// Keys are compared case-insensitively so that header names and JSON field names are both covered.
+var sensitiveLogKeys = map[string]bool{
+	"authorization":                  true,
+	"proxy-authorization":            true,
+	"iban":                           true,
+	"account_number":                 true,
+	"name":                           true,
+	"alternative_names":              true,
+	"first_name":                     true,
+	"bank_account_name":              true,
+	"alternative_bank_account_names": true,
+	"secondary_identification":       true,
+}
+
+// loggingTransportDecorator is a custom RoundTripper that decorates the RoundTripper in its transport field
+// with the functionality to write a structured log record for every request
+type loggingTransportDecorator struct {
+	transport http.RoundTripper
+	logger    *slog.Logger
+}
+
+// RoundTrip hands off to the underlying transport and then logs the outcome of the request
+func (lrt *loggingTransportDecorator) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+
+	resp, err := lrt.transport.RoundTrip(req)
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", pathTemplate(req.URL.Path)),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("failover_attempt", failoverAttemptFromContext(ctx)),
+		slog.String("request_id", req.Header.Get(requestIDHeader)),
+	}
+
+	if info, ok := operationInfoFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("operation", info.name))
+	}
+
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+	}
+
+	// headers are only worth the noise when debugging
+	if lrt.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, headerAttrs(req.Header))
+	}
+
+	lrt.logger.LogAttrs(ctx, level, "account api request", attrs...)
+
+	return resp, err
+}
+
+// headerAttrs returns the request headers as a log group
+func headerAttrs(header http.Header) slog.Attr {
+	attrs := make([]any, 0, len(header))
+	for key, values := range header {
+		attrs = append(attrs, slog.String(key, strings.Join(values, ",")))
+	}
+
+	return slog.Group("headers", attrs...)
+}
+
+// redactingHandler is a slog.Handler that replaces the values of sensitive attributes before
+// handing records off to the handler it wraps
+type redactingHandler struct {
+	handler slog.Handler
+}
+
+// newRedactingHandler returns a slog.Handler which redacts sensitive attributes written to handler
+func newRedactingHandler(handler slog.Handler) *redactingHandler {
+	if rh, ok := handler.(*redactingHandler); ok {
+		return rh
+	}
+
+	return &redactingHandler{handler: handler}
+}
+
+func (rh *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return rh.handler.Enabled(ctx, level)
+}
+
+func (rh *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+
+	return rh.handler.Handle(ctx, redacted)
+}
+
+func (rh *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for idx, attr := range attrs {
+		redacted[idx] = redactAttr(attr)
+	}
+
+	return &redactingHandler{handler: rh.handler.WithAttrs(redacted)}
+}
+
+func (rh *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{handler: rh.handler.WithGroup(name)}
+}
+
+// redactAttr returns attr with its value replaced if its key is sensitive, descending into groups
+func redactAttr(attr slog.Attr) slog.Attr {
+	if sensitiveLogKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redactedValue)
+	}
+
+	value := attr.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+
+	group := value.Group()
+	redacted := make([]slog.Attr, len(group))
+	for idx, groupAttr := range group {
+		redacted[idx] = redactAttr(groupAttr)
+	}
+
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+}
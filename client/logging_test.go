@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLogger_nilLoggerReturnsError(t *testing.T) {
+	_, err := NewClient("http://0.0.0.0:8080", nil, WithLogger(nil))
+	assert.Equal(t, "input error - logger cannot be nil", err.Error())
+}
+
+func TestWithLogger_writesOneRecordPerRequest(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithLogger(logger))
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.NoError(t, err)
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "account api request", record["msg"])
+	assert.Equal(t, http.MethodDelete, record["method"])
+	assert.Equal(t, "/v1/organisation/accounts/{id}", record["path"])
+	assert.Equal(t, float64(http.StatusNoContent), record["status"])
+	assert.Equal(t, float64(0), record["failover_attempt"])
+	assert.Equal(t, "delete", record["operation"])
+	assert.NotEmpty(t, record["request_id"])
+	assert.Contains(t, record, "duration")
+}
+
+func TestWithLogger_countsFailoverAttempts(t *testing.T) {
+	ert := &endpointsRoundTripper{handlers: map[string]func(req *http.Request) (*http.Response, error){
+		"primary:8080":   refuseConnection,
+		"secondary:8080": answerWith(http.StatusOK, fetchedAccountBody),
+	}}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	c, err := NewClient(primaryURL, ert, WithLogger(logger), WithEndpoints(EndpointsConfig{
+		Endpoints:           []string{secondaryURL},
+		HealthCheckInterval: -1,
+	}))
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+
+	var failoverAttempts []float64
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record map[string]interface{}
+		assert.NoError(t, decoder.Decode(&record))
+		failoverAttempts = append(failoverAttempts, record["failover_attempt"].(float64))
+	}
+
+	assert.Equal(t, []float64{0, 1}, failoverAttempts)
+}
+
+func TestWithLogger_logsTransportFailuresAtErrorLevel(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("connection refused")
+		},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithLogger(logger))
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.Error(t, err)
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "connection refused", record["error"])
+	assert.NotContains(t, record, "status")
+}
+
+func TestWithLogger_requestIDIsSentToServer(t *testing.T) {
+	var sentRequestID string
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			sentRequestID = req.Header.Get("X-Request-ID")
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"error_message": "not found"}`)),
+			}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithLogger(logger))
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.Error(t, err)
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.NotEmpty(t, sentRequestID)
+	assert.Equal(t, sentRequestID, record["request_id"])
+}
+
+func TestWithLogger_redactsSensitiveHeadersAtDebugLevel(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithLogger(logger))
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://0.0.0.0:8080/v1/organisation/accounts", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-token")
+
+	_, err = c.httpClient.Do(req)
+	assert.NoError(t, err)
+
+	assert.NotContains(t, buf.String(), "secret-token")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	headers, ok := record["headers"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "[REDACTED]", headers["Authorization"])
+	assert.Equal(t, "application/vnd.api+json", headers["Accept"])
+}
+
+func TestRedactingHandler_redactsPIIAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newRedactingHandler(slog.NewJSONHandler(&buf, nil)))
+
+	logger.With(slog.String("iban", "GB28NWBK40030212764204")).Info(
+		"test",
+		slog.Any("name", []string{"Jane Doe"}),
+		slog.Group("attributes",
+			slog.String("bank_account_name", "Jane Doe"),
+			slog.String("country", "GB"),
+		),
+	)
+
+	assert.NotContains(t, buf.String(), "Jane Doe")
+	assert.NotContains(t, buf.String(), "GB28NWBK40030212764204")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, "[REDACTED]", record["iban"])
+	assert.Equal(t, "[REDACTED]", record["name"])
+	assert.Equal(t, map[string]interface{}{"bank_account_name": "[REDACTED]", "country": "GB"}, record["attributes"])
+}
+
+func TestPathTemplate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "accounts collection", input: "/v1/organisation/accounts", expected: "/v1/organisation/accounts"},
+		{name: "single account", input: "/v1/organisation/accounts/1dfaf917-c6d6-4e18-b7e7-972e66492976", expected: "/v1/organisation/accounts/{id}"},
+		{name: "unrelated path", input: "/this/is/a/fake", expected: "/this/is/a/fake"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.expected, pathTemplate(tc.input))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const unknownOperation = "other"
+
+// Metrics receives measurements of the client's behaviour.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// IncRequests counts a http request sent for operation, statusCode is 0 when no response was received
+	IncRequests(operation string, statusCode int)
+	// IncErrors counts an operation that failed with an error of kind errType
+	IncErrors(operation, errType string)
+	// ObserveLatency records how long a http request sent for operation took
+	ObserveLatency(operation string, duration time.Duration)
+	// AddInFlight adjusts the number of http requests currently in flight for operation
+	AddInFlight(operation string, delta int)
+	// IncFailoverAttempts counts a http request sent for operation to another endpoint after the previous one failed
+	IncFailoverAttempts(operation string)
+	// ObserveRateLimitWait records how long a call to operation waited for the client side rate limiter
+	ObserveRateLimitWait(operation string, duration time.Duration)
+}
+
+// WithMetrics makes the client report request counts, error counts, latencies,
+// in flight requests, failover attempts and rate limiter waits to metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(cfg *config) error {
+		if metrics == nil {
+			return newInputError("metrics cannot be nil", nil)
+		}
+
+		cfg.metrics = metrics
+		return nil
+	}
+}
+
+// metricsTransportDecorator is a custom RoundTripper that decorates the RoundTripper in its transport field
+// with the functionality to measure every request
+type metricsTransportDecorator struct {
+	transport http.RoundTripper
+	metrics   Metrics
+}
+
+// RoundTrip hands off to the underlying transport and records measurements of the request
+func (mrt *metricsTransportDecorator) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	operation := operationName(ctx)
+
+	if failoverAttemptFromContext(ctx) > 0 {
+		mrt.metrics.IncFailoverAttempts(operation)
+	}
+
+	mrt.metrics.AddInFlight(operation, 1)
+	defer mrt.metrics.AddInFlight(operation, -1)
+
+	start := time.Now()
+	resp, err := mrt.transport.RoundTrip(req)
+	mrt.metrics.ObserveLatency(operation, time.Since(start))
+
+	statusCode := 0
+	if err == nil {
+		statusCode = resp.StatusCode
+	}
+
+	mrt.metrics.IncRequests(operation, statusCode)
+
+	return resp, err
+}
+
+// errorType returns the kind of err as a label suitable for metrics e.g. "api_error"
+func errorType(err error) string {
+	var cerr *clientError
+	if !errors.As(err, &cerr) {
+		return "unknown_error"
+	}
+
+	return strings.ReplaceAll(clientErrors[cerr.code], " ", "_")
+}
+
+// noopMetrics is the Metrics used when none has been configured
+type noopMetrics struct{}
+
+func (noopMetrics) IncRequests(string, int) {}
+
+func (noopMetrics) IncErrors(string, string) {}
+
+func (noopMetrics) ObserveLatency(string, time.Duration) {}
+
+func (noopMetrics) AddInFlight(string, int) {}
+
+func (noopMetrics) IncFailoverAttempts(string) {}
+
+func (noopMetrics) ObserveRateLimitWait(string, time.Duration) {}
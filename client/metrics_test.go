@@ -0,0 +1,188 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMetrics records every measurement it receives
+type fakeMetrics struct {
+	mu          sync.Mutex
+	requests    map[string]int
+	errors      map[string]int
+	latencies   map[string]int
+	inFlight    map[string]int
+	maxInFlight int
+	failovers   map[string]int
+	waits       map[string]int
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{
+		requests:  map[string]int{},
+		errors:    map[string]int{},
+		latencies: map[string]int{},
+		inFlight:  map[string]int{},
+		failovers: map[string]int{},
+		waits:     map[string]int{},
+	}
+}
+
+func (fm *fakeMetrics) IncRequests(operation string, statusCode int) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.requests[fmt.Sprintf("%s %d", operation, statusCode)]++
+}
+
+func (fm *fakeMetrics) IncErrors(operation, errType string) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.errors[fmt.Sprintf("%s %s", operation, errType)]++
+}
+
+func (fm *fakeMetrics) ObserveLatency(operation string, _ time.Duration) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.latencies[operation]++
+}
+
+func (fm *fakeMetrics) AddInFlight(operation string, delta int) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.inFlight[operation] += delta
+	if fm.inFlight[operation] > fm.maxInFlight {
+		fm.maxInFlight = fm.inFlight[operation]
+	}
+}
+
+func (fm *fakeMetrics) IncFailoverAttempts(operation string) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.failovers[operation]++
+}
+
+func (fm *fakeMetrics) ObserveRateLimitWait(operation string, _ time.Duration) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	fm.waits[operation]++
+}
+
+func TestWithMetrics_nilMetricsReturnsError(t *testing.T) {
+	_, err := NewClient("http://0.0.0.0:8080", nil, WithMetrics(nil))
+	assert.Equal(t, "input error - metrics cannot be nil", err.Error())
+}
+
+func TestWithMetrics_recordsSuccessfulRequest(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	metrics := newFakeMetrics()
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithMetrics(metrics))
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"delete 204": 1}, metrics.requests)
+	assert.Equal(t, map[string]int{"delete": 1}, metrics.latencies)
+	assert.Equal(t, map[string]int{"delete": 0}, metrics.inFlight)
+	assert.Equal(t, 1, metrics.maxInFlight)
+	assert.Empty(t, metrics.errors)
+	assert.Empty(t, metrics.failovers)
+}
+
+func TestWithMetrics_countsErrorsByType(t *testing.T) {
+	testCases := []struct {
+		name          string
+		transportFunc func(req *http.Request) (*http.Response, error)
+		accountID     string
+		expectedError string
+	}{
+		{
+			name: "api error",
+			transportFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(`{"error_message": "not found"}`)),
+				}, nil
+			},
+			accountID:     "1dfaf917-c6d6-4e18-b7e7-972e66492976",
+			expectedError: "fetch api_error",
+		},
+		{
+			name: "internal error",
+			transportFunc: func(req *http.Request) (*http.Response, error) {
+				return nil, fmt.Errorf("connection refused")
+			},
+			accountID:     "1dfaf917-c6d6-4e18-b7e7-972e66492976",
+			expectedError: "fetch internal_error",
+		},
+		{
+			name:          "input error",
+			accountID:     "",
+			expectedError: "fetch input_error",
+		},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			metrics := newFakeMetrics()
+			c, err := NewClient("http://0.0.0.0:8080", &mockRoundTripper{transportFunc: tc.transportFunc}, WithMetrics(metrics))
+			assert.NoError(t, err)
+
+			_, err = c.Fetch(context.Background(), tc.accountID)
+			assert.Error(t, err)
+
+			assert.Equal(t, map[string]int{tc.expectedError: 1}, metrics.errors)
+		})
+	}
+}
+
+func TestMetricsTransportDecorator_countsFailoverAttempts(t *testing.T) {
+	metrics := newFakeMetrics()
+	mrt := &metricsTransportDecorator{
+		transport: &mockRoundTripper{
+			transportFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			},
+		},
+		metrics: metrics,
+	}
+
+	info := newOperationInfo("fetch", accountPathTemplate)
+	for failovers := 0; failovers < 3; failovers++ {
+		ctx := context.WithValue(withOperationInfo(context.Background(), info), failoverAttemptKey{}, failovers)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://0.0.0.0:8080/v1/organisation/accounts/x", nil)
+		assert.NoError(t, err)
+
+		_, err = mrt.RoundTrip(req)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]int{"fetch": 2}, metrics.failovers)
+	assert.Equal(t, map[string]int{"fetch 200": 3}, metrics.requests)
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "api_error", errorType(newApiError("test", nil)))
+	assert.Equal(t, "internal_error", errorType(newInternalError("test", nil)))
+	assert.Equal(t, "input_error", errorType(newInputError("test", nil)))
+	assert.Equal(t, "unknown_error", errorType(fmt.Errorf("test")))
+}
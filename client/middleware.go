@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+)
+
+// Operation identifies a client method as it passes through the middleware chain
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationFetch  Operation = "fetch"
+	OperationDelete Operation = "delete"
+	OperationList   Operation = "list"
+)
+
+// operationPathTemplates maps each operation to the API path it is sent to
+var operationPathTemplates = map[Operation]string{
+	OperationCreate: basev1AccountsPath,
+	OperationFetch:  accountPathTemplate,
+	OperationDelete: accountPathTemplate,
+	OperationList:   basev1AccountsPath,
+}
+
+// OperationRequest is the typed input of a client operation
+type OperationRequest struct {
+	Operation Operation
+	// AccountID is the ID of the account targeted by the operation
+	AccountID string
+	// Version is the version of the account to delete, only used by OperationDelete
+	Version uint
+	// Account is the account to create, only used by OperationCreate
+	Account *accounts.AccountData
+	// List selects the page of accounts to return, only used by OperationList
+	List *ListOptions
+	// IfNoneMatch is an ETag to send in an If-None-Match header, only used by OperationFetch
+	IfNoneMatch string
+	// IfModifiedSince is a time to send in an If-Modified-Since header, only used by OperationFetch
+	IfModifiedSince string
+}
+
+// OperationResponse is the typed output of a client operation
+type OperationResponse struct {
+	// Account is the account returned by the API, it is nil for operations without a response body
+	Account *accounts.Response
+	// Accounts is the page of accounts returned by OperationList
+	Accounts *accounts.ListResponse
+	// ETag and LastModified are the validators the API returned for a fetched account
+	ETag         string
+	LastModified string
+	// NotModified is set when the API answered a conditional fetch with 304 Not Modified.
+	// Account is only populated in that case if a middleware had a copy of the account to hand.
+	NotModified bool
+}
+
+// Next handles an operation request, either by calling the next middleware in the chain or by calling the API
+type Next func(ctx context.Context, req *OperationRequest) (*OperationResponse, error)
+
+// Middleware wraps the handling of client operations e.g. to add caching, auditing, validation or fault injection.
+// A middleware can inspect or modify the request before calling next, inspect or modify the response
+// after calling next, or return without calling next at all.
+type Middleware func(next Next) Next
+
+// WithMiddleware adds middlewares to the client's operation chain.
+// Middlewares run in the order given, so the first middleware sees requests first and responses last.
+// Calling WithMiddleware more than once appends to the chain.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(cfg *config) error {
+		for _, middleware := range middlewares {
+			if middleware == nil {
+				return newInputError("middleware cannot be nil", nil)
+			}
+		}
+
+		cfg.middlewares = append(cfg.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// chain wraps final with middlewares so that middlewares[0] is the outermost
+func chain(final Next, middlewares ...Middleware) Next {
+	next := final
+	for idx := len(middlewares) - 1; idx >= 0; idx-- {
+		next = middlewares[idx](next)
+	}
+
+	return next
+}
+
+// dispatch is the end of the middleware chain, it calls the API on behalf of req
+func (c *Client) dispatch(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+	switch req.Operation {
+	case OperationCreate:
+		return c.createAccount(ctx, req)
+	case OperationFetch:
+		return c.fetchAccount(ctx, req)
+	case OperationDelete:
+		return c.deleteAccount(ctx, req)
+	case OperationList:
+		return c.listAccounts(ctx, req)
+	default:
+		return nil, newInputError("unsupported operation: "+string(req.Operation), nil)
+	}
+}
+
+// invoke sends req through the middleware chain, tracing and measuring the operation as a whole
+func (c *Client) invoke(ctx context.Context, req *OperationRequest) (resp *OperationResponse, err error) {
+	ctx, op := c.startOperation(ctx, req)
+	defer func() { op.end(err, resp) }()
+
+	resp, err = c.handler(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// guard callers against middlewares that return neither a response nor an error
+	if resp == nil {
+		resp = &OperationResponse{}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMiddleware appends name to calls on the way in and on the way out of the chain
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			*calls = append(*calls, fmt.Sprintf("%s before %s", name, req.Operation))
+			resp, err := next(ctx, req)
+			*calls = append(*calls, fmt.Sprintf("%s after %s", name, req.Operation))
+			return resp, err
+		}
+	}
+}
+
+func TestWithMiddleware_nilMiddlewareReturnsError(t *testing.T) {
+	_, err := NewClient("http://0.0.0.0:8080", nil, WithMiddleware(nil))
+	assert.Equal(t, "input error - middleware cannot be nil", err.Error())
+}
+
+func TestWithMiddleware_runsInOrderGiven(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	var calls []string
+	c, err := NewClient(
+		"http://0.0.0.0:8080",
+		mrt,
+		WithMiddleware(recordingMiddleware("first", &calls), recordingMiddleware("second", &calls)),
+		WithMiddleware(recordingMiddleware("third", &calls)),
+	)
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]string{
+			"first before delete",
+			"second before delete",
+			"third before delete",
+			"third after delete",
+			"second after delete",
+			"first after delete",
+		},
+		calls,
+	)
+}
+
+func TestWithMiddleware_receivesTypedRequests(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data": {"id": "1dfaf917-c6d6-4e18-b7e7-972e66492976"}}`)),
+			}, nil
+		},
+	}
+
+	var seen []OperationRequest
+	var seenResp *OperationResponse
+	middleware := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			seen = append(seen, *req)
+			resp, err := next(ctx, req)
+			seenResp = resp
+			return resp, err
+		}
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithMiddleware(middleware))
+	assert.NoError(t, err)
+
+	account := accounts.AccountData{ID: "1dfaf917-c6d6-4e18-b7e7-972e66492976"}
+	resp, err := c.Create(context.Background(), account)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []OperationRequest{{Operation: OperationCreate, AccountID: account.ID, Account: &account}}, seen)
+	assert.Equal(t, resp, seenResp.Account)
+}
+
+func TestWithMiddleware_canShortCircuit(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			t.Fatal("request should not reach the transport")
+			return nil, nil
+		},
+	}
+
+	cached := &accounts.Response{Data: &accounts.AccountData{ID: "1dfaf917-c6d6-4e18-b7e7-972e66492976"}}
+	middleware := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			if req.Operation == OperationFetch {
+				return &OperationResponse{Account: cached}, nil
+			}
+
+			return next(ctx, req)
+		}
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithMiddleware(middleware))
+	assert.NoError(t, err)
+
+	resp, err := c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+	assert.Equal(t, cached, resp)
+}
+
+func TestWithMiddleware_canInjectErrors(t *testing.T) {
+	injected := fmt.Errorf("injected fault")
+	middleware := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			return nil, injected
+		}
+	}
+
+	metrics := newFakeMetrics()
+	c, err := NewClient("http://0.0.0.0:8080", &mockRoundTripper{}, WithMiddleware(middleware), WithMetrics(metrics))
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.Equal(t, injected, err)
+	assert.Equal(t, map[string]int{"delete unknown_error": 1}, metrics.errors)
+}
+
+func TestWithMiddleware_nilResponseWithoutErrorIsTolerated(t *testing.T) {
+	middleware := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			return nil, nil
+		}
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", &mockRoundTripper{}, WithMiddleware(middleware))
+	assert.NoError(t, err)
+
+	resp, err := c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestDispatch_unsupportedOperation(t *testing.T) {
+	c, err := NewClient("http://0.0.0.0:8080", &mockRoundTripper{})
+	assert.NoError(t, err)
+
+	_, err = c.dispatch(context.Background(), &OperationRequest{Operation: "update"})
+	assert.Equal(t, "input error - unsupported operation: update", err.Error())
+}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/accounts/ndjson"
+)
+
+const (
+	defaultImportBatchSize = 500
+	exportPageSize         = 100
+)
+
+// Export writes every account matching filter to w as newline delimited JSON, one account per line,
+// and returns the number of accounts written. Accounts are streamed a page at a time so memory use
+// does not grow with the number of accounts.
+func (c *Client) Export(ctx context.Context, w io.Writer, filter ListFilter) (int, error) {
+	enc := ndjson.NewEncoder(w)
+
+	exported := 0
+	err := c.ListAll(ctx, ListOptions{Filter: filter, PageSize: exportPageSize}, func(account accounts.AccountData) error {
+		if err := enc.Encode(account); err != nil {
+			return newInternalError("failed to write account", err)
+		}
+
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	if err := enc.Flush(); err != nil {
+		return exported, newInternalError("failed to write accounts", err)
+	}
+
+	return exported, nil
+}
+
+// ImportOptions controls how Import creates accounts
+type ImportOptions struct {
+	// Concurrency is the number of accounts created at once, default 10
+	Concurrency int
+	// BatchSize is the number of accounts read into memory and created before the checkpoint is updated, default 500
+	BatchSize int
+	// CheckpointPath, if set, is a file recording how far through the input the import has got.
+	// An import given the checkpoint of an earlier, interrupted, import skips the accounts it had already dealt with.
+	CheckpointPath string
+}
+
+// ImportFailure is an account that could not be imported
+type ImportFailure struct {
+	// Line is the line of the input the account was on
+	Line int
+	// ID is the ID of the account, empty if the line could not be decoded
+	ID  string
+	Err error
+}
+
+// ImportSummary reports the outcome of Import
+type ImportSummary struct {
+	// Imported is the number of accounts that were created
+	Imported int
+	// AlreadyExisted is the number of accounts that were not created because an account with the same ID exists,
+	// e.g. because they were created by an earlier attempt at the import
+	AlreadyExisted int
+	// Resumed is the number of accounts skipped because the checkpoint showed an earlier import had dealt with them
+	Resumed int
+	// Failed are the accounts that could not be imported for any other reason
+	Failed []ImportFailure
+}
+
+// importCheckpoint is the content of the checkpoint file
+type importCheckpoint struct {
+	// Line is the last line of the input that has been dealt with
+	Line int `json:"line"`
+}
+
+// Import creates the accounts read from r, which holds newline delimited JSON as written by Export.
+// Read only fields such as version and created_on are cleared before each account is created.
+// Accounts are read and created a batch at a time so memory use does not grow with the number of accounts.
+//
+// With opts.CheckpointPath set, the checkpoint is updated after every batch, so an interrupted import can be
+// resumed by calling Import again with the same input and checkpoint. Accounts from the batch that was
+// interrupted are created again, which the API rejects as duplicates, so they are counted as AlreadyExisted.
+// Failed accounts are not retried by a resumed import.
+//
+// The returned error is only non-nil if the input or checkpoint could not be read or written, or if ctx ended,
+// in which case the summary covers the accounts dealt with so far.
+func (c *Client) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportSummary, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+
+	checkpoint, err := readImportCheckpoint(opts.CheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ImportSummary{}
+	dec := ndjson.NewDecoder(r)
+
+	for done := false; !done; {
+		var batch []accounts.AccountData
+		var lines []int
+
+		for len(batch) < batchSize {
+			account, err := dec.Decode()
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+
+			var lerr *ndjson.LineError
+			if err != nil && !errors.As(err, &lerr) {
+				return summary, newInternalError("failed to read accounts", err)
+			}
+
+			if dec.Line() <= checkpoint.Line {
+				summary.Resumed++
+				continue
+			}
+
+			if lerr != nil {
+				summary.Failed = append(summary.Failed, ImportFailure{Line: lerr.Line, Err: newInputError("invalid account", lerr.Err)})
+				continue
+			}
+
+			account.Version = nil
+			account.CreatedOn = nil
+			account.ModifiedOn = nil
+
+			batch = append(batch, account)
+			lines = append(lines, dec.Line())
+		}
+
+		results, err := c.CreateMany(ctx, batch, BulkOptions{Concurrency: opts.Concurrency})
+		for idx, result := range results {
+			switch {
+			case result.Err == nil:
+				summary.Imported++
+			case StatusCode(result.Err) == http.StatusConflict:
+				summary.AlreadyExisted++
+			case ctx.Err() != nil:
+				// interrupted accounts are picked up again when the import is resumed
+			default:
+				summary.Failed = append(summary.Failed, ImportFailure{Line: lines[idx], ID: result.Account.ID, Err: result.Err})
+			}
+		}
+
+		// the checkpoint must not move past a batch that was interrupted
+		if err != nil {
+			return summary, err
+		}
+
+		checkpoint.Line = dec.Line()
+		if err := writeImportCheckpoint(opts.CheckpointPath, checkpoint); err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+// readImportCheckpoint reads the checkpoint at path, a missing file or empty path means the import has not begun
+func readImportCheckpoint(path string) (importCheckpoint, error) {
+	var checkpoint importCheckpoint
+	if path == "" {
+		return checkpoint, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+
+	if err != nil {
+		return checkpoint, newInternalError("failed to read import checkpoint", err)
+	}
+
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return checkpoint, newInputError(fmt.Sprintf("invalid import checkpoint %s", path), err)
+	}
+
+	return checkpoint, nil
+}
+
+// writeImportCheckpoint replaces the checkpoint at path, if there is one, so that a crash part way through
+// never leaves a half written checkpoint behind
+func writeImportCheckpoint(path string, checkpoint importCheckpoint) error {
+	if path == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(checkpoint)
+	if err != nil {
+		return newInternalError("failed to encode import checkpoint", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return newInternalError("failed to write import checkpoint", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return newInternalError("failed to write import checkpoint", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return newInternalError("failed to write import checkpoint", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return newInternalError("failed to write import checkpoint", err)
+	}
+
+	return nil
+}
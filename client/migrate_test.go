@@ -0,0 +1,161 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImport_roundTrip(t *testing.T) {
+	source := fakeapi.NewServer(testAccounts(250)...)
+	sourceServer := httptest.NewServer(source)
+	defer sourceServer.Close()
+
+	src, err := NewClient(sourceServer.URL, nil)
+	assert.NoError(t, err)
+
+	var dump bytes.Buffer
+	exported, err := src.Export(context.Background(), &dump, ListFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 250, exported)
+	assert.Equal(t, 250, strings.Count(dump.String(), "\n"))
+
+	target := fakeapi.NewServer()
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	dst, err := NewClient(targetServer.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err := dst.Import(context.Background(), &dump, ImportOptions{BatchSize: 40, Concurrency: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, &ImportSummary{Imported: 250}, summary)
+	assert.ElementsMatch(t, accountIDs(source.Accounts()), accountIDs(target.Accounts()))
+}
+
+func TestImport_resumesFromCheckpointAfterInterruption(t *testing.T) {
+	sourceServer := httptest.NewServer(fakeapi.NewServer(testAccounts(100)...))
+	defer sourceServer.Close()
+
+	src, err := NewClient(sourceServer.URL, nil)
+	assert.NoError(t, err)
+
+	var dump bytes.Buffer
+	_, err = src.Export(context.Background(), &dump, ListFilter{})
+	assert.NoError(t, err)
+	input := dump.String()
+
+	target := fakeapi.NewServer()
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	// the first import is interrupted after 30 accounts have been created
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var created int32
+	interrupt := func(next Next) Next {
+		return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+			resp, err := next(ctx, req)
+			if atomic.AddInt32(&created, 1) == 30 {
+				cancel()
+			}
+
+			return resp, err
+		}
+	}
+
+	dst, err := NewClient(targetServer.URL, nil, WithMiddleware(interrupt))
+	assert.NoError(t, err)
+
+	checkpointPath := filepath.Join(t.TempDir(), "import.checkpoint")
+	opts := ImportOptions{BatchSize: 20, Concurrency: 1, CheckpointPath: checkpointPath}
+
+	summary, err := dst.Import(ctx, strings.NewReader(input), opts)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 30, summary.Imported)
+	assert.Empty(t, summary.Failed)
+
+	// only the first batch was finished
+	checkpoint, err := os.ReadFile(checkpointPath)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"line": 20}`, string(checkpoint))
+
+	dst, err = NewClient(targetServer.URL, nil)
+	assert.NoError(t, err)
+
+	summary, err = dst.Import(context.Background(), strings.NewReader(input), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, &ImportSummary{Imported: 70, AlreadyExisted: 10, Resumed: 20}, summary)
+	assert.Len(t, target.Accounts(), 100)
+
+	// resuming a finished import does nothing
+	summary, err = dst.Import(context.Background(), strings.NewReader(input), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, &ImportSummary{Resumed: 100}, summary)
+}
+
+func TestImport_reportsFailuresWithLineNumbers(t *testing.T) {
+	target := fakeapi.NewServer()
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	c, err := NewClient(targetServer.URL, nil)
+	assert.NoError(t, err)
+
+	existing := testAccount(testAccountIDC)
+	existing.Version = ptrInt64(4)
+	target.Put(existing)
+
+	withReadOnlyFields := testAccount(testAccountIDA)
+	withReadOnlyFields.Version, withReadOnlyFields.CreatedOn = ptrInt64(3), ptrTime(getDummyTime())
+
+	var lines []string
+	for _, account := range []accounts.AccountData{withReadOnlyFields, testAccount(testAccountIDC), testAccount(testAccountIDB)} {
+		line, err := json.Marshal(account)
+		assert.NoError(t, err)
+		lines = append(lines, string(line))
+	}
+
+	input := lines[0] + "\n" +
+		`{"id":` + "\n" +
+		"\n" +
+		lines[1] + "\n" +
+		lines[2] + "\n"
+
+	summary, err := c.Import(context.Background(), strings.NewReader(input), ImportOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, summary.Imported)
+	assert.Equal(t, 1, summary.AlreadyExisted)
+	assert.Len(t, summary.Failed, 1)
+	assert.Equal(t, 2, summary.Failed[0].Line)
+	assert.Equal(t, "input error - invalid account: unexpected end of JSON input", summary.Failed[0].Err.Error())
+
+	// read only fields are not sent
+	imported, ok := target.Account(testAccountIDA)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), *imported.Version)
+	assert.NotEqual(t, getDummyTime(), *imported.CreatedOn)
+}
+
+func TestImport_invalidCheckpoint(t *testing.T) {
+	checkpointPath := filepath.Join(t.TempDir(), "import.checkpoint")
+	assert.NoError(t, os.WriteFile(checkpointPath, []byte("not json"), 0o600))
+
+	c, err := NewClient("http://0.0.0.0:8080", nil)
+	assert.NoError(t, err)
+
+	_, err = c.Import(context.Background(), strings.NewReader(""), ImportOptions{CheckpointPath: checkpointPath})
+	assert.True(t, IsInputError(err))
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// operationInfo describes the client call that http requests are being made on behalf of.
+// It travels in the request context so that transport decorators can describe requests consistently.
+type operationInfo struct {
+	name         string
+	pathTemplate string
+	requestID    string
+	requests     int32
+	statusCode   int32
+}
+
+type operationInfoKey struct{}
+
+type failoverAttemptKey struct{}
+
+// newOperationInfo returns operationInfo for the named operation with a freshly generated request ID
+func newOperationInfo(name, pathTemplate string) *operationInfo {
+	return &operationInfo{
+		name:         name,
+		pathTemplate: pathTemplate,
+		requestID:    uuid.NewString(),
+	}
+}
+
+// withOperationInfo returns a copy of ctx carrying info
+func withOperationInfo(ctx context.Context, info *operationInfo) context.Context {
+	return context.WithValue(ctx, operationInfoKey{}, info)
+}
+
+// operationInfoFromContext returns the operationInfo carried by ctx if there is one
+func operationInfoFromContext(ctx context.Context) (*operationInfo, bool) {
+	info, ok := ctx.Value(operationInfoKey{}).(*operationInfo)
+	return info, ok
+}
+
+// nextRequest records that another http request is being sent for the operation and returns the number of
+// times the operation has failed over to another endpoint before it, 0 for the request to the first endpoint
+func (oi *operationInfo) nextRequest() int {
+	return int(atomic.AddInt32(&oi.requests, 1)) - 1
+}
+
+// failoverAttempts returns the number of http requests sent for the operation after failing over to another endpoint
+func (oi *operationInfo) failoverAttempts() int {
+	if requests := int(atomic.LoadInt32(&oi.requests)); requests > 1 {
+		return requests - 1
+	}
+
+	return 0
+}
+
+// setStatusCode records the status code of the latest response received for the operation
+func (oi *operationInfo) setStatusCode(code int) {
+	atomic.StoreInt32(&oi.statusCode, int32(code))
+}
+
+// lastStatusCode returns the status code of the latest response received for the operation, or 0 if there was none
+func (oi *operationInfo) lastStatusCode() int {
+	return int(atomic.LoadInt32(&oi.statusCode))
+}
+
+// operationName returns the name of the operation carried by ctx, or a placeholder if there is none
+func operationName(ctx context.Context) string {
+	if info, ok := operationInfoFromContext(ctx); ok && info.name != "" {
+		return info.name
+	}
+
+	return unknownOperation
+}
+
+// failoverAttemptFromContext returns the number of times the operation failed over to another endpoint before
+// sending the http request whose context is ctx, defaulting to 0
+func failoverAttemptFromContext(ctx context.Context) int {
+	if failovers, ok := ctx.Value(failoverAttemptKey{}).(int); ok {
+		return failovers
+	}
+
+	return 0
+}
+
+// pathTemplate replaces the account ID in paths under the accounts endpoint with a placeholder
+// so that requests for different accounts can be grouped together
+func pathTemplate(path string) string {
+	if !strings.HasPrefix(path, basev1AccountsPath+"/") {
+		return path
+	}
+
+	return accountPathTemplate
+}
+
+// operation is an in-flight client call
+type operation struct {
+	info    *operationInfo
+	span    Span
+	metrics Metrics
+}
+
+// startOperation prepares ctx for the operation requested by req and starts a span for it
+func (c *Client) startOperation(ctx context.Context, req *OperationRequest) (context.Context, *operation) {
+	info := newOperationInfo(string(req.Operation), operationPathTemplates[req.Operation])
+
+	ctx, span := c.tracer.Start(ctx, "accounts."+info.name)
+	span.SetAttributes(Attribute{Key: AttributeAccountID, Value: req.AccountID})
+	if req.Account != nil {
+		span.SetAttributes(Attribute{Key: AttributeOrganisationID, Value: req.Account.OrganisationID})
+	}
+
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	ctx = withOperationInfo(ctx, info)
+
+	return ctx, &operation{info: info, span: span, metrics: c.metrics}
+}
+
+// end records the outcome of the operation on its span and in the client metrics and then ends the span
+func (op *operation) end(err error, resp *OperationResponse) {
+	// the organisation of fetched accounts is only known once the account has been found
+	if resp != nil && resp.Account != nil && resp.Account.Data != nil && resp.Account.Data.OrganisationID != "" {
+		op.span.SetAttributes(Attribute{Key: AttributeOrganisationID, Value: resp.Account.Data.OrganisationID})
+	}
+
+	if statusCode := op.info.lastStatusCode(); statusCode != 0 {
+		op.span.SetAttributes(Attribute{Key: AttributeHTTPStatusCode, Value: statusCode})
+	}
+
+	op.span.SetAttributes(Attribute{Key: AttributeFailoverAttempts, Value: op.info.failoverAttempts()})
+
+	if err != nil {
+		op.span.RecordError(err)
+		op.metrics.IncErrors(op.info.name, errorType(err))
+	}
+
+	op.span.End()
+}
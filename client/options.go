@@ -0,0 +1,94 @@
+package client
+
+import (
+	"log/slog"
+)
+
+// Option configures optional behaviour of the Client at construction time
+type Option func(cfg *config) error
+
+// config collects the settings applied by Options before the Client is built
+type config struct {
+	logger  *slog.Logger
+	tracer  Tracer
+	metrics Metrics
+
+	middlewares    []Middleware
+	cache          *cacheLayer
+	conditional    *conditionalLayer
+	circuitBreaker *circuitBreaker
+	limiter        *rateLimiter
+	endpoints      *EndpointsConfig
+	tls            *tlsTransport
+	proxy          *proxySelector
+}
+
+// setDefaults fills in no-op implementations for any optional dependency that was not configured
+func (cfg *config) setDefaults() {
+	if cfg.tracer == nil {
+		cfg.tracer = noopTracer{}
+	}
+
+	if cfg.metrics == nil {
+		cfg.metrics = noopMetrics{}
+	}
+}
+
+// operationMiddlewares returns the middlewares configured by the user followed by those
+// implementing built in behaviour, which run closest to the API
+func (cfg *config) operationMiddlewares() []Middleware {
+	middlewares := append([]Middleware(nil), cfg.middlewares...)
+
+	// cache hits should not count towards the circuit breaker or use up rate limit budget so the cache comes first
+	if cfg.cache != nil {
+		middlewares = append(middlewares, cfg.cache.middleware)
+	}
+
+	// conditional fetches go after the cache so that they are only sent once a cached account has gone stale
+	if cfg.conditional != nil {
+		if cfg.cache != nil {
+			cfg.conditional.store = cfg.cache.cache
+		} else {
+			cfg.conditional.store = NewLRUCache(defaultConditionalCapacity)
+			cfg.conditional.ownsStore = true
+		}
+
+		middlewares = append(middlewares, cfg.conditional.middleware)
+	}
+
+	if cfg.circuitBreaker != nil {
+		middlewares = append(middlewares, cfg.circuitBreaker.middleware)
+	}
+
+	// requests rejected by the circuit breaker should not use up rate limit budget so the limiter comes after it
+	if cfg.limiter != nil {
+		cfg.limiter.metrics = cfg.metrics
+		middlewares = append(middlewares, cfg.limiter.middleware)
+	}
+
+	return middlewares
+}
+
+// rateLimiter returns the rate limiter being configured, creating it if this is the first rate limit option
+func (cfg *config) rateLimiter() *rateLimiter {
+	if cfg.limiter == nil {
+		cfg.limiter = &rateLimiter{operations: map[Operation]*tokenBucket{}}
+	}
+
+	return cfg.limiter
+}
+
+// WithLogger makes the client write one structured record per http request to the given logger.
+// Sensitive values such as Authorization headers, IBANs and account holder names are redacted automatically.
+// The failover_attempt field of a record counts the times the operation failed over to another endpoint
+// before sending the request, so it is 0 for the request sent to the first endpoint.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cfg *config) error {
+		if logger == nil {
+			return newInputError("logger cannot be nil", nil)
+		}
+
+		cfg.logger = slog.New(newRedactingHandler(logger.Handler()))
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+// Package oteltracer adapts an OpenTelemetry tracer to the client.Tracer interface
+// so that client operations can be traced without the client package depending on the OpenTelemetry SDK.
+package oteltracer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer is a client.Tracer that starts OpenTelemetry spans
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New returns a client.Tracer backed by tracer
+func New(tracer trace.Tracer) *Tracer {
+	return &Tracer{tracer: tracer}
+}
+
+// Start starts an OpenTelemetry client span
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, client.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &Span{span: span}
+}
+
+// Span is a client.Span wrapping an OpenTelemetry span
+type Span struct {
+	span trace.Span
+}
+
+// SetAttributes converts attrs to OpenTelemetry attributes and sets them on the span
+func (s *Span) SetAttributes(attrs ...client.Attribute) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, toKeyValue(attr))
+	}
+
+	s.span.SetAttributes(kvs...)
+}
+
+// RecordError records err on the span and marks the span as failed
+func (s *Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// SpanContext returns the trace and span IDs of the span
+func (s *Span) SpanContext() client.SpanContext {
+	sc := s.span.SpanContext()
+	return client.SpanContext{
+		TraceID: sc.TraceID(),
+		SpanID:  sc.SpanID(),
+		Sampled: sc.IsSampled(),
+	}
+}
+
+// End ends the span
+func (s *Span) End() {
+	s.span.End()
+}
+
+// toKeyValue converts attr to the closest OpenTelemetry attribute type, falling back to its string representation
+func toKeyValue(attr client.Attribute) attribute.KeyValue {
+	switch v := attr.Value.(type) {
+	case string:
+		return attribute.String(attr.Key, v)
+	case int:
+		return attribute.Int(attr.Key, v)
+	case int64:
+		return attribute.Int64(attr.Key, v)
+	case bool:
+		return attribute.Bool(attr.Key, v)
+	case float64:
+		return attribute.Float64(attr.Key, v)
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,77 @@
+package oteltracer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer_clientOperationsProduceOtelSpans(t *testing.T) {
+	var traceParent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	c, err := client.NewClient(server.URL, nil, client.WithTracer(New(provider.Tracer("test"))))
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "accounts.delete", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.ElementsMatch(
+		t,
+		[]attribute.KeyValue{
+			attribute.String(client.AttributeAccountID, "1dfaf917-c6d6-4e18-b7e7-972e66492976"),
+			attribute.Int(client.AttributeHTTPStatusCode, http.StatusNotFound),
+			attribute.Int(client.AttributeFailoverAttempts, 0),
+		},
+		span.Attributes(),
+	)
+
+	expectedTraceParent := fmt.Sprintf("00-%s-%s-01", span.SpanContext().TraceID(), span.SpanContext().SpanID())
+	assert.Equal(t, expectedTraceParent, traceParent)
+}
+
+func TestToKeyValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    client.Attribute
+		expected attribute.KeyValue
+	}{
+		{name: "string", input: client.Attribute{Key: "k", Value: "v"}, expected: attribute.String("k", "v")},
+		{name: "int", input: client.Attribute{Key: "k", Value: 1}, expected: attribute.Int("k", 1)},
+		{name: "int64", input: client.Attribute{Key: "k", Value: int64(1)}, expected: attribute.Int64("k", 1)},
+		{name: "bool", input: client.Attribute{Key: "k", Value: true}, expected: attribute.Bool("k", true)},
+		{name: "float64", input: client.Attribute{Key: "k", Value: 1.5}, expected: attribute.Float64("k", 1.5)},
+		{name: "other", input: client.Attribute{Key: "k", Value: uint(7)}, expected: attribute.String("k", "7")},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.expected, toKeyValue(tc.input))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Package prommetrics provides a client.Metrics implementation that keeps its measurements in memory
+// and serves them over http in the Prometheus text exposition format.
+// https://prometheus.io/docs/instrumenting/exposition_formats/#text-based-format
+package prommetrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	requestsMetric = "accountapi_client_requests_total"
+	errorsMetric   = "accountapi_client_errors_total"
+	latencyMetric  = "accountapi_client_request_duration_seconds"
+	inFlightMetric = "accountapi_client_requests_in_flight"
+	failoverMetric = "accountapi_client_failover_attempts_total"
+	waitMetric     = "accountapi_client_rate_limit_wait_seconds"
+)
+
+// DefaultBuckets are the latency histogram bucket upper bounds in seconds used when none are provided
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry is a client.Metrics that can be exposed to Prometheus through its ServeHTTP method
+type Registry struct {
+	mu        sync.Mutex
+	buckets   []float64
+	requests  map[[2]string]uint64
+	errors    map[[2]string]uint64
+	latency   map[string]*histogram
+	inFlight  map[string]int64
+	failovers map[string]uint64
+	waits     map[string]*histogram
+}
+
+// histogram is a cumulative histogram of latencies in seconds
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// New returns an empty Registry whose latency histograms use buckets, or DefaultBuckets if none are given
+func New(buckets ...float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &Registry{
+		buckets:   sorted,
+		requests:  map[[2]string]uint64{},
+		errors:    map[[2]string]uint64{},
+		latency:   map[string]*histogram{},
+		inFlight:  map[string]int64{},
+		failovers: map[string]uint64{},
+		waits:     map[string]*histogram{},
+	}
+}
+
+// IncRequests counts a request sent for operation that received statusCode
+func (r *Registry) IncRequests(operation string, statusCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests[[2]string{operation, strconv.Itoa(statusCode)}]++
+}
+
+// IncErrors counts an operation that failed with an error of kind errType
+func (r *Registry) IncErrors(operation, errType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors[[2]string{operation, errType}]++
+}
+
+// ObserveLatency adds duration to the latency histogram of operation
+func (r *Registry) ObserveLatency(operation string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observe(r.latency, operation, duration)
+}
+
+// AddInFlight adjusts the in flight gauge of operation by delta
+func (r *Registry) AddInFlight(operation string, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inFlight[operation] += int64(delta)
+}
+
+// IncFailoverAttempts counts a request for operation sent to another endpoint after the previous one failed
+func (r *Registry) IncFailoverAttempts(operation string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failovers[operation]++
+}
+
+// ObserveRateLimitWait adds duration to the rate limiter wait histogram of operation
+func (r *Registry) ObserveRateLimitWait(operation string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observe(r.waits, operation, duration)
+}
+
+// observe adds duration to the histogram of operation in histograms. r.mu must be held.
+func (r *Registry) observe(histograms map[string]*histogram, operation string, duration time.Duration) {
+	h, ok := histograms[operation]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(r.buckets))}
+		histograms[operation] = h
+	}
+
+	seconds := duration.Seconds()
+	for idx, upperBound := range r.buckets {
+		if seconds <= upperBound {
+			h.counts[idx]++
+		}
+	}
+
+	h.count++
+	h.sum += seconds
+}
+
+// ServeHTTP writes every metric in the Prometheus text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	r.WriteTo(w)
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format to w
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sb strings.Builder
+
+	writeHeader(&sb, requestsMetric, "counter", "Number of http requests sent to the account API.")
+	for _, key := range sortedPairKeys(r.requests) {
+		fmt.Fprintf(&sb, "%s{operation=%q,code=%q} %d\n", requestsMetric, key[0], key[1], r.requests[key])
+	}
+
+	writeHeader(&sb, errorsMetric, "counter", "Number of client operations that failed, by error type.")
+	for _, key := range sortedPairKeys(r.errors) {
+		fmt.Fprintf(&sb, "%s{operation=%q,type=%q} %d\n", errorsMetric, key[0], key[1], r.errors[key])
+	}
+
+	writeHeader(&sb, latencyMetric, "histogram", "Latency of http requests sent to the account API.")
+	r.writeHistograms(&sb, latencyMetric, r.latency)
+
+	writeHeader(&sb, inFlightMetric, "gauge", "Number of http requests to the account API currently in flight.")
+	for _, operation := range sortedKeys(r.inFlight) {
+		fmt.Fprintf(&sb, "%s{operation=%q} %d\n", inFlightMetric, operation, r.inFlight[operation])
+	}
+
+	writeHeader(&sb, failoverMetric, "counter", "Number of http requests to the account API sent to another endpoint after the previous one failed.")
+	for _, operation := range sortedKeys(r.failovers) {
+		fmt.Fprintf(&sb, "%s{operation=%q} %d\n", failoverMetric, operation, r.failovers[operation])
+	}
+
+	writeHeader(&sb, waitMetric, "histogram", "Time client operations spent waiting for the client side rate limiter.")
+	r.writeHistograms(&sb, waitMetric, r.waits)
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// writeHistograms writes the histogram of every operation in histograms. r.mu must be held.
+func (r *Registry) writeHistograms(sb *strings.Builder, name string, histograms map[string]*histogram) {
+	for _, operation := range sortedKeys(histograms) {
+		h := histograms[operation]
+		for idx, upperBound := range r.buckets {
+			fmt.Fprintf(
+				sb, "%s_bucket{operation=%q,le=%q} %d\n",
+				name, operation, strconv.FormatFloat(upperBound, 'g', -1, 64), h.counts[idx],
+			)
+		}
+
+		fmt.Fprintf(sb, "%s_bucket{operation=%q,le=\"+Inf\"} %d\n", name, operation, h.count)
+		fmt.Fprintf(sb, "%s_sum{operation=%q} %s\n", name, operation, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(sb, "%s_count{operation=%q} %d\n", name, operation, h.count)
+	}
+}
+
+func writeHeader(sb *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPairKeys(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+
+		return keys[i][1] < keys[j][1]
+	})
+
+	return keys
+}
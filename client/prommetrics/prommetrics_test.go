@@ -0,0 +1,87 @@
+package prommetrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_ServeHTTPWritesTextExpositionFormat(t *testing.T) {
+	r := New(0.1, 1)
+	r.IncRequests("fetch", 200)
+	r.IncRequests("fetch", 200)
+	r.IncRequests("create", 409)
+	r.IncErrors("create", "api_error")
+	r.ObserveLatency("fetch", 50*time.Millisecond)
+	r.ObserveLatency("fetch", 500*time.Millisecond)
+	r.AddInFlight("fetch", 1)
+	r.IncFailoverAttempts("fetch")
+	r.ObserveRateLimitWait("create", 0)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	expected := strings.Join([]string{
+		"# HELP accountapi_client_requests_total Number of http requests sent to the account API.",
+		"# TYPE accountapi_client_requests_total counter",
+		`accountapi_client_requests_total{operation="create",code="409"} 1`,
+		`accountapi_client_requests_total{operation="fetch",code="200"} 2`,
+		"# HELP accountapi_client_errors_total Number of client operations that failed, by error type.",
+		"# TYPE accountapi_client_errors_total counter",
+		`accountapi_client_errors_total{operation="create",type="api_error"} 1`,
+		"# HELP accountapi_client_request_duration_seconds Latency of http requests sent to the account API.",
+		"# TYPE accountapi_client_request_duration_seconds histogram",
+		`accountapi_client_request_duration_seconds_bucket{operation="fetch",le="0.1"} 1`,
+		`accountapi_client_request_duration_seconds_bucket{operation="fetch",le="1"} 2`,
+		`accountapi_client_request_duration_seconds_bucket{operation="fetch",le="+Inf"} 2`,
+		`accountapi_client_request_duration_seconds_sum{operation="fetch"} 0.55`,
+		`accountapi_client_request_duration_seconds_count{operation="fetch"} 2`,
+		"# HELP accountapi_client_requests_in_flight Number of http requests to the account API currently in flight.",
+		"# TYPE accountapi_client_requests_in_flight gauge",
+		`accountapi_client_requests_in_flight{operation="fetch"} 1`,
+		"# HELP accountapi_client_failover_attempts_total Number of http requests to the account API sent to another endpoint after the previous one failed.",
+		"# TYPE accountapi_client_failover_attempts_total counter",
+		`accountapi_client_failover_attempts_total{operation="fetch"} 1`,
+		"# HELP accountapi_client_rate_limit_wait_seconds Time client operations spent waiting for the client side rate limiter.",
+		"# TYPE accountapi_client_rate_limit_wait_seconds histogram",
+		`accountapi_client_rate_limit_wait_seconds_bucket{operation="create",le="0.1"} 1`,
+		`accountapi_client_rate_limit_wait_seconds_bucket{operation="create",le="1"} 1`,
+		`accountapi_client_rate_limit_wait_seconds_bucket{operation="create",le="+Inf"} 1`,
+		`accountapi_client_rate_limit_wait_seconds_sum{operation="create"} 0`,
+		`accountapi_client_rate_limit_wait_seconds_count{operation="create"} 1`,
+		"",
+	}, "\n")
+
+	assert.Equal(t, expected, rec.Body.String())
+}
+
+func TestRegistry_collectsFromClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	defer server.Close()
+
+	r := New()
+	c, err := client.NewClient(server.URL, nil, client.WithMetrics(r))
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.NoError(t, err)
+
+	var sb strings.Builder
+	_, err = r.WriteTo(&sb)
+	assert.NoError(t, err)
+
+	assert.Contains(t, sb.String(), `accountapi_client_requests_total{operation="delete",code="204"} 1`)
+	assert.Contains(t, sb.String(), `accountapi_client_request_duration_seconds_count{operation="delete"} 1`)
+	assert.Contains(t, sb.String(), `accountapi_client_requests_in_flight{operation="delete"} 0`)
+}
@@ -0,0 +1,308 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProxyConfig configures a proxy to send requests to the API through
+type ProxyConfig struct {
+	// URL is the proxy, e.g. http://proxy.internal:3128. Requests to https APIs are tunnelled through it with CONNECT.
+	URL string
+	// Username and Password authenticate with the proxy, overriding any credentials in URL
+	Username string
+	Password string
+	// NoProxy lists the hosts that requests are sent to directly: host names, which also match their subdomains,
+	// domains starting with a dot, which only match subdomains, IP addresses, CIDR ranges and "*" for every host.
+	// Each may be followed by a port, e.g. "api.internal:8080", to only match that port.
+	NoProxy []string
+}
+
+// TransportDecorator is a RoundTripper that passes requests on to another one. Decorated transports given to
+// NewClient must implement it for WithProxy to reach the *http.Transport underneath them.
+type TransportDecorator interface {
+	http.RoundTripper
+	// Unwrap returns the RoundTripper requests are passed on to
+	Unwrap() http.RoundTripper
+	// WithTransport returns a decorator that behaves like this one but passes requests on to transport
+	WithTransport(transport http.RoundTripper) http.RoundTripper
+}
+
+// WithProxy makes the client send requests through a proxy, e.g. one that needs authentication.
+// The proxy is set on a clone of the transport given to NewClient, which must be nil, an *http.Transport or
+// a TransportDecorator around one, in which case the decorator is given the clone to pass requests on to.
+// For other decorated transports set ProxyFunc on the *http.Transport being decorated instead.
+func WithProxy(proxyConfig ProxyConfig) Option {
+	return func(cfg *config) error {
+		ps, err := newProxySelector(proxyConfig)
+		if err != nil {
+			return err
+		}
+
+		cfg.proxy = ps
+		return nil
+	}
+}
+
+// WithProxyFromEnvironment makes the client send requests through the proxies set by the HTTPS_PROXY, HTTP_PROXY
+// and NO_PROXY environment variables, or their lowercase versions, as they are when the client is created.
+// Unlike net/http, requests to localhost go through the proxy unless NO_PROXY says otherwise.
+// The client connects directly if no proxy is set.
+func WithProxyFromEnvironment() Option {
+	return func(cfg *config) error {
+		ps, err := proxySelectorFromEnvironment(os.Getenv)
+		if err != nil {
+			return err
+		}
+
+		cfg.proxy = ps
+		return nil
+	}
+}
+
+// ProxyFunc returns a function for http.Transport.Proxy that picks the proxy for a request as WithProxy does,
+// for when the client is given a decorated transport that does not implement TransportDecorator
+func ProxyFunc(proxyConfig ProxyConfig) (func(req *http.Request) (*url.URL, error), error) {
+	ps, err := newProxySelector(proxyConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps.proxy, nil
+}
+
+// proxySelector picks the proxy each request is sent through
+type proxySelector struct {
+	// httpProxy and httpsProxy are the proxies for requests to http and https URLs, nil to connect directly
+	httpProxy  *url.URL
+	httpsProxy *url.URL
+	noProxy    []noProxyRule
+}
+
+func newProxySelector(proxyConfig ProxyConfig) (*proxySelector, error) {
+	proxyURL, err := parseProxyURL(proxyConfig.URL)
+	if err != nil {
+		return nil, newInputError("invalid proxy URL", err)
+	}
+
+	if proxyConfig.Username != "" || proxyConfig.Password != "" {
+		proxyURL.User = url.UserPassword(proxyConfig.Username, proxyConfig.Password)
+	}
+
+	noProxy, err := parseNoProxy(proxyConfig.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proxySelector{httpProxy: proxyURL, httpsProxy: proxyURL, noProxy: noProxy}, nil
+}
+
+// proxySelectorFromEnvironment returns the proxySelector set by the environment variables read with getenv
+func proxySelectorFromEnvironment(getenv func(key string) string) (*proxySelector, error) {
+	lookup := func(key string) string {
+		if value := getenv(key); value != "" {
+			return value
+		}
+
+		return getenv(strings.ToLower(key))
+	}
+
+	ps := &proxySelector{}
+	for _, proxy := range []struct {
+		key    string
+		target **url.URL
+	}{{key: "HTTP_PROXY", target: &ps.httpProxy}, {key: "HTTPS_PROXY", target: &ps.httpsProxy}} {
+		value := lookup(proxy.key)
+		if value == "" {
+			continue
+		}
+
+		// a proxy without a scheme, e.g. proxy.internal:3128, is taken to be an http proxy as by curl
+		if !strings.Contains(value, "://") {
+			value = "http://" + value
+		}
+
+		proxyURL, err := parseProxyURL(value)
+		if err != nil {
+			return nil, newInputError(fmt.Sprintf("invalid %s", proxy.key), err)
+		}
+
+		*proxy.target = proxyURL
+	}
+
+	noProxy, err := parseNoProxy(strings.Split(lookup("NO_PROXY"), ","))
+	if err != nil {
+		return nil, err
+	}
+
+	ps.noProxy = noProxy
+	return ps, nil
+}
+
+// parseProxyURL parses the URL of a proxy, which must be an http, https or socks5 proxy
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("%q has no host", rawURL)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q, expected http, https or socks5", proxyURL.Scheme)
+	}
+
+	return proxyURL, nil
+}
+
+// wrap returns a clone of transport that sends requests through the proxies picked by ps.
+// Decorators are unwrapped down to the *http.Transport underneath, which is the one that is cloned.
+func (ps *proxySelector) wrap(transport http.RoundTripper) (http.RoundTripper, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	switch t := transport.(type) {
+	case *http.Transport:
+		clone := t.Clone()
+		clone.Proxy = ps.proxy
+		return clone, nil
+	case TransportDecorator:
+		inner, err := ps.wrap(t.Unwrap())
+		if err != nil {
+			return nil, err
+		}
+
+		return t.WithTransport(inner), nil
+	default:
+		return nil, newInputError(fmt.Sprintf("proxy options need an *http.Transport or a TransportDecorator, got %T, use ProxyFunc on the transport it wraps", transport), nil)
+	}
+}
+
+// proxy returns the proxy req should be sent through, or nil if it should be sent directly
+func (ps *proxySelector) proxy(req *http.Request) (*url.URL, error) {
+	proxyURL := ps.httpProxy
+	if req.URL.Scheme == "https" {
+		proxyURL = ps.httpsProxy
+	}
+
+	if proxyURL == nil {
+		return nil, nil
+	}
+
+	host, port := strings.ToLower(req.URL.Hostname()), req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	for _, rule := range ps.noProxy {
+		if rule.matches(host, port) {
+			return nil, nil
+		}
+	}
+
+	return proxyURL, nil
+}
+
+// noProxyRule is an entry of a NO_PROXY list
+type noProxyRule struct {
+	all bool
+	ip  net.IP
+	net *net.IPNet
+	// domain matches itself and its subdomains, or only its subdomains if subdomainsOnly is set
+	domain         string
+	subdomainsOnly bool
+	// port, if set, is the only port the rule matches
+	port string
+}
+
+// parseNoProxy parses the entries of a NO_PROXY list, skipping empty ones
+func parseNoProxy(entries []string) ([]noProxyRule, error) {
+	var rules []noProxyRule
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			rules = append(rules, noProxyRule{all: true})
+			continue
+		}
+
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			rules = append(rules, noProxyRule{net: ipNet})
+			continue
+		}
+
+		if ip := net.ParseIP(strings.Trim(entry, "[]")); ip != nil {
+			rules = append(rules, noProxyRule{ip: ip})
+			continue
+		}
+
+		var rule noProxyRule
+		host := entry
+		if h, port, err := net.SplitHostPort(entry); err == nil {
+			if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				return nil, newInputError(fmt.Sprintf("invalid no proxy entry %q", entry), nil)
+			}
+
+			host, rule.port = h, port
+		}
+
+		if ip := net.ParseIP(host); ip != nil {
+			rule.ip = ip
+		} else {
+			rule.subdomainsOnly = strings.HasPrefix(host, ".")
+			rule.domain = strings.TrimPrefix(host, ".")
+			if rule.domain == "" || strings.ContainsAny(rule.domain, "/:") {
+				return nil, newInputError(fmt.Sprintf("invalid no proxy entry %q", entry), nil)
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// matches reports whether requests to host and port should be sent directly
+func (rule noProxyRule) matches(host, port string) bool {
+	if rule.all {
+		return true
+	}
+
+	if rule.port != "" && rule.port != port {
+		return false
+	}
+
+	if rule.net != nil || rule.ip != nil {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return false
+		}
+
+		if rule.net != nil {
+			return rule.net.Contains(ip)
+		}
+
+		return rule.ip.Equal(ip)
+	}
+
+	if strings.HasSuffix(host, "."+rule.domain) {
+		return true
+	}
+
+	return !rule.subdomainsOnly && host == rule.domain
+}
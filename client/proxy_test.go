@@ -0,0 +1,353 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testProxy is a forward proxy that tunnels CONNECT requests, forwards the rest and records every request it gets
+type testProxy struct {
+	*httptest.Server
+	// credentials, if set, are the username and password clients must give, as "username:password"
+	credentials string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newTestProxy(t *testing.T, credentials string) *testProxy {
+	tp := &testProxy{credentials: credentials}
+	tp.Server = httptest.NewServer(tp)
+	t.Cleanup(tp.Close)
+
+	return tp
+}
+
+func (tp *testProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tp.mu.Lock()
+	tp.requests = append(tp.requests, r.Method+" "+r.RequestURI)
+	tp.mu.Unlock()
+
+	if tp.credentials != "" && r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(tp.credentials)) {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		tp.tunnel(w, r)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Authorization")
+
+	resp, err := (&http.Transport{}).RoundTrip(out)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	defer resp.Body.Close()
+
+	for key, values := range resp.Header {
+		w.Header()[key] = values
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// tunnel connects the client to the host it asked for and copies bytes both ways until either side closes
+func (tp *testProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+
+	_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	go func() {
+		_, _ = io.Copy(upstream, conn)
+		upstream.Close()
+	}()
+
+	_, _ = io.Copy(conn, upstream)
+	conn.Close()
+}
+
+func (tp *testProxy) seen() []string {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	return append([]string(nil), tp.requests...)
+}
+
+// healthyHandler answers every request as a healthy API
+var healthyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(`{"status":"up"}`))
+})
+
+func TestWithProxy_invalidConfigReturnsError(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cfg         ProxyConfig
+		expectedErr string
+	}{
+		{name: "no URL", cfg: ProxyConfig{}, expectedErr: `input error - invalid proxy URL: "" has no host`},
+		{name: "unsupported scheme", cfg: ProxyConfig{URL: "ftp://proxy:21"}, expectedErr: `input error - invalid proxy URL: unsupported scheme "ftp", expected http, https or socks5`},
+		{name: "invalid no proxy entry", cfg: ProxyConfig{URL: "http://proxy:3128", NoProxy: []string{"http://api.internal"}}, expectedErr: `input error - invalid no proxy entry "http://api.internal"`},
+	}
+
+	for idx, tc := range testCases {
+		_, err := NewClient("http://0.0.0.0:8080", nil, WithProxy(tc.cfg))
+		assert.EqualError(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+
+	_, err := NewClient("http://0.0.0.0:8080", &mockRoundTripper{}, WithProxy(ProxyConfig{URL: "http://proxy:3128"}))
+	assert.EqualError(t, err, "input error - proxy options need an *http.Transport or a TransportDecorator, got *client.mockRoundTripper, use ProxyFunc on the transport it wraps")
+}
+
+func TestWithProxy_plainHTTP(t *testing.T) {
+	api := httptest.NewServer(healthyHandler)
+	defer api.Close()
+
+	testCases := []struct {
+		name               string
+		username, password string
+		noProxy            []string
+		expectedStatusCode int
+		expectedSeen       []string
+	}{
+		{name: "authenticated", username: "user", password: "secret", expectedSeen: []string{"GET " + api.URL + "/v1/health"}},
+		{name: "wrong password", username: "user", password: "guess", expectedStatusCode: http.StatusProxyAuthRequired, expectedSeen: []string{"GET " + api.URL + "/v1/health"}},
+		{name: "no proxy", noProxy: []string{"example.com", "127.0.0.0/8"}, expectedSeen: nil},
+	}
+
+	for idx, tc := range testCases {
+		proxy := newTestProxy(t, "user:secret")
+		c, err := NewClient(api.URL, nil, WithProxy(ProxyConfig{URL: proxy.URL, Username: tc.username, Password: tc.password, NoProxy: tc.noProxy}))
+		assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+
+		_, err = c.Health(context.Background())
+		if tc.expectedStatusCode != 0 {
+			assert.Equal(t, tc.expectedStatusCode, StatusCode(err), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		} else {
+			assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		}
+
+		assert.Equal(t, tc.expectedSeen, proxy.seen(), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
+
+func TestWithProxy_connectTunnelWithTLSAndDecorators(t *testing.T) {
+	api := httptest.NewTLSServer(healthyHandler)
+	defer api.Close()
+
+	proxy := newTestProxy(t, "user:secret")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: api.Certificate().Raw})
+
+	metrics := newFakeMetrics()
+	c, err := NewClient(api.URL, nil,
+		WithProxy(ProxyConfig{URL: proxy.URL, Username: "user", Password: "secret"}),
+		WithTLS(TLSConfig{CAPEM: [][]byte{caPEM}}),
+		WithMetrics(metrics),
+	)
+	assert.NoError(t, err)
+
+	health, err := c.Health(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, health.Up())
+
+	apiURL, _ := url.Parse(api.URL)
+	assert.Equal(t, []string{"CONNECT " + apiURL.Host}, proxy.seen())
+	// the client's own decorators still see the request
+	assert.Equal(t, map[string]int{unknownOperation + " 200": 1}, metrics.requests)
+
+	// the tunnel is refused without credentials
+	c, err = NewClient(api.URL, nil, WithProxy(ProxyConfig{URL: proxy.URL}), WithTLS(TLSConfig{CAPEM: [][]byte{caPEM}}))
+	assert.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	assert.ErrorContains(t, err, "Proxy Authentication Required")
+}
+
+// countingTransport is a custom decorator counting the requests it passes on
+type countingTransport struct {
+	transport http.RoundTripper
+	count     int
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.count++
+	return ct.transport.RoundTrip(req)
+}
+
+func TestProxyFunc_decoratedTransport(t *testing.T) {
+	api := httptest.NewServer(healthyHandler)
+	defer api.Close()
+
+	proxy := newTestProxy(t, "")
+	proxyFunc, err := ProxyFunc(ProxyConfig{URL: proxy.URL})
+	assert.NoError(t, err)
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = proxyFunc
+	decorated := &countingTransport{transport: base}
+
+	c, err := NewClient(api.URL, decorated)
+	assert.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, decorated.count)
+	assert.Equal(t, []string{"GET " + api.URL + "/v1/health"}, proxy.seen())
+}
+
+// unwrappableTransport is a custom decorator counting the requests it passes on that WithProxy can see through
+type unwrappableTransport struct {
+	transport http.RoundTripper
+	count     *int
+}
+
+func (ut *unwrappableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	*ut.count++
+	return ut.transport.RoundTrip(req)
+}
+
+func (ut *unwrappableTransport) Unwrap() http.RoundTripper {
+	return ut.transport
+}
+
+func (ut *unwrappableTransport) WithTransport(transport http.RoundTripper) http.RoundTripper {
+	return &unwrappableTransport{transport: transport, count: ut.count}
+}
+
+func TestWithProxy_decoratedTransport(t *testing.T) {
+	api := httptest.NewServer(healthyHandler)
+	defer api.Close()
+
+	proxy := newTestProxy(t, "")
+
+	var count int
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = nil
+	// decorators can be stacked, the proxy is set on the *http.Transport at the bottom
+	decorated := &unwrappableTransport{transport: &unwrappableTransport{transport: base, count: &count}, count: &count}
+
+	c, err := NewClient(api.URL, decorated, WithProxy(ProxyConfig{URL: proxy.URL}))
+	assert.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"GET " + api.URL + "/v1/health"}, proxy.seen())
+
+	// the transports given to the client are left as they were
+	assert.Nil(t, base.Proxy)
+	assert.Same(t, base, decorated.transport.(*unwrappableTransport).transport)
+
+	// a decorator that cannot be seen through is rejected
+	_, err = NewClient(api.URL, &unwrappableTransport{transport: &countingTransport{transport: base}, count: &count}, WithProxy(ProxyConfig{URL: proxy.URL}))
+	assert.EqualError(t, err, "input error - proxy options need an *http.Transport or a TransportDecorator, got *client.countingTransport, use ProxyFunc on the transport it wraps")
+}
+
+func TestProxySelectorFromEnvironment(t *testing.T) {
+	testCases := []struct {
+		name          string
+		env           map[string]string
+		requestURL    string
+		expectedProxy string
+		expectedErr   string
+	}{
+		{name: "nothing set", env: map[string]string{}, requestURL: "https://api.internal", expectedProxy: ""},
+		{name: "https proxy", env: map[string]string{"HTTPS_PROXY": "http://secure-proxy:3128", "HTTP_PROXY": "http://proxy:3128"}, requestURL: "https://api.internal", expectedProxy: "http://secure-proxy:3128"},
+		{name: "http proxy", env: map[string]string{"HTTPS_PROXY": "http://secure-proxy:3128", "HTTP_PROXY": "http://proxy:3128"}, requestURL: "http://api.internal", expectedProxy: "http://proxy:3128"},
+		{name: "lowercase", env: map[string]string{"https_proxy": "http://u:p@proxy:3128"}, requestURL: "https://api.internal", expectedProxy: "http://u:p@proxy:3128"},
+		{name: "uppercase wins", env: map[string]string{"HTTPS_PROXY": "http://upper:3128", "https_proxy": "http://lower:3128"}, requestURL: "https://api.internal", expectedProxy: "http://upper:3128"},
+		{name: "no scheme", env: map[string]string{"HTTPS_PROXY": "proxy:3128"}, requestURL: "https://api.internal", expectedProxy: "http://proxy:3128"},
+		{name: "localhost is proxied", env: map[string]string{"HTTP_PROXY": "http://proxy:3128"}, requestURL: "http://localhost:8080", expectedProxy: "http://proxy:3128"},
+		{name: "no proxy", env: map[string]string{"HTTPS_PROXY": "http://proxy:3128", "NO_PROXY": "localhost, .internal"}, requestURL: "https://api.internal", expectedProxy: ""},
+		{name: "lowercase no proxy", env: map[string]string{"HTTPS_PROXY": "http://proxy:3128", "no_proxy": "api.internal"}, requestURL: "https://api.internal", expectedProxy: ""},
+		{name: "invalid proxy", env: map[string]string{"HTTPS_PROXY": "ftp://proxy:21"}, expectedErr: `input error - invalid HTTPS_PROXY: unsupported scheme "ftp", expected http, https or socks5`},
+	}
+
+	for idx, tc := range testCases {
+		ps, err := proxySelectorFromEnvironment(func(key string) string { return tc.env[key] })
+		if tc.expectedErr != "" {
+			assert.EqualError(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+
+		req, err := http.NewRequest(http.MethodGet, tc.requestURL, nil)
+		assert.NoError(t, err)
+
+		proxyURL, err := ps.proxy(req)
+		assert.NoError(t, err)
+		if tc.expectedProxy == "" {
+			assert.Nil(t, proxyURL, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		} else {
+			assert.Equal(t, tc.expectedProxy, proxyURL.String(), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		}
+	}
+}
+
+func TestNoProxyRule_matches(t *testing.T) {
+	testCases := []struct {
+		name     string
+		entry    string
+		url      string
+		expected bool
+	}{
+		{name: "everything", entry: "*", url: "https://api.example.com", expected: true},
+		{name: "exact host", entry: "example.com", url: "https://example.com", expected: true},
+		{name: "subdomain of host", entry: "example.com", url: "https://api.example.com", expected: true},
+		{name: "host is not a suffix", entry: "example.com", url: "https://badexample.com", expected: false},
+		{name: "subdomains only", entry: ".example.com", url: "https://example.com", expected: false},
+		{name: "subdomain", entry: ".example.com", url: "https://api.example.com", expected: true},
+		{name: "case insensitive", entry: "Example.COM", url: "https://API.example.com", expected: true},
+		{name: "matching port", entry: "example.com:8443", url: "https://example.com:8443", expected: true},
+		{name: "other port", entry: "example.com:8443", url: "https://example.com", expected: false},
+		{name: "default port", entry: "example.com:443", url: "https://example.com", expected: true},
+		{name: "ip", entry: "10.1.2.3", url: "http://10.1.2.3:8080", expected: true},
+		{name: "other ip", entry: "10.1.2.3", url: "http://10.1.2.4:8080", expected: false},
+		{name: "ip and port", entry: "10.1.2.3:8080", url: "http://10.1.2.3:8080", expected: true},
+		{name: "ipv6", entry: "[::1]", url: "http://[::1]:8080", expected: true},
+		{name: "cidr", entry: "10.0.0.0/8", url: "http://10.1.2.3", expected: true},
+		{name: "outside cidr", entry: "10.0.0.0/8", url: "http://192.168.1.1", expected: false},
+		{name: "cidr does not match names", entry: "10.0.0.0/8", url: "http://example.com", expected: false},
+	}
+
+	for idx, tc := range testCases {
+		rules, err := parseNoProxy([]string{tc.entry})
+		assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+
+		ps := &proxySelector{httpProxy: &url.URL{Scheme: "http", Host: "proxy:3128"}, httpsProxy: &url.URL{Scheme: "http", Host: "proxy:3128"}, noProxy: rules}
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		assert.NoError(t, err)
+
+		proxyURL, err := ps.proxy(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, proxyURL == nil, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
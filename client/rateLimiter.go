@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket budget for requests
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate at which requests may be sent
+	RequestsPerSecond float64
+	// Burst is the number of requests that may be sent at once after a quiet period
+	Burst int
+}
+
+// validate checks that rl describes a usable budget
+func (rl RateLimit) validate() error {
+	if rl.RequestsPerSecond <= 0 {
+		return newInputError("rate limit requests per second must be positive", nil)
+	}
+
+	if rl.Burst < 1 {
+		return newInputError("rate limit burst must be at least 1", nil)
+	}
+
+	return nil
+}
+
+// WithRateLimit limits the rate of requests sent by the client across all operations.
+// The limit is shared by every goroutine using the client, and callers wait for their turn
+// for as long as their context allows.
+func WithRateLimit(limit RateLimit) Option {
+	return func(cfg *config) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+
+		cfg.rateLimiter().shared = newTokenBucket(limit)
+		return nil
+	}
+}
+
+// WithOperationRateLimit gives operation its own budget, e.g. so that writes can be limited more tightly than reads.
+// Requests for operation must fit within both its own budget and any limit set with WithRateLimit.
+func WithOperationRateLimit(operation Operation, limit RateLimit) Option {
+	return func(cfg *config) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+
+		cfg.rateLimiter().operations[operation] = newTokenBucket(limit)
+		return nil
+	}
+}
+
+// rateLimiter holds the token buckets that requests must take a token from before they are sent
+type rateLimiter struct {
+	shared     *tokenBucket
+	operations map[Operation]*tokenBucket
+	metrics    Metrics
+}
+
+// middleware makes requests wait until every bucket that applies to them has a token to spare
+func (rl *rateLimiter) middleware(next Next) Next {
+	return func(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+		start := time.Now()
+
+		var buckets []*tokenBucket
+		if bucket, ok := rl.operations[req.Operation]; ok {
+			buckets = append(buckets, bucket)
+		}
+
+		if rl.shared != nil {
+			buckets = append(buckets, rl.shared)
+		}
+
+		if err := waitAll(ctx, buckets); err != nil {
+			// the client refused to send the request itself, so it must not count against the API in the circuit breaker
+			if ctx.Err() == nil {
+				return nil, newUnavailableError(fmt.Sprintf("rate limit on %s would not allow the request before its deadline", req.Operation), err)
+			}
+
+			return nil, newInternalError(fmt.Sprintf("gave up waiting for rate limit on %s", req.Operation), err)
+		}
+
+		rl.metrics.ObserveRateLimitWait(string(req.Operation), time.Since(start))
+
+		return next(ctx, req)
+	}
+}
+
+// waitAll takes a token from every bucket, waiting until the last of them is available.
+// If ctx ends first, or would end first, every token is handed back.
+func waitAll(ctx context.Context, buckets []*tokenBucket) error {
+	var wait time.Duration
+	for _, bucket := range buckets {
+		if bucketWait := bucket.reserve(); bucketWait > wait {
+			wait = bucketWait
+		}
+	}
+
+	cancel := func() {
+		for _, bucket := range buckets {
+			bucket.cancel()
+		}
+	}
+
+	if wait == 0 {
+		return nil
+	}
+
+	// fail straight away rather than waiting for a deadline we already know we will miss
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		cancel()
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// tokenBucket is a token bucket that is safe for concurrent use.
+// Tokens may be borrowed against the future, in which case the balance goes negative
+// and the borrower must wait for it to be paid back before using its token.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket for limit
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	return &tokenBucket{
+		rate:   limit.RequestsPerSecond,
+		burst:  float64(limit.Burst),
+		now:    time.Now,
+		tokens: float64(limit.Burst),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before it may be used
+func (tb *tokenBucket) reserve() time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	tb.tokens--
+
+	if tb.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+}
+
+// cancel hands back a token taken by reserve that will not be used
+func (tb *tokenBucket) cancel() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	tb.tokens++
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+}
+
+// refill adds the tokens earned since the bucket was last touched. tb.mu must be held.
+func (tb *tokenBucket) refill() {
+	now := tb.now()
+	if !tb.last.IsZero() {
+		tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+	}
+
+	tb.last = now
+}
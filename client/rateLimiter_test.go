@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTokenBucket(limit RateLimit) (*tokenBucket, *fakeClock) {
+	clock := &fakeClock{now: getDummyTime()}
+	tb := newTokenBucket(limit)
+	tb.now = clock.Now
+
+	return tb, clock
+}
+
+func TestWithRateLimit_invalidLimitReturnsError(t *testing.T) {
+	_, err := NewClient("http://0.0.0.0:8080", nil, WithRateLimit(RateLimit{RequestsPerSecond: 0, Burst: 1}))
+	assert.Equal(t, "input error - rate limit requests per second must be positive", err.Error())
+
+	_, err = NewClient("http://0.0.0.0:8080", nil, WithOperationRateLimit(OperationCreate, RateLimit{RequestsPerSecond: 1}))
+	assert.Equal(t, "input error - rate limit burst must be at least 1", err.Error())
+}
+
+func TestTokenBucket_reserve(t *testing.T) {
+	tb, clock := newTestTokenBucket(RateLimit{RequestsPerSecond: 10, Burst: 2})
+
+	// the burst is available straight away
+	assert.Equal(t, time.Duration(0), tb.reserve())
+	assert.Equal(t, time.Duration(0), tb.reserve())
+
+	// after which callers queue up behind one another
+	assert.Equal(t, 100*time.Millisecond, tb.reserve())
+	assert.Equal(t, 200*time.Millisecond, tb.reserve())
+
+	// cancelled reservations are handed back to the queue
+	tb.cancel()
+	assert.Equal(t, 200*time.Millisecond, tb.reserve())
+
+	// tokens accumulate again over time but never beyond the burst
+	clock.Advance(time.Hour)
+	assert.Equal(t, time.Duration(0), tb.reserve())
+	assert.Equal(t, time.Duration(0), tb.reserve())
+	assert.Equal(t, 100*time.Millisecond, tb.reserve())
+}
+
+func TestWaitAll_failsImmediatelyWhenDeadlineWouldBeMissed(t *testing.T) {
+	tb := newTokenBucket(RateLimit{RequestsPerSecond: 1, Burst: 1})
+	assert.NoError(t, waitAll(context.Background(), []*tokenBucket{tb}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := waitAll(ctx, []*tokenBucket{tb})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	// the token was handed back so the next caller is not pushed further back
+	assert.InDelta(t, time.Second, tb.reserve(), float64(50*time.Millisecond))
+}
+
+func TestWaitAll_stopsWaitingWhenContextIsCancelled(t *testing.T) {
+	tb := newTokenBucket(RateLimit{RequestsPerSecond: 1, Burst: 1})
+	assert.NoError(t, waitAll(context.Background(), []*tokenBucket{tb}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	err := waitAll(ctx, []*tokenBucket{tb})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestWithRateLimit_sharedAcrossGoroutines(t *testing.T) {
+	var requests int32
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requests, 1)
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	metrics := newFakeMetrics()
+	c, err := NewClient(
+		"http://0.0.0.0:8080",
+		mrt,
+		WithRateLimit(RateLimit{RequestsPerSecond: 200, Burst: 10}),
+		WithMetrics(metrics),
+	)
+	assert.NoError(t, err)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0))
+		}()
+	}
+
+	wg.Wait()
+
+	// 10 requests go straight through as the burst, the other 40 take at least 200ms at 200 per second
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+	assert.Equal(t, int32(50), atomic.LoadInt32(&requests))
+	assert.Equal(t, map[string]int{"delete": 50}, metrics.waits)
+}
+
+func TestWithOperationRateLimit_onlyAppliesToItsOperation(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	c, err := NewClient(
+		"http://0.0.0.0:8080",
+		mrt,
+		WithOperationRateLimit(OperationDelete, RateLimit{RequestsPerSecond: 0.001, Burst: 1}),
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, c.Delete(ctx, "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0))
+
+	err = c.Delete(ctx, "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, IsUnavailableError(err))
+	assert.Equal(t, "unavailable error - rate limit on delete would not allow the request before its deadline: context deadline exceeded", err.Error())
+
+	// fetches are not limited by the delete budget
+	mrt.transportFunc = func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("not found")
+	}
+
+	_, err = c.Fetch(ctx, "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.Equal(t, "internal error - failed to send http request: Get \"http://0.0.0.0:8080/v1/organisation/accounts/1dfaf917-c6d6-4e18-b7e7-972e66492976\": not found", err.Error())
+}
+
+func TestWithRateLimit_rejectionsDoNotTripCircuitBreaker(t *testing.T) {
+	var requests int32
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requests, 1)
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	var opened bool
+	c, err := NewClient(
+		"http://0.0.0.0:8080",
+		mrt,
+		WithRateLimit(RateLimit{RequestsPerSecond: 0.001, Burst: 1}),
+		WithCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 2, OnStateChange: func(endpoint string, from, to CircuitState) {
+			opened = opened || to == CircuitOpen
+		}}),
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0))
+
+	// every later request is turned away by the rate limiter before it reaches the API
+	for idx := 0; idx < 5; idx++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		err = c.Delete(ctx, "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+		cancel()
+
+		assert.True(t, IsUnavailableError(err))
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+
+	assert.False(t, opened)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
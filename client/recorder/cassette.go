@@ -0,0 +1,109 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette is the recorded interactions saved to a file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a request and the response it received
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+// Request is a recorded request. The host is not recorded so that a cassette can be replayed against any host.
+type Request struct {
+	Method string      `json:"method" yaml:"method"`
+	Path   string      `json:"path" yaml:"path"`
+	Query  string      `json:"query,omitempty" yaml:"query,omitempty"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	StatusCode int         `json:"status_code" yaml:"status_code"`
+	Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+func (req Request) String() string {
+	if req.Query == "" {
+		return req.Method + " " + req.Path
+	}
+
+	return req.Method + " " + req.Path + "?" + req.Query
+}
+
+// isYAML reports whether the cassette at path is YAML, as opposed to JSON, going by its extension
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// LoadCassette reads the cassette at path. Files ending in .yaml or .yml are YAML, anything else JSON.
+func LoadCassette(path string) (*Cassette, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if isYAML(path) {
+		err = yaml.Unmarshal(raw, &cassette)
+	} else {
+		err = json.Unmarshal(raw, &cassette)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("recorder: invalid cassette %s: %w", path, err)
+	}
+
+	return &cassette, nil
+}
+
+// Save writes the cassette to path, creating any missing directories.
+// Files ending in .yaml or .yml are written as YAML, anything else as JSON.
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	if isYAML(path) {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(c); err != nil {
+			return fmt.Errorf("recorder: failed to encode cassette: %w", err)
+		}
+
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("recorder: failed to encode cassette: %w", err)
+		}
+	} else {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(c); err != nil {
+			return fmt.Errorf("recorder: failed to encode cassette: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("recorder: failed to save cassette: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("recorder: failed to save cassette: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,397 @@
+// Package recorder records the HTTP interactions between a client.Client and the account API to cassette files
+// and replays them, so that code using the client can be tested against realistic responses without running the API.
+//
+// Record a cassette once against a running API:
+//
+//	rec, err := recorder.New("testdata/fetch.yaml", recorder.ModeRecord)
+//	c, err := client.NewClient("http://localhost:8080", rec)
+//	...
+//	err = rec.Stop() // saves the cassette
+//
+// and then replay it in tests with recorder.ModeReplay. Replayed requests are matched on method, path, query
+// and body. A request that matches no recorded interaction fails with a *MismatchError, which Stop also returns
+// so that a test fails even if the code under test swallows the error.
+//
+// Headers that change on every request, such as Date and X-Request-ID, are not recorded and secrets such as
+// Authorization headers are redacted. Cassettes ending in .yaml or .yml are YAML, anything else JSON.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mode is whether a Recorder records or replays
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette without sending any requests
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real API and saves the interactions to the cassette
+	ModeRecord
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// Redacted replaces the value of secrets in cassettes
+const Redacted = "REDACTED"
+
+// volatileHeaders change from run to run so are left out of cassettes
+var volatileHeaders = []string{"Date", "Host", "X-Request-ID", "Traceparent", "Tracestate"}
+
+// secretHeaders have their values redacted by default
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// Option configures a Recorder
+type Option func(*Recorder)
+
+// WithTransport sets the RoundTripper used to send requests in record mode, default http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithRedactedHeaders redacts the values of the named request and response headers, on top of the defaults
+func WithRedactedHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		for _, name := range names {
+			r.redactedHeaders = append(r.redactedHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// WithRedactedQueryParams redacts the values of the named query parameters.
+// A redacted parameter matches any value when replaying.
+func WithRedactedQueryParams(names ...string) Option {
+	return func(r *Recorder) {
+		r.redactedParams = append(r.redactedParams, names...)
+	}
+}
+
+// WithSanitizer sets a function that is called on every interaction before it is saved,
+// e.g. to remove secrets from bodies. It is called after the headers and query have been sanitized.
+func WithSanitizer(sanitize func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.sanitize = sanitize
+	}
+}
+
+// Recorder is an http.RoundTripper that records interactions to, or replays them from, a cassette.
+// It is safe for concurrent use.
+type Recorder struct {
+	path            string
+	mode            Mode
+	transport       http.RoundTripper
+	redactedHeaders []string
+	redactedParams  []string
+	sanitize        func(*Interaction)
+
+	mu         sync.Mutex
+	cassette   *Cassette
+	played     []bool
+	mismatches []error
+}
+
+// New returns a Recorder for the cassette at path. In replay mode the cassette is loaded straight away
+// and must exist, in record mode it is written by Stop.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:            path,
+		mode:            mode,
+		transport:       http.DefaultTransport,
+		redactedHeaders: append([]string(nil), secretHeaders...),
+		cassette:        &Cassette{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	switch mode {
+	case ModeReplay:
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+
+		r.cassette = cassette
+		r.played = make([]bool, len(cassette.Interactions))
+	case ModeRecord:
+		if r.transport == nil {
+			return nil, errors.New("recorder: transport cannot be nil")
+		}
+	default:
+		return nil, fmt.Errorf("recorder: unknown mode %v", mode)
+	}
+
+	return r, nil
+}
+
+// Mode returns the mode of the Recorder
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip records or replays req depending on the mode
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := r.newRequest(req, body)
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: failed to read response body: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.sanitizeHeader(resp.Header),
+			Body:       string(respBody),
+		},
+	}
+
+	if r.sanitize != nil {
+		r.sanitize(&interaction)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay returns the response of the first interaction matching req that has not been played yet
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for idx, interaction := range r.cassette.Interactions {
+		if r.played[idx] || !matches(interaction.Request, recorded) {
+			continue
+		}
+
+		r.played[idx] = true
+
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	merr := &MismatchError{Request: recorded, Cassette: r.path}
+	for idx, interaction := range r.cassette.Interactions {
+		if !r.played[idx] {
+			merr.Unplayed = append(merr.Unplayed, interaction.Request)
+		}
+	}
+
+	r.mismatches = append(r.mismatches, merr)
+	return nil, merr
+}
+
+// Stop finishes recording or replaying. In record mode it saves the cassette.
+// In replay mode it returns an error if any request did not match the cassette.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeRecord {
+		return r.cassette.Save(r.path)
+	}
+
+	return errors.Join(r.mismatches...)
+}
+
+// Unplayed returns the recorded requests that have not been replayed yet
+func (r *Recorder) Unplayed() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unplayed []Request
+	for idx, interaction := range r.cassette.Interactions {
+		if idx < len(r.played) && !r.played[idx] {
+			unplayed = append(unplayed, interaction.Request)
+		}
+	}
+
+	return unplayed
+}
+
+// MismatchError is returned in replay mode for a request that matches no unplayed interaction in the cassette
+type MismatchError struct {
+	Request  Request
+	Cassette string
+	// Unplayed are the requests of the interactions that had not been played when the request was made
+	Unplayed []Request
+}
+
+func (merr *MismatchError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "recorder: no interaction in %s matches %s", merr.Cassette, merr.Request)
+	if merr.Request.Body != "" {
+		fmt.Fprintf(&b, " with body %s", merr.Request.Body)
+	}
+
+	if len(merr.Unplayed) == 0 {
+		b.WriteString(", every interaction has been played")
+		return b.String()
+	}
+
+	b.WriteString(", unplayed interactions are:")
+	for _, req := range merr.Unplayed {
+		fmt.Fprintf(&b, "\n  %s", req)
+		if req.Body != "" {
+			fmt.Fprintf(&b, " with body %s", req.Body)
+		}
+	}
+
+	return b.String()
+}
+
+// readRequestBody reads the body of req and replaces it so that it can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: failed to read request body: %w", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// newRequest returns the sanitized record of req
+func (r *Recorder) newRequest(req *http.Request, body []byte) Request {
+	query := req.URL.Query()
+	for _, name := range r.redactedParams {
+		if _, ok := query[name]; ok {
+			query.Set(name, Redacted)
+		}
+	}
+
+	return Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  query.Encode(),
+		Header: r.sanitizeHeader(req.Header),
+		Body:   string(body),
+	}
+}
+
+// sanitizeHeader returns a copy of header without volatile headers and with secrets redacted
+func (r *Recorder) sanitizeHeader(header http.Header) http.Header {
+	sanitized := header.Clone()
+	for _, name := range volatileHeaders {
+		sanitized.Del(name)
+	}
+
+	for _, name := range r.redactedHeaders {
+		if values := sanitized.Values(name); len(values) > 0 {
+			sanitized.Set(name, Redacted)
+		}
+	}
+
+	if len(sanitized) == 0 {
+		return nil
+	}
+
+	return sanitized
+}
+
+// matches reports whether the live request matches the recorded one on method, path, query and body
+func matches(recorded, live Request) bool {
+	return recorded.Method == live.Method &&
+		recorded.Path == live.Path &&
+		queriesMatch(recorded.Query, live.Query) &&
+		bodiesMatch(recorded.Body, live.Body)
+}
+
+// queriesMatch compares queries ignoring parameter order, a redacted recorded value matches any live value
+func queriesMatch(recorded, live string) bool {
+	recordedValues, err := url.ParseQuery(recorded)
+	if err != nil {
+		return recorded == live
+	}
+
+	liveValues, err := url.ParseQuery(live)
+	if err != nil {
+		return false
+	}
+
+	for name, values := range recordedValues {
+		if len(values) == 1 && values[0] == Redacted {
+			if _, ok := liveValues[name]; !ok {
+				return false
+			}
+
+			liveValues[name] = values
+		}
+	}
+
+	return reflect.DeepEqual(recordedValues, liveValues)
+}
+
+// bodiesMatch compares JSON bodies ignoring formatting and field order, and any other bodies byte for byte
+func bodiesMatch(recorded, live string) bool {
+	if recorded == live {
+		return true
+	}
+
+	var recordedJSON, liveJSON interface{}
+	if json.Unmarshal([]byte(recorded), &recordedJSON) != nil || json.Unmarshal([]byte(live), &liveJSON) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(recordedJSON, liveJSON)
+}
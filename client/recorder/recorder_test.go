@@ -0,0 +1,290 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAccountID = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+
+// newAccountServer returns a server that creates accounts and fetches the last one created
+func newAccountServer() *httptest.Server {
+	var created *accounts.AccountData
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.Header().Set("Set-Cookie", "session=secret")
+
+		switch r.Method {
+		case http.MethodPost:
+			var req accounts.Request
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			version := int64(0)
+			req.Data.Version = &version
+			created = req.Data
+
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(accounts.Response{Data: created})
+		case http.MethodGet:
+			if created == nil || !strings.HasSuffix(r.URL.Path, created.ID) {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(accounts.ApiError{ErrMsg: "not found"})
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(accounts.Response{Data: created})
+		}
+	}))
+}
+
+func testAccount() accounts.AccountData {
+	country := "GB"
+	return accounts.AccountData{
+		ID:             testAccountID,
+		OrganisationID: "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c",
+		Type:           "accounts",
+		Attributes:     &accounts.AccountAttributes{Country: &country, Name: []string{"Samantha Holder"}},
+	}
+}
+
+// exercise runs the same operations against c whether it is recording or replaying
+func exercise(t *testing.T, c *client.Client) {
+	ctx := context.Background()
+
+	_, err := c.Fetch(ctx, testAccountID)
+	assert.Equal(t, http.StatusNotFound, client.StatusCode(err))
+
+	created, err := c.Create(ctx, testAccount())
+	assert.NoError(t, err)
+	assert.Equal(t, testAccountID, created.Data.ID)
+
+	fetched, err := c.Fetch(ctx, testAccountID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Data, fetched.Data)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cassette string
+	}{
+		{name: "yaml cassette", cassette: "fetch.yaml"},
+		{name: "json cassette", cassette: "fetch.json"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassettes", tc.cassette)
+
+			server := newAccountServer()
+			rec, err := New(path, ModeRecord)
+			assert.NoError(t, err)
+
+			c, err := client.NewClient(server.URL, rec)
+			assert.NoError(t, err)
+
+			exercise(t, c)
+			assert.NoError(t, rec.Stop())
+			server.Close()
+
+			cassette, err := LoadCassette(path)
+			assert.NoError(t, err)
+			assert.Len(t, cassette.Interactions, 3)
+
+			post := cassette.Interactions[1]
+			assert.Equal(t, "POST /v1/organisation/accounts", post.Request.String())
+			assert.Contains(t, post.Request.Body, testAccountID)
+			assert.Equal(t, http.StatusCreated, post.Response.StatusCode)
+
+			// volatile headers are dropped and secrets redacted
+			for _, interaction := range cassette.Interactions {
+				assert.Empty(t, interaction.Request.Header.Get("Date"))
+				assert.Empty(t, interaction.Request.Header.Get("X-Request-ID"))
+				assert.Empty(t, interaction.Response.Header.Get("Date"))
+				assert.Equal(t, Redacted, interaction.Response.Header.Get("Set-Cookie"))
+			}
+
+			raw, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(raw), "session=secret")
+
+			// the server is gone so every response must come from the cassette
+			rec, err = New(path, ModeReplay)
+			assert.NoError(t, err)
+
+			c, err = client.NewClient(server.URL, rec)
+			assert.NoError(t, err)
+
+			exercise(t, c)
+			assert.NoError(t, rec.Stop())
+			assert.Empty(t, rec.Unplayed())
+		})
+	}
+}
+
+func TestReplay_mismatchFailsLoudly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := &Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: http.MethodGet, Path: "/v1/organisation/accounts", Query: "page%5Bnumber%5D=0&page%5Bsize%5D=2"},
+		Response: Response{StatusCode: http.StatusOK, Body: `{"data": []}`},
+	}}}
+	assert.NoError(t, cassette.Save(path))
+
+	rec, err := New(path, ModeReplay)
+	assert.NoError(t, err)
+
+	c, err := client.NewClient("http://localhost:8080", rec)
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), testAccountID)
+	var merr *MismatchError
+	assert.True(t, errors.As(err, &merr))
+	assert.Equal(
+		t,
+		"recorder: no interaction in "+path+" matches GET /v1/organisation/accounts/"+testAccountID+", unplayed interactions are:\n"+
+			"  GET /v1/organisation/accounts?page%5Bnumber%5D=0&page%5Bsize%5D=2",
+		merr.Error(),
+	)
+
+	// the query matches whatever order the parameters are in
+	_, err = c.List(context.Background(), client.ListOptions{PageSize: 2})
+	assert.NoError(t, err)
+
+	// an interaction is only played once
+	_, err = c.List(context.Background(), client.ListOptions{PageSize: 2})
+	assert.Contains(t, err.Error(), "every interaction has been played")
+
+	err = rec.Stop()
+	assert.Error(t, err)
+	assert.Equal(t, 2, strings.Count(err.Error(), "recorder: no interaction"))
+}
+
+func TestMatching(t *testing.T) {
+	testCases := []struct {
+		name     string
+		recorded Request
+		live     Request
+		matches  bool
+	}{
+		{
+			name:     "identical",
+			recorded: Request{Method: "GET", Path: "/a", Query: "x=1&y=2"},
+			live:     Request{Method: "GET", Path: "/a", Query: "y=2&x=1"},
+			matches:  true,
+		},
+		{
+			name:     "different method",
+			recorded: Request{Method: "GET", Path: "/a"},
+			live:     Request{Method: "DELETE", Path: "/a"},
+		},
+		{
+			name:     "different path",
+			recorded: Request{Method: "GET", Path: "/a"},
+			live:     Request{Method: "GET", Path: "/b"},
+		},
+		{
+			name:     "different query",
+			recorded: Request{Method: "GET", Path: "/a", Query: "x=1"},
+			live:     Request{Method: "GET", Path: "/a", Query: "x=2"},
+		},
+		{
+			name:     "redacted query param matches any value",
+			recorded: Request{Method: "GET", Path: "/a", Query: "key=" + Redacted},
+			live:     Request{Method: "GET", Path: "/a", Query: "key=abc"},
+			matches:  true,
+		},
+		{
+			name:     "redacted query param must be present",
+			recorded: Request{Method: "GET", Path: "/a", Query: "key=" + Redacted},
+			live:     Request{Method: "GET", Path: "/a"},
+		},
+		{
+			name:     "equivalent JSON bodies",
+			recorded: Request{Method: "POST", Path: "/a", Body: `{"a": 1, "b": [true]}`},
+			live:     Request{Method: "POST", Path: "/a", Body: `{"b":[true],"a":1}`},
+			matches:  true,
+		},
+		{
+			name:     "different JSON bodies",
+			recorded: Request{Method: "POST", Path: "/a", Body: `{"a": 1}`},
+			live:     Request{Method: "POST", Path: "/a", Body: `{"a": 2}`},
+		},
+		{
+			name:     "different plain bodies",
+			recorded: Request{Method: "POST", Path: "/a", Body: "a"},
+			live:     Request{Method: "POST", Path: "/a", Body: "b"},
+		},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.matches, matches(tc.recorded, tc.live))
+		})
+	}
+}
+
+func TestRecord_redactsSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Session-Token", "server-secret")
+		_, _ = w.Write([]byte(`{"token": "body-secret"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	rec, err := New(
+		path,
+		ModeRecord,
+		WithRedactedHeaders("x-session-token"),
+		WithRedactedQueryParams("api_key"),
+		WithSanitizer(func(interaction *Interaction) {
+			interaction.Response.Body = strings.ReplaceAll(interaction.Response.Body, "body-secret", Redacted)
+		}),
+	)
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/a?api_key=key-secret&page=1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer bearer-secret")
+
+	resp, err := rec.RoundTrip(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.NoError(t, rec.Stop())
+
+	raw, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	for _, secret := range []string{"server-secret", "body-secret", "key-secret", "bearer-secret"} {
+		assert.NotContains(t, string(raw), secret)
+	}
+
+	// the redacted api key still matches when replaying
+	rec, err = New(path, ModeReplay)
+	assert.NoError(t, err)
+
+	resp, err = rec.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestNew_invalid(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.yaml"), ModeReplay)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = New("cassette.yaml", ModeRecord, WithTransport(nil))
+	assert.EqualError(t, err, "recorder: transport cannot be nil")
+
+	_, err = New("cassette.yaml", Mode(7))
+	assert.EqualError(t, err, "recorder: unknown mode Mode(7)")
+}
@@ -108,3 +108,33 @@ type errReader int
 func (errReader) Read(p []byte) (n int, err error) {
 	return 0, fmt.Errorf("failed to read")
 }
+
+// IDs of the accounts used in tests against the in-memory API, which only accepts UUIDs
+const (
+	testAccountIDA       = "0b2ae3a4-4e44-4f6f-9a0e-b5a47d83a5c1"
+	testAccountIDB       = "5a5f0d8c-2b4d-4f0a-8f0e-2a8c6e8b7d10"
+	testAccountIDC       = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+	testMissingAccountID = "e3b0c442-98fc-4c14-9afb-f4c8996fb924"
+
+	testOrganisationID = "caca9817-6936-4da4-96e7-9ce93206070f"
+)
+
+// testAccount returns an account with id that the in-memory API accepts
+func testAccount(id string) accounts.AccountData {
+	return accounts.AccountData{
+		ID:             id,
+		OrganisationID: testOrganisationID,
+		Type:           "accounts",
+		Attributes:     &accounts.AccountAttributes{Country: ptrStr("GB"), Name: []string{"Jane Doe"}},
+	}
+}
+
+// accountIDs returns the IDs of accts in order
+func accountIDs(accts []accounts.AccountData) []string {
+	ids := make([]string, 0, len(accts))
+	for _, account := range accts {
+		ids = append(ids, account.ID)
+	}
+
+	return ids
+}
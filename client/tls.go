@@ -0,0 +1,328 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTLSReloadInterval = 30 * time.Second
+
+// ErrPinMismatch is returned, wrapped in a client error, when the API's certificate chain includes none of the pinned keys
+var ErrPinMismatch = errors.New("certificate chain does not match any pinned public key")
+
+// TLSConfig configures how the client secures its connections to the API. Zero values are replaced with defaults.
+type TLSConfig struct {
+	// CertFile and KeyFile are the paths of a PEM encoded client certificate and its key, for mutual TLS
+	CertFile string
+	KeyFile  string
+	// CertPEM and KeyPEM are a PEM encoded client certificate and its key, as an alternative to CertFile and KeyFile
+	CertPEM []byte
+	KeyPEM  []byte
+	// CAFiles are the paths of PEM bundles of the CAs trusted to sign the API's certificate, e.g. a private CA
+	CAFiles []string
+	// CAPEM are PEM bundles of trusted CAs, as an alternative or in addition to CAFiles.
+	// The system's CAs are trusted when there are none.
+	CAPEM [][]byte
+	// MinVersion is the lowest TLS version the client accepts, e.g. tls.VersionTLS13, default TLS 1.2
+	MinVersion uint16
+	// PinnedSPKIHashes are base64 encoded SHA-256 hashes of the public keys, as DER encoded SubjectPublicKeyInfo,
+	// one of which the API's certificate chain must include, e.g. as printed by
+	// openssl x509 -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+	PinnedSPKIHashes []string
+	// ReloadInterval is how often the files are checked for changes while the client is in use, default 30 seconds.
+	// A negative interval turns reloading off.
+	ReloadInterval time.Duration
+}
+
+// WithTLS secures the client's connections to the API with tlsConfig, e.g. to present a client certificate and trust a private CA.
+// The settings are applied to a clone of the transport given to NewClient, which must be nil or an *http.Transport.
+// Certificates and CAs loaded from files are reloaded when the files change, so they can be rotated without
+// restarting; connections made before a change keep the old certificates until they close.
+func WithTLS(tlsConfig TLSConfig) Option {
+	return func(cfg *config) error {
+		if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+			return newInputError("client certificate and key files must be given together", nil)
+		}
+
+		if (len(tlsConfig.CertPEM) == 0) != (len(tlsConfig.KeyPEM) == 0) {
+			return newInputError("client certificate and key PEM must be given together", nil)
+		}
+
+		if tlsConfig.CertFile != "" && len(tlsConfig.CertPEM) > 0 {
+			return newInputError("client certificate cannot be given as both files and PEM", nil)
+		}
+
+		if tlsConfig.MinVersion != 0 && (tlsConfig.MinVersion < tls.VersionTLS10 || tlsConfig.MinVersion > tls.VersionTLS13) {
+			return newInputError(fmt.Sprintf("unknown TLS version %#x", tlsConfig.MinVersion), nil)
+		}
+
+		if tlsConfig.MinVersion == 0 {
+			tlsConfig.MinVersion = tls.VersionTLS12
+		}
+
+		if tlsConfig.ReloadInterval == 0 {
+			tlsConfig.ReloadInterval = defaultTLSReloadInterval
+		}
+
+		var pins [][]byte
+		for _, hash := range tlsConfig.PinnedSPKIHashes {
+			pin, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(hash, "sha256/"))
+			if err != nil || len(pin) != sha256.Size {
+				return newInputError(fmt.Sprintf("invalid SPKI hash %q, expected a base64 encoded SHA-256 hash", hash), err)
+			}
+
+			pins = append(pins, pin)
+		}
+
+		tt := &tlsTransport{cfg: tlsConfig, pins: pins, now: time.Now}
+		material, versions, err := tt.load()
+		if err != nil {
+			return newInputError("failed to load TLS configuration", err)
+		}
+
+		tt.material, tt.versions = material, versions
+		cfg.tls = tt
+		return nil
+	}
+}
+
+// tlsMaterial is what is loaded from the files and PEM of a TLSConfig
+type tlsMaterial struct {
+	certificate *tls.Certificate
+	roots       *x509.CertPool
+}
+
+// fileVersion identifies the contents of a file without reading it
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// tlsTransport sends requests through a clone of the transport given to NewClient configured by TLSConfig.
+// When the files the configuration was loaded from change, it builds a new clone and sends later requests through that.
+type tlsTransport struct {
+	cfg  TLSConfig
+	pins [][]byte
+	now  func() time.Time
+	base *http.Transport
+
+	mu        sync.Mutex
+	current   *http.Transport
+	material  tlsMaterial
+	versions  map[string]fileVersion
+	lastCheck time.Time
+}
+
+// wrap returns the tlsTransport applying its configuration to a clone of transport
+func (tt *tlsTransport) wrap(transport http.RoundTripper) (*tlsTransport, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	base, ok := transport.(*http.Transport)
+	if !ok {
+		return nil, newInputError(fmt.Sprintf("TLS options need an *http.Transport, got %T", transport), nil)
+	}
+
+	tt.base = base
+	tt.current = tt.build(tt.material)
+	tt.lastCheck = tt.now()
+
+	return tt, nil
+}
+
+// RoundTrip sends req through the transport built from the latest configuration
+func (tt *tlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return tt.transport().RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport
+func (tt *tlsTransport) CloseIdleConnections() {
+	tt.transport().CloseIdleConnections()
+}
+
+// transport returns the current transport, first rebuilding it if a reload is due and the files have changed.
+// A failed reload, e.g. because only one of a certificate and its key has been replaced so far, keeps the
+// current transport and is retried at the next check.
+func (tt *tlsTransport) transport() *http.Transport {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	now := tt.now()
+	if tt.cfg.ReloadInterval < 0 || len(tt.versions) == 0 || now.Sub(tt.lastCheck) < tt.cfg.ReloadInterval {
+		return tt.current
+	}
+
+	tt.lastCheck = now
+	if !tt.changed() {
+		return tt.current
+	}
+
+	material, versions, err := tt.load()
+	if err != nil {
+		return tt.current
+	}
+
+	previous := tt.current
+	tt.material, tt.versions = material, versions
+	tt.current = tt.build(material)
+
+	// connections in use finish their requests, idle ones would keep the old certificates
+	go previous.CloseIdleConnections()
+
+	return tt.current
+}
+
+// files returns the paths of the files the configuration is loaded from
+func (tt *tlsTransport) files() []string {
+	var files []string
+	if tt.cfg.CertFile != "" {
+		files = append(files, tt.cfg.CertFile, tt.cfg.KeyFile)
+	}
+
+	return append(files, tt.cfg.CAFiles...)
+}
+
+// changed reports whether any of the files differs from the version last loaded. tt.mu must be held.
+func (tt *tlsTransport) changed() bool {
+	for _, file := range tt.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			// a file being replaced may briefly be missing, wait for it to come back
+			continue
+		}
+
+		if (fileVersion{modTime: info.ModTime(), size: info.Size()}) != tt.versions[file] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// load reads the client certificate and CAs from the configured files and PEM
+func (tt *tlsTransport) load() (tlsMaterial, map[string]fileVersion, error) {
+	var material tlsMaterial
+	versions := map[string]fileVersion{}
+
+	// read records the version of file before reading it, so that a change made while reading is picked up by the next check
+	read := func(file string) ([]byte, error) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+
+		versions[file] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+		return os.ReadFile(file)
+	}
+
+	certPEM, keyPEM := tt.cfg.CertPEM, tt.cfg.KeyPEM
+	if tt.cfg.CertFile != "" {
+		var err error
+		if certPEM, err = read(tt.cfg.CertFile); err != nil {
+			return material, nil, err
+		}
+
+		if keyPEM, err = read(tt.cfg.KeyFile); err != nil {
+			return material, nil, err
+		}
+	}
+
+	if len(certPEM) > 0 {
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return material, nil, fmt.Errorf("client certificate: %w", err)
+		}
+
+		material.certificate = &certificate
+	}
+
+	bundles := append([][]byte(nil), tt.cfg.CAPEM...)
+	for _, file := range tt.cfg.CAFiles {
+		bundle, err := read(file)
+		if err != nil {
+			return material, nil, err
+		}
+
+		bundles = append(bundles, bundle)
+	}
+
+	if len(bundles) > 0 {
+		material.roots = x509.NewCertPool()
+		for _, bundle := range bundles {
+			if !material.roots.AppendCertsFromPEM(bundle) {
+				return material, nil, errors.New("CA bundle contains no certificates")
+			}
+		}
+	}
+
+	return material, versions, nil
+}
+
+// build returns a clone of the base transport configured with material
+func (tt *tlsTransport) build(material tlsMaterial) *http.Transport {
+	transport := tt.base.Clone()
+
+	tlsConfig := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig
+	}
+
+	tlsConfig.MinVersion = tt.cfg.MinVersion
+
+	if material.certificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*material.certificate}
+	}
+
+	if material.roots != nil {
+		tlsConfig.RootCAs = material.roots
+	}
+
+	if len(tt.pins) > 0 {
+		verify := tlsConfig.VerifyConnection
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(cs); err != nil {
+					return err
+				}
+			}
+
+			return tt.verifyPins(cs)
+		}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
+
+// verifyPins returns ErrPinMismatch unless a certificate in the chains presented in cs has a pinned public key
+func (tt *tlsTransport) verifyPins(cs tls.ConnectionState) error {
+	chains := cs.VerifiedChains
+	if len(chains) == 0 {
+		// verification was turned off on the transport given to NewClient
+		chains = [][]*x509.Certificate{cs.PeerCertificates}
+	}
+
+	for _, chain := range chains {
+		for _, cert := range chain {
+			hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range tt.pins {
+				if bytes.Equal(hash[:], pin) {
+					return nil
+				}
+			}
+		}
+	}
+
+	return ErrPinMismatch
+}
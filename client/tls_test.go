@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCert is a certificate and key generated for a test
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func (tc *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	certificate, err := tls.X509KeyPair(tc.certPEM, tc.keyPEM)
+	assert.NoError(t, err)
+
+	return certificate
+}
+
+func (tc *testCert) spkiHash() string {
+	hash := sha256.Sum256(tc.cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// newTestCert returns a certificate for commonName signed by parent, or a self signed CA if parent is nil
+func newTestCert(t *testing.T, commonName string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// mtlsServer is an API answering health checks over TLS that records the common names of the client certificates it sees
+type mtlsServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	commonNames []string
+}
+
+// newMTLSServer starts a server presenting serverCert that requires client certificates signed by clientCA
+func newMTLSServer(t *testing.T, serverCert, clientCA *testCert, maxVersion uint16) *mtlsServer {
+	ms := &mtlsServer{}
+	ms.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms.mu.Lock()
+		ms.commonNames = append(ms.commonNames, r.TLS.PeerCertificates[0].Subject.CommonName)
+		ms.mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"status":"up"}`))
+	}))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+	ms.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.tlsCertificate(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MaxVersion:   maxVersion,
+	}
+
+	ms.StartTLS()
+	t.Cleanup(ms.Close)
+
+	return ms
+}
+
+func (ms *mtlsServer) seen() []string {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return append([]string(nil), ms.commonNames...)
+}
+
+// writeFile writes contents to name in dir, moving its modification time on so that the change is noticed
+func writeFile(t *testing.T, dir, name string, contents []byte, modTime time.Time) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, contents, 0o600))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	return path
+}
+
+func TestWithTLS_invalidConfigReturnsError(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	dir := t.TempDir()
+	garbage := writeFile(t, dir, "garbage.pem", []byte("not a certificate"), time.Now())
+
+	testCases := []struct {
+		name        string
+		cfg         TLSConfig
+		expectedErr string
+	}{
+		{name: "cert file without key", cfg: TLSConfig{CertFile: "client.pem"}, expectedErr: "input error - client certificate and key files must be given together"},
+		{name: "cert PEM without key", cfg: TLSConfig{CertPEM: ca.certPEM}, expectedErr: "input error - client certificate and key PEM must be given together"},
+		{name: "cert as files and PEM", cfg: TLSConfig{CertFile: "c.pem", KeyFile: "k.pem", CertPEM: ca.certPEM, KeyPEM: ca.keyPEM}, expectedErr: "input error - client certificate cannot be given as both files and PEM"},
+		{name: "unknown version", cfg: TLSConfig{MinVersion: 0x0200}, expectedErr: "input error - unknown TLS version 0x200"},
+		{name: "invalid pin", cfg: TLSConfig{PinnedSPKIHashes: []string{"AAAA"}}, expectedErr: `input error - invalid SPKI hash "AAAA", expected a base64 encoded SHA-256 hash`},
+		{name: "missing file", cfg: TLSConfig{CAFiles: []string{filepath.Join(dir, "missing.pem")}}, expectedErr: "input error - failed to load TLS configuration: stat " + filepath.Join(dir, "missing.pem") + ": no such file or directory"},
+		{name: "invalid CA", cfg: TLSConfig{CAFiles: []string{garbage}}, expectedErr: "input error - failed to load TLS configuration: CA bundle contains no certificates"},
+		{name: "key does not match", cfg: TLSConfig{CertPEM: ca.certPEM, KeyPEM: newTestCert(t, "other", nil).keyPEM}, expectedErr: "input error - failed to load TLS configuration: client certificate: tls: private key does not match public key"},
+	}
+
+	for idx, tc := range testCases {
+		_, err := NewClient("https://127.0.0.1:8443", nil, WithTLS(tc.cfg))
+		assert.EqualError(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+
+	_, err := NewClient("https://127.0.0.1:8443", &mockRoundTripper{}, WithTLS(TLSConfig{}))
+	assert.EqualError(t, err, "input error - TLS options need an *http.Transport, got *client.mockRoundTripper")
+}
+
+func TestWithTLS_mutualTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newMTLSServer(t, newTestCert(t, "server", ca), ca, 0)
+	client := newTestCert(t, "client", ca)
+
+	dir := t.TempDir()
+	now := time.Now()
+	files := TLSConfig{
+		CertFile: writeFile(t, dir, "client.pem", client.certPEM, now),
+		KeyFile:  writeFile(t, dir, "client-key.pem", client.keyPEM, now),
+		CAFiles:  []string{writeFile(t, dir, "ca.pem", ca.certPEM, now)},
+	}
+
+	testCases := []struct {
+		name        string
+		cfg         TLSConfig
+		expectedErr string
+	}{
+		{name: "files", cfg: files},
+		{name: "PEM", cfg: TLSConfig{CertPEM: client.certPEM, KeyPEM: client.keyPEM, CAPEM: [][]byte{ca.certPEM}}},
+		{name: "pinned CA", cfg: TLSConfig{CertPEM: client.certPEM, KeyPEM: client.keyPEM, CAPEM: [][]byte{ca.certPEM}, PinnedSPKIHashes: []string{"sha256/" + ca.spkiHash()}}},
+		{name: "pin mismatch", cfg: TLSConfig{CertPEM: client.certPEM, KeyPEM: client.keyPEM, CAPEM: [][]byte{ca.certPEM}, PinnedSPKIHashes: []string{client.spkiHash()}}, expectedErr: ErrPinMismatch.Error()},
+		{name: "untrusted CA", cfg: TLSConfig{CertPEM: client.certPEM, KeyPEM: client.keyPEM}, expectedErr: "certificate signed by unknown authority"},
+		{name: "no client certificate", cfg: TLSConfig{CAPEM: [][]byte{ca.certPEM}}, expectedErr: "certificate required"},
+	}
+
+	for idx, tc := range testCases {
+		c, err := NewClient(server.URL, nil, WithTLS(tc.cfg))
+		assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+
+		health, err := c.Health(context.Background())
+		if tc.expectedErr != "" {
+			assert.ErrorContains(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.True(t, health.Up(), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+
+	assert.Equal(t, []string{"client", "client", "client"}, server.seen())
+}
+
+func TestWithTLS_pinMismatchIsWrapped(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newMTLSServer(t, newTestCert(t, "server", ca), ca, 0)
+	client := newTestCert(t, "client", ca)
+
+	c, err := NewClient(server.URL, nil, WithTLS(TLSConfig{
+		CertPEM: client.certPEM, KeyPEM: client.keyPEM, CAPEM: [][]byte{ca.certPEM}, PinnedSPKIHashes: []string{client.spkiHash()},
+	}))
+	assert.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	assert.ErrorIs(t, err, ErrPinMismatch)
+	assert.True(t, IsInternalError(err))
+}
+
+func TestWithTLS_minVersion(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newMTLSServer(t, newTestCert(t, "server", ca), ca, tls.VersionTLS12)
+	client := newTestCert(t, "client", ca)
+
+	cfg := TLSConfig{CertPEM: client.certPEM, KeyPEM: client.keyPEM, CAPEM: [][]byte{ca.certPEM}}
+	c, err := NewClient(server.URL, nil, WithTLS(cfg))
+	assert.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	assert.NoError(t, err)
+
+	cfg.MinVersion = tls.VersionTLS13
+	c, err = NewClient(server.URL, nil, WithTLS(cfg))
+	assert.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	assert.ErrorContains(t, err, "protocol version not supported")
+}
+
+func TestWithTLS_reloadsRotatedFiles(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newMTLSServer(t, newTestCert(t, "server", ca), ca, 0)
+	before, after := newTestCert(t, "before rotation", ca), newTestCert(t, "after rotation", ca)
+
+	dir := t.TempDir()
+	now := time.Now()
+	cfg := TLSConfig{
+		CertFile:       writeFile(t, dir, "client.pem", before.certPEM, now),
+		KeyFile:        writeFile(t, dir, "client-key.pem", before.keyPEM, now),
+		CAFiles:        []string{writeFile(t, dir, "ca.pem", ca.certPEM, now)},
+		ReloadInterval: time.Minute,
+	}
+
+	c, err := NewClient(server.URL, nil, WithTLS(cfg))
+	assert.NoError(t, err)
+
+	clock := &fakeClock{now: now}
+	tt := c.httpClient.Transport.(*requiredHeadersTransportDecorator).transport.(*tlsTransport)
+	tt.now = clock.Now
+	tt.lastCheck = now
+
+	_, err = c.Health(context.Background())
+	assert.NoError(t, err)
+
+	// halfway through a rotation the new certificate does not match the old key, the old pair keeps being used
+	writeFile(t, dir, "client.pem", after.certPEM, now.Add(time.Second))
+	clock.Advance(time.Minute)
+	_, err = c.Health(context.Background())
+	assert.NoError(t, err)
+
+	// once the key is replaced too the new pair is used, but not before the next check is due
+	writeFile(t, dir, "client-key.pem", after.keyPEM, now.Add(time.Second))
+	_, err = c.Health(context.Background())
+	assert.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	_, err = c.Health(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"before rotation", "before rotation", "before rotation", "after rotation"}, server.seen())
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	traceParentHeader = "traceparent"
+
+	// attribute keys set on every operation span
+	AttributeAccountID        = "account.id"
+	AttributeOrganisationID   = "organisation.id"
+	AttributeHTTPStatusCode   = "http.status_code"
+	AttributeFailoverAttempts = "failover.attempts"
+)
+
+// Tracer starts spans for client operations.
+// It lets tracing be plugged into the client without the client depending on a tracing SDK.
+type Tracer interface {
+	// Start creates a span named name that is a child of any span in ctx and returns a context containing it
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced client operation
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	// SpanContext returns the identifiers propagated to the API in the W3C traceparent header
+	SpanContext() SpanContext
+	End()
+}
+
+// Attribute is a key value pair describing a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// SpanContext holds the identifiers of a span that are propagated across process boundaries
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether sc has both a trace ID and a span ID
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent formats sc as a W3C traceparent header value
+// https://www.w3.org/TR/trace-context/#traceparent-header
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// WithTracer makes the client start a span for every operation using tracer
+// and propagate the trace context to the API
+func WithTracer(tracer Tracer) Option {
+	return func(cfg *config) error {
+		if tracer == nil {
+			return newInputError("tracer cannot be nil", nil)
+		}
+
+		cfg.tracer = tracer
+		return nil
+	}
+}
+
+type spanKey struct{}
+
+// injectTraceParent sets the traceparent header on header if ctx carries a span with a valid span context
+func injectTraceParent(ctx context.Context, header http.Header) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+
+	if sc := span.SpanContext(); sc.IsValid() {
+		header.Set(traceParentHeader, sc.TraceParent())
+	}
+}
+
+// noopTracer is the Tracer used when none has been configured
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) SpanContext() SpanContext { return SpanContext{} }
+
+func (noopSpan) End() {}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSpan records everything done to it so that tests can make assertions about spans
+type fakeSpan struct {
+	mu         sync.Mutex
+	name       string
+	attributes map[string]interface{}
+	errs       []error
+	ended      bool
+	spanCtx    SpanContext
+}
+
+func (fs *fakeSpan) SetAttributes(attrs ...Attribute) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, attr := range attrs {
+		fs.attributes[attr.Key] = attr.Value
+	}
+}
+
+func (fs *fakeSpan) RecordError(err error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.errs = append(fs.errs, err)
+}
+
+func (fs *fakeSpan) SpanContext() SpanContext {
+	return fs.spanCtx
+}
+
+func (fs *fakeSpan) End() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.ended = true
+}
+
+// fakeTracer hands out fakeSpans and keeps hold of them
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (ft *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &fakeSpan{
+		name:       name,
+		attributes: map[string]interface{}{},
+		spanCtx: SpanContext{
+			TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			Sampled: true,
+		},
+	}
+	ft.spans = append(ft.spans, span)
+
+	return ctx, span
+}
+
+func TestWithTracer_nilTracerReturnsError(t *testing.T) {
+	_, err := NewClient("http://0.0.0.0:8080", nil, WithTracer(nil))
+	assert.Equal(t, "input error - tracer cannot be nil", err.Error())
+}
+
+func TestWithTracer_createStartsSpanAndInjectsTraceParent(t *testing.T) {
+	var traceParent string
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			traceParent = req.Header.Get("traceparent")
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data": {"id": "1dfaf917-c6d6-4e18-b7e7-972e66492976"}}`)),
+			}, nil
+		},
+	}
+
+	tracer := &fakeTracer{}
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithTracer(tracer))
+	assert.NoError(t, err)
+
+	_, err = c.Create(context.Background(), accounts.AccountData{
+		ID:             "1dfaf917-c6d6-4e18-b7e7-972e66492976",
+		OrganisationID: "caca9817-6936-4da4-96e7-9ce93206070f",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceParent)
+
+	assert.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "accounts.create", span.name)
+	assert.True(t, span.ended)
+	assert.Empty(t, span.errs)
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			AttributeAccountID:        "1dfaf917-c6d6-4e18-b7e7-972e66492976",
+			AttributeOrganisationID:   "caca9817-6936-4da4-96e7-9ce93206070f",
+			AttributeHTTPStatusCode:   http.StatusCreated,
+			AttributeFailoverAttempts: 0,
+		},
+		span.attributes,
+	)
+}
+
+func TestWithTracer_recordsFailoverAttempts(t *testing.T) {
+	ert := &endpointsRoundTripper{handlers: map[string]func(req *http.Request) (*http.Response, error){
+		"primary:8080":   refuseConnection,
+		"secondary:8080": answerWith(http.StatusOK, fetchedAccountBody),
+	}}
+
+	tracer := &fakeTracer{}
+	c, err := NewClient(primaryURL, ert, WithTracer(tracer), WithEndpoints(EndpointsConfig{
+		Endpoints:           []string{secondaryURL},
+		HealthCheckInterval: -1,
+	}))
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+
+	assert.Len(t, tracer.spans, 1)
+	assert.Equal(t, 1, tracer.spans[0].attributes[AttributeFailoverAttempts])
+}
+
+func TestWithTracer_fetchRecordsOrganisationFromResponse(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(bytes.NewBufferString(
+					`{"data": {"id": "1dfaf917-c6d6-4e18-b7e7-972e66492976", "organisation_id": "caca9817-6936-4da4-96e7-9ce93206070f"}}`,
+				)),
+			}, nil
+		},
+	}
+
+	tracer := &fakeTracer{}
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithTracer(tracer))
+	assert.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976")
+	assert.NoError(t, err)
+
+	assert.Len(t, tracer.spans, 1)
+	assert.Equal(t, "accounts.fetch", tracer.spans[0].name)
+	assert.Equal(t, "caca9817-6936-4da4-96e7-9ce93206070f", tracer.spans[0].attributes[AttributeOrganisationID])
+	assert.Equal(t, http.StatusOK, tracer.spans[0].attributes[AttributeHTTPStatusCode])
+}
+
+func TestWithTracer_deleteRecordsErrors(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("connection refused")
+		},
+	}
+
+	tracer := &fakeTracer{}
+	c, err := NewClient("http://0.0.0.0:8080", mrt, WithTracer(tracer))
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.Error(t, err)
+
+	assert.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "accounts.delete", span.name)
+	assert.True(t, span.ended)
+	assert.Equal(t, []error{err}, span.errs)
+	assert.NotContains(t, span.attributes, AttributeHTTPStatusCode)
+}
+
+func TestSpanContext_TraceParent(t *testing.T) {
+	sc := SpanContext{
+		TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	}
+	assert.True(t, sc.IsValid())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", sc.TraceParent())
+
+	assert.False(t, SpanContext{}.IsValid())
+}
+
+func TestNoTracer_doesNotInjectTraceParent(t *testing.T) {
+	mrt := &mockRoundTripper{
+		transportFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Empty(t, req.Header.Get("traceparent"))
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		},
+	}
+
+	c, err := NewClient("http://0.0.0.0:8080", mrt)
+	assert.NoError(t, err)
+
+	err = c.Delete(context.Background(), "1dfaf917-c6d6-4e18-b7e7-972e66492976", 0)
+	assert.NoError(t, err)
+}
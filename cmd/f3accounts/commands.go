@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// globalFlags are the flags shared by every command that talks to the API
+type globalFlags struct {
+	host    string
+	output  string
+	timeout time.Duration
+}
+
+// newFlagSet returns a flag set for the command name whose usage message describes its positional args
+func newFlagSet(env *environment, name, positional string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "Usage: f3accounts %s [flags] %s\n\nFlags:\n", name, positional)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// addGlobalFlags registers the shared flags on fs, timeout is the default time limit for the command
+func addGlobalFlags(env *environment, fs *flag.FlagSet, timeout time.Duration) *globalFlags {
+	globals := &globalFlags{}
+	fs.StringVar(&globals.host, "host", env.getenv(hostEnvVar), "API host e.g. http://localhost:8080, defaults to $"+hostEnvVar)
+	fs.StringVar(&globals.output, "output", string(outputJSON), "output format: json, table or yaml")
+	fs.DurationVar(&globals.timeout, "timeout", timeout, "time limit for the whole command, 0 for none")
+
+	return globals
+}
+
+// parseArgs parses args with fs, allowing flags to come after positional args, and returns the positional args
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+
+			return nil, &usageError{msg: err.Error(), reported: true}
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// setup checks the global flags and returns a client and a context bounded by the timeout, if there is one
+func (globals *globalFlags) setup(ctx context.Context, env *environment) (*client.Client, outputFormat, context.Context, context.CancelFunc, error) {
+	format, err := parseOutputFormat(globals.output)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	if globals.host == "" {
+		return nil, "", nil, nil, usageErrorf("no API host, set --host or %s", hostEnvVar)
+	}
+
+	if globals.timeout < 0 {
+		return nil, "", nil, nil, usageErrorf("--timeout cannot be negative")
+	}
+
+	c, err := client.NewClient(globals.host, env.transport)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	if globals.timeout == 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return c, format, ctx, cancel, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, globals.timeout)
+	return c, format, ctx, cancel, nil
+}
+
+func runCreate(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "create", "")
+	globals := addGlobalFlags(env, fs, defaultTimeout)
+	input := addAccountFlags(fs)
+
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	account, err := input.account(fs, env.stdin)
+	if err != nil {
+		return err
+	}
+
+	if err := account.Validate(); err != nil {
+		return err
+	}
+
+	c, format, ctx, cancel, err := globals.setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	resp, err := c.Create(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(env.stdout, format, resp.Data)
+}
+
+func runValidate(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "validate", "")
+	input := addAccountFlags(fs)
+
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	account, err := input.account(fs, env.stdin)
+	if err != nil {
+		return err
+	}
+
+	if err := account.Validate(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(env.stdout, "account is valid")
+	return nil
+}
+
+func runFetch(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "fetch", "<account-id>")
+	globals := addGlobalFlags(env, fs, defaultTimeout)
+
+	id, err := parseAccountID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, format, ctx, cancel, err := globals.setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	resp, err := c.Fetch(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(env.stdout, format, resp.Data)
+}
+
+// deleteResult is the output of the delete command
+type deleteResult struct {
+	ID      string `json:"id"`
+	Version uint   `json:"version"`
+}
+
+func runDelete(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "delete", "<account-id>")
+	globals := addGlobalFlags(env, fs, defaultTimeout)
+	version := fs.Int64("version", -1, "version of the account to delete")
+	latest := fs.Bool("latest", false, "look up and delete the current version of the account")
+
+	id, err := parseAccountID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if (*version >= 0) == *latest {
+		return usageErrorf("exactly one of --version or --latest must be given")
+	}
+
+	c, format, ctx, cancel, err := globals.setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	if *latest {
+		resp, err := c.Fetch(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if resp.Data == nil || resp.Data.Version == nil {
+			return fmt.Errorf("account %s has no version", id)
+		}
+
+		*version = *resp.Data.Version
+	}
+
+	if err := c.Delete(ctx, id, uint(*version)); err != nil {
+		return err
+	}
+
+	return writeOutput(env.stdout, format, deleteResult{ID: id, Version: uint(*version)})
+}
+
+func runList(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "list", "")
+	globals := addGlobalFlags(env, fs, defaultTimeout)
+
+	var opts client.ListOptions
+	fs.IntVar(&opts.PageNumber, "page-number", 0, "page of results to return, starting from 0")
+	fs.IntVar(&opts.PageSize, "page-size", 0, "number of accounts per page, defaults to the API's page size")
+	all := fs.Bool("all", false, "return every page of results, ignoring --page-number")
+
+	addFilterFlags(fs, &opts.Filter)
+
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	if opts.PageNumber < 0 || opts.PageSize < 0 {
+		return usageErrorf("--page-number and --page-size cannot be negative")
+	}
+
+	c, format, ctx, cancel, err := globals.setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	listed := []accounts.AccountData{}
+	if *all {
+		err = c.ListAll(ctx, opts, func(account accounts.AccountData) error {
+			listed = append(listed, account)
+			return nil
+		})
+	} else {
+		var page *accounts.ListResponse
+		page, err = c.List(ctx, opts)
+		if page != nil {
+			listed = append(listed, page.Data...)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(env.stdout, format, listed)
+}
+
+// addFilterFlags registers a flag on fs for each field of filter
+func addFilterFlags(fs *flag.FlagSet, filter *client.ListFilter) {
+	fs.StringVar(&filter.BankIDCode, "bank-id-code", "", "only accounts with this bank ID code")
+	fs.StringVar(&filter.BankID, "bank-id", "", "only accounts with this bank ID")
+	fs.StringVar(&filter.AccountNumber, "account-number", "", "only accounts with this account number")
+	fs.StringVar(&filter.Iban, "iban", "", "only accounts with this IBAN")
+	fs.StringVar(&filter.CustomerID, "customer-id", "", "only accounts with this customer ID")
+	fs.StringVar(&filter.Country, "country", "", "only accounts in this country")
+	fs.StringVar(&filter.OrganisationID, "organisation-id", "", "only accounts belonging to this organisation")
+}
+
+// parseNoArgs parses args with fs and rejects any positional args
+func parseNoArgs(fs *flag.FlagSet, args []string) error {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 0 {
+		return usageErrorf("%s takes no arguments, got %q", fs.Name(), positional)
+	}
+
+	return nil
+}
+
+// parseAccountID parses args with fs and returns the single account ID they must contain
+func parseAccountID(fs *flag.FlagSet, args []string) (string, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return "", err
+	}
+
+	if len(positional) != 1 {
+		return "", usageErrorf("%s takes exactly one account ID", fs.Name())
+	}
+
+	return positional[0], nil
+}
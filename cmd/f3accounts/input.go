@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// stringsFlag is a flag that may be given more than once, collecting every value
+type stringsFlag []string
+
+func (sf *stringsFlag) String() string {
+	return strings.Join(*sf, ",")
+}
+
+func (sf *stringsFlag) Set(value string) error {
+	*sf = append(*sf, value)
+	return nil
+}
+
+// accountFlags describe an account on the command line, either in a file, in individual flags or both
+type accountFlags struct {
+	file string
+
+	id                      string
+	organisationID          string
+	country                 string
+	baseCurrency            string
+	bankID                  string
+	bankIDCode              string
+	bic                     string
+	accountNumber           string
+	iban                    string
+	customerID              string
+	classification          string
+	secondaryIdentification string
+	joint                   bool
+	names                   stringsFlag
+	alternativeNames        stringsFlag
+}
+
+// addAccountFlags registers the flags describing an account on fs
+func addAccountFlags(fs *flag.FlagSet) *accountFlags {
+	af := &accountFlags{}
+	fs.StringVar(&af.file, "file", "", "JSON or YAML file holding the account, or - for stdin. Other flags override its fields")
+	fs.StringVar(&af.id, "id", "", "account ID, a random UUID is used if there is none")
+	fs.StringVar(&af.organisationID, "organisation-id", "", "organisation ID")
+	fs.StringVar(&af.country, "country", "", "ISO 3166-1 alpha-2 country code")
+	fs.StringVar(&af.baseCurrency, "base-currency", "", "ISO 4217 currency code")
+	fs.StringVar(&af.bankID, "bank-id", "", "bank ID e.g. sort code")
+	fs.StringVar(&af.bankIDCode, "bank-id-code", "", "bank ID code e.g. GBDSC")
+	fs.StringVar(&af.bic, "bic", "", "SWIFT BIC")
+	fs.StringVar(&af.accountNumber, "account-number", "", "account number")
+	fs.StringVar(&af.iban, "iban", "", "IBAN")
+	fs.StringVar(&af.customerID, "customer-id", "", "customer ID")
+	fs.StringVar(&af.classification, "classification", "", "account classification: Personal or Business")
+	fs.StringVar(&af.secondaryIdentification, "secondary-identification", "", "secondary identification e.g. building society roll number")
+	fs.BoolVar(&af.joint, "joint", false, "the account is held jointly")
+	fs.Var(&af.names, "name", "account holder name, repeat for up to 4 lines")
+	fs.Var(&af.alternativeNames, "alternative-name", "alternative account holder name, repeat for up to 3 names")
+
+	return af
+}
+
+// account builds the account described by the file and the flags that were set on fs
+func (af *accountFlags) account(fs *flag.FlagSet, stdin io.Reader) (accounts.AccountData, error) {
+	var account accounts.AccountData
+	if af.file != "" {
+		var err error
+		if account, err = readAccountFile(af.file, stdin); err != nil {
+			return accounts.AccountData{}, err
+		}
+	}
+
+	if account.Attributes == nil {
+		account.Attributes = &accounts.AccountAttributes{}
+	}
+
+	var err error
+	attrs := account.Attributes
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "id":
+			account.ID = af.id
+		case "organisation-id":
+			account.OrganisationID = af.organisationID
+		case "country":
+			attrs.Country = &af.country
+		case "base-currency":
+			attrs.BaseCurrency = af.baseCurrency
+		case "bank-id":
+			attrs.BankID = af.bankID
+		case "bank-id-code":
+			attrs.BankIDCode = af.bankIDCode
+		case "bic":
+			attrs.Bic = af.bic
+		case "account-number":
+			attrs.AccountNumber = af.accountNumber
+		case "iban":
+			attrs.Iban = af.iban
+		case "customer-id":
+			attrs.CustomerID = af.customerID
+		case "secondary-identification":
+			attrs.SecondaryIdentification = af.secondaryIdentification
+		case "joint":
+			attrs.JointAccount = &af.joint
+		case "name":
+			attrs.Name = af.names
+		case "alternative-name":
+			attrs.AlternativeNames = af.alternativeNames
+		case "classification":
+			var classification accounts.AccountClassification
+			if classification, err = accounts.NewAccountClassification(af.classification); err != nil {
+				err = usageErrorf("--classification: %v", err)
+			}
+
+			attrs.AccountClassification = classification
+		}
+	})
+
+	if err != nil {
+		return accounts.AccountData{}, err
+	}
+
+	if account.ID == "" {
+		account.ID = uuid.NewString()
+	}
+
+	if account.Type == "" {
+		account.Type = "accounts"
+	}
+
+	return account, nil
+}
+
+// readAccountFile reads an account from path, or from stdin if path is "-".
+// The file may hold the account itself or a request body with the account under "data",
+// in JSON or YAML, with fields named as in the API.
+func readAccountFile(path string, stdin io.Reader) (accounts.AccountData, error) {
+	var raw []byte
+	var err error
+	if path == "-" {
+		raw, err = io.ReadAll(stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return accounts.AccountData{}, usageErrorf("failed to read account file: %v", err)
+	}
+
+	account, err := decodeAccount(raw)
+	if err != nil {
+		return accounts.AccountData{}, usageErrorf("failed to decode account file %s: %v", path, err)
+	}
+
+	return account, nil
+}
+
+// decodeAccount decodes an account from JSON or YAML. YAML is a superset of JSON so both are read as YAML
+// and converted to JSON so that the field names and formats match the API exactly.
+func decodeAccount(raw []byte) (accounts.AccountData, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return accounts.AccountData{}, err
+	}
+
+	fields, ok := doc.(map[string]interface{})
+	if !ok {
+		return accounts.AccountData{}, fmt.Errorf("expected an object")
+	}
+
+	if data, ok := fields["data"]; ok && len(fields) == 1 {
+		doc = data
+	}
+
+	asJSON, err := json.Marshal(doc)
+	if err != nil {
+		return accounts.AccountData{}, err
+	}
+
+	var account accounts.AccountData
+	dec := json.NewDecoder(bytes.NewReader(asJSON))
+	// unknown fields are most likely typos that would otherwise be silently dropped
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&account); err != nil {
+		return accounts.AccountData{}, err
+	}
+
+	return account, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeAccount(t *testing.T) {
+	country := "GB"
+	expected := accounts.AccountData{
+		ID:         testAccountID,
+		Attributes: &accounts.AccountAttributes{Country: &country, Name: []string{"A"}, AccountClassification: accounts.AccountClassificationBusiness},
+	}
+
+	testCases := []struct {
+		name     string
+		raw      string
+		expected accounts.AccountData
+		errMsg   string
+	}{
+		{
+			name:     "json account",
+			raw:      `{"id": "` + testAccountID + `", "attributes": {"country": "GB", "name": ["A"], "account_classification": "Business"}}`,
+			expected: expected,
+		},
+		{
+			name:     "json request body",
+			raw:      `{"data": {"id": "` + testAccountID + `", "attributes": {"country": "GB", "name": ["A"], "account_classification": "Business"}}}`,
+			expected: expected,
+		},
+		{
+			name:     "yaml account",
+			raw:      "id: " + testAccountID + "\nattributes:\n  country: GB\n  name:\n    - A\n  account_classification: Business\n",
+			expected: expected,
+		},
+		{
+			name:   "not an object",
+			raw:    "[1, 2]",
+			errMsg: "expected an object",
+		},
+		{
+			name:   "unknown field",
+			raw:    `{"idd": "1"}`,
+			errMsg: `json: unknown field "idd"`,
+		},
+		{
+			name:   "bad enum",
+			raw:    `{"attributes": {"account_classification": "Corporate"}}`,
+			errMsg: "invalid account classification: Corporate",
+		},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			account, err := decodeAccount([]byte(tc.raw))
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, account)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Command f3accounts creates, fetches, deletes, lists, validates, exports and imports accounts from the terminal.
+//
+// Usage:
+//
+//	f3accounts <command> [flags] [args]
+//
+// Run f3accounts help for the list of commands and exit codes.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client"
+)
+
+// exit codes, one per kind of failure so that scripts can react to them
+const (
+	exitOK          = 0
+	exitInternal    = 1
+	exitUsage       = 2
+	exitInvalid     = 3
+	exitAPI         = 4
+	exitNotFound    = 5
+	exitConflict    = 6
+	exitUnavailable = 7
+)
+
+// hostEnvVar names the environment variable holding the default API host
+const hostEnvVar = "F3_ACCOUNTS_HOST"
+
+// environment is what commands use to talk to the outside world, it is swapped out in tests
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(key string) string
+	// transport is the RoundTripper used by the client, nil means http.DefaultTransport
+	transport http.RoundTripper
+}
+
+// command is a subcommand of f3accounts
+type command struct {
+	summary string
+	run     func(ctx context.Context, env *environment, args []string) error
+}
+
+var commands = map[string]command{
+	"create":   {summary: "create an account from flags or a JSON/YAML file", run: runCreate},
+	"fetch":    {summary: "fetch an account by ID", run: runFetch},
+	"delete":   {summary: "delete an account by ID", run: runDelete},
+	"export":   {summary: "write accounts to a newline delimited JSON file", run: runExport},
+	"import":   {summary: "create the accounts in a newline delimited JSON file, resuming where an earlier run stopped", run: runImport},
+	"list":     {summary: "list accounts, optionally filtered", run: runList},
+	"validate": {summary: "check an account locally without sending it to the API", run: runValidate},
+}
+
+// usageError is returned for mistakes in how f3accounts was invoked
+type usageError struct {
+	msg string
+	// reported is set when the flag package has already printed the error along with the usage message
+	reported bool
+}
+
+func (uerr *usageError) Error() string {
+	return uerr.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env := &environment{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	code := run(ctx, env, os.Args[1:])
+
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command named by args[0] and returns the process exit code
+func run(ctx context.Context, env *environment, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(env.stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+
+		return exitOK
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(env.stderr, "f3accounts: unknown command %q\n\n", args[0])
+		printUsage(env.stderr)
+		return exitUsage
+	}
+
+	err := cmd.run(ctx, env, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	var uerr *usageError
+	if err != nil && !(errors.As(err, &uerr) && uerr.reported) {
+		reportError(env.stderr, err)
+	}
+
+	return exitCode(err)
+}
+
+// exitCode maps err to the exit code for its kind
+func exitCode(err error) int {
+	var uerr *usageError
+	var verr *accounts.ValidationError
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &uerr), client.IsInputError(err):
+		return exitUsage
+	case errors.As(err, &verr):
+		return exitInvalid
+	case client.StatusCode(err) == http.StatusNotFound:
+		return exitNotFound
+	case client.StatusCode(err) == http.StatusConflict:
+		return exitConflict
+	case client.IsAPIError(err):
+		return exitAPI
+	case client.IsUnavailableError(err), unreachable(err):
+		return exitUnavailable
+	default:
+		return exitInternal
+	}
+}
+
+// unreachable reports whether err is a failure to get a response from the API, e.g. because the connection was refused.
+// An interrupted request is not counted as the API being unreachable.
+func unreachable(err error) bool {
+	var urlErr *url.Error
+	var netErr net.Error
+	return (errors.As(err, &urlErr) || errors.As(err, &netErr)) && !errors.Is(err, context.Canceled)
+}
+
+// reportError writes err to w, listing each problem of a validation error on its own line
+func reportError(w io.Writer, err error) {
+	var verr *accounts.ValidationError
+	if !errors.As(err, &verr) {
+		fmt.Fprintf(w, "f3accounts: %v\n", err)
+		return
+	}
+
+	fmt.Fprintln(w, "f3accounts: invalid account:")
+	for _, ferr := range verr.Fields {
+		fmt.Fprintf(w, "  - %v\n", ferr)
+	}
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Usage: f3accounts <command> [flags] [args]\n\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-10s %s\n", name, commands[name].summary)
+	}
+
+	fmt.Fprintf(&b, "\nThe API host is set with --host or the %s environment variable.\n", hostEnvVar)
+	b.WriteString("Run f3accounts <command> -h for the flags of a command.\n\n")
+	b.WriteString("Exit codes:\n")
+	fmt.Fprintf(&b, "  %d  success\n", exitOK)
+	fmt.Fprintf(&b, "  %d  internal or network error\n", exitInternal)
+	fmt.Fprintf(&b, "  %d  invalid usage or input\n", exitUsage)
+	fmt.Fprintf(&b, "  %d  account failed validation\n", exitInvalid)
+	fmt.Fprintf(&b, "  %d  API error\n", exitAPI)
+	fmt.Fprintf(&b, "  %d  account not found\n", exitNotFound)
+	fmt.Fprintf(&b, "  %d  conflict e.g. duplicate account or stale version\n", exitConflict)
+	fmt.Fprintf(&b, "  %d  API unavailable or unreachable\n", exitUnavailable)
+
+	fmt.Fprint(w, b.String())
+}
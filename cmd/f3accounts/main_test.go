@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAccountID = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+	testOrgID     = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+)
+
+func testAccount(id, country string, version int64) accounts.AccountData {
+	return accounts.AccountData{
+		ID:             id,
+		OrganisationID: testOrgID,
+		Type:           "accounts",
+		Version:        &version,
+		Attributes: &accounts.AccountAttributes{
+			Country:    &country,
+			BankID:     "400300",
+			BankIDCode: "GBDSC",
+			Bic:        "NWBKGB22",
+			Name:       []string{"Samantha Holder"},
+		},
+	}
+}
+
+// runCLI runs f3accounts with args against host and returns the exit code, stdout and stderr
+func runCLI(host, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	env := &environment{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string {
+			if key == hostEnvVar {
+				return host
+			}
+
+			return ""
+		},
+	}
+
+	code := run(context.Background(), env, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_usage(t *testing.T) {
+	code, _, stderr := runCLI("", "")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "Usage: f3accounts <command>")
+
+	code, _, _ = runCLI("", "", "help")
+	assert.Equal(t, exitOK, code)
+
+	code, _, stderr = runCLI("", "", "frobnicate")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown command "frobnicate"`)
+
+	code, _, stderr = runCLI("", "", "fetch", "-h")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "Usage: f3accounts fetch [flags] <account-id>")
+}
+
+func TestRun_fetch(t *testing.T) {
+	server := httptest.NewServer(fakeapi.NewServer(testAccount(testAccountID, "GB", 2)))
+	defer server.Close()
+
+	code, stdout, stderr := runCLI(server.URL, "", "fetch", testAccountID)
+	assert.Equal(t, exitOK, code, stderr)
+
+	var fetched accounts.AccountData
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &fetched))
+	assert.Equal(t, testAccount(testAccountID, "GB", 2), fetched)
+
+	// flags may come after the account ID
+	code, stdout, _ = runCLI("", "", "fetch", testAccountID, "--host", server.URL, "--output", "table")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(
+		t,
+		"ID                                    ORGANISATION ID                       COUNTRY  BANK ID CODE  BANK ID  BIC       ACCOUNT NUMBER  IBAN  STATUS  VERSION\n"+
+			"ad27e265-9605-4b4b-a0e5-3003ea9cc4dc  eb0bd6f5-c3f5-44b2-b677-acd23cdde73c  GB       GBDSC         400300   NWBKGB22                                2\n",
+		stdout,
+	)
+}
+
+func TestRun_exitCodes(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountID, "GB", 2))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	testCases := []struct {
+		name         string
+		host         string
+		args         []string
+		apiStatus    int
+		expectedCode int
+	}{
+		{name: "not found", host: server.URL, args: []string{"fetch", "5a5f0d8c-2b4d-4f0a-8f0e-2a8c6e8b7d10"}, expectedCode: exitNotFound},
+		{name: "stale version", host: server.URL, args: []string{"delete", testAccountID, "--version", "1"}, expectedCode: exitConflict},
+		{name: "api error", host: server.URL, args: []string{"fetch", testAccountID}, apiStatus: 400, expectedCode: exitAPI},
+		{name: "no host", host: "", args: []string{"fetch", testAccountID}, expectedCode: exitUsage},
+		{name: "invalid host", host: "not a host", args: []string{"fetch", testAccountID}, expectedCode: exitUsage},
+		{name: "bad output format", host: server.URL, args: []string{"fetch", testAccountID, "--output", "xml"}, expectedCode: exitUsage},
+		{name: "unknown flag", host: server.URL, args: []string{"fetch", "--frobnicate", testAccountID}, expectedCode: exitUsage},
+		{name: "missing id", host: server.URL, args: []string{"fetch"}, expectedCode: exitUsage},
+		{name: "unreachable", host: "http://127.0.0.1:1", args: []string{"fetch", testAccountID}, expectedCode: exitUnavailable},
+		{name: "invalid account", host: server.URL, args: []string{"create", "--country", "gb"}, expectedCode: exitInvalid},
+		{name: "both delete versions", host: server.URL, args: []string{"delete", testAccountID, "--version", "2", "--latest"}, expectedCode: exitUsage},
+		{name: "neither delete version", host: server.URL, args: []string{"delete", testAccountID}, expectedCode: exitUsage},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			api.FailWith("", tc.apiStatus)
+
+			code, _, stderr := runCLI(tc.host, "", tc.args...)
+			assert.Equal(t, tc.expectedCode, code, stderr)
+			assert.NotEmpty(t, stderr)
+		})
+	}
+}
+
+func TestRun_closedPortIsUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	host := "http://" + listener.Addr().String()
+	assert.NoError(t, listener.Close())
+
+	code, _, stderr := runCLI(host, "", "fetch", testAccountID)
+	assert.Equal(t, exitUnavailable, code, stderr)
+	assert.Contains(t, stderr, "connection refused")
+}
+
+func TestRun_createFromFlagsAndFile(t *testing.T) {
+	api := fakeapi.NewServer()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	code, stdout, stderr := runCLI(
+		server.URL, "",
+		"create", "--id", testAccountID, "--organisation-id", testOrgID, "--country", "GB",
+		"--name", "Samantha", "--name", "Holder", "--bic", "NWBKGB22", "--classification", "Business", "--output", "yaml",
+	)
+	assert.Equal(t, exitOK, code, stderr)
+
+	// the API sets the creation and modification times
+	var created []string
+	for _, line := range strings.SplitAfter(stdout, "\n") {
+		if !strings.HasPrefix(line, "created_on: ") && !strings.HasPrefix(line, "modified_on: ") {
+			created = append(created, line)
+		}
+	}
+
+	assert.Contains(t, stdout, "\ncreated_on: ")
+	assert.Equal(
+		t,
+		"attributes:\n"+
+			"  account_classification: Business\n"+
+			"  bic: NWBKGB22\n"+
+			"  country: GB\n"+
+			"  name:\n"+
+			"    - Samantha\n"+
+			"    - Holder\n"+
+			"id: ad27e265-9605-4b4b-a0e5-3003ea9cc4dc\n"+
+			"organisation_id: eb0bd6f5-c3f5-44b2-b677-acd23cdde73c\n"+
+			"type: accounts\n"+
+			"version: 0\n",
+		strings.Join(created, ""),
+	)
+
+	// a YAML request body with a flag overriding one of its fields
+	dir := t.TempDir()
+	path := filepath.Join(dir, "account.yaml")
+	body := "data:\n" +
+		"  id: 0b2ae3a4-4e44-4f6f-9a0e-b5a47d83a5c1\n" +
+		"  organisation_id: " + testOrgID + "\n" +
+		"  attributes:\n" +
+		"    country: FR\n" +
+		"    name: [Jean Dupont]\n"
+	assert.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+
+	code, _, stderr = runCLI(server.URL, "", "create", "--file", path, "--country", "GB")
+	assert.Equal(t, exitOK, code, stderr)
+	createdFromFile, ok := api.Account("0b2ae3a4-4e44-4f6f-9a0e-b5a47d83a5c1")
+	assert.True(t, ok)
+	assert.Equal(t, "GB", *createdFromFile.Attributes.Country)
+
+	// creating the same account again conflicts
+	code, _, _ = runCLI(server.URL, "", "create", "--file", path)
+	assert.Equal(t, exitConflict, code)
+
+	// JSON from stdin
+	code, _, stderr = runCLI(
+		server.URL,
+		`{"id": "5a5f0d8c-2b4d-4f0a-8f0e-2a8c6e8b7d10", "organisation_id": "`+testOrgID+`", "attributes": {"country": "GB", "name": ["A"]}}`,
+		"create", "--file", "-",
+	)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Len(t, api.Accounts(), 3)
+}
+
+func TestRun_delete(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountID, "GB", 3), testAccount(testOrgID, "GB", 0))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	code, stdout, stderr := runCLI(server.URL, "", "delete", testAccountID, "--latest")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.JSONEq(t, `{"id": "`+testAccountID+`", "version": 3}`, stdout)
+
+	code, stdout, stderr = runCLI(server.URL, "", "delete", "--version", "0", "--output", "table", testOrgID)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "ID                                    VERSION\n"+testOrgID+"  0\n", stdout)
+
+	assert.Empty(t, api.Accounts())
+
+	code, _, _ = runCLI(server.URL, "", "delete", testAccountID, "--latest")
+	assert.Equal(t, exitNotFound, code)
+}
+
+func TestRun_list(t *testing.T) {
+	api := fakeapi.NewServer(testAccount(testAccountID, "GB", 0), testAccount(testOrgID, "FR", 0))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	code, stdout, stderr := runCLI(server.URL, "", "list", "--country", "FR")
+	assert.Equal(t, exitOK, code, stderr)
+
+	var listed []accounts.AccountData
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &listed))
+	assert.Equal(t, []accounts.AccountData{testAccount(testOrgID, "FR", 0)}, listed)
+
+	code, stdout, stderr = runCLI(server.URL, "", "list", "--all", "--country", "DE")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "[]\n", stdout)
+
+	code, _, _ = runCLI(server.URL, "", "list", "--page-size", "-1")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI(server.URL, "", "list", "extra")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestRun_validate(t *testing.T) {
+	code, stdout, _ := runCLI("", "", "validate", "--organisation-id", testOrgID, "--country", "GB", "--name", "A")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "account is valid\n", stdout)
+
+	code, _, stderr := runCLI("", `{"id": "1", "attributes": {"country": "GB", "name": ["A"]}}`, "validate", "--file", "-")
+	assert.Equal(t, exitInvalid, code)
+	assert.Equal(t, "f3accounts: invalid account:\n  - id must be a UUID\n  - organisation_id must be a UUID\n", stderr)
+
+	code, _, stderr = runCLI("", `{"id": "1", "atributes": {}}`, "validate", "--file", "-")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown field "atributes"`)
+
+	code, _, stderr = runCLI("", "", "validate", "--classification", "Corporate")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "--classification: invalid account classification: Corporate")
+}
+
+func TestRun_exportImport(t *testing.T) {
+	source := httptest.NewServer(fakeapi.NewServer(testAccount(testAccountID, "GB", 2), testAccount(testOrgID, "FR", 0)))
+	defer source.Close()
+
+	code, stdout, stderr := runCLI(source.URL, "", "export", "--country", "GB")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, 1, strings.Count(stdout, "\n"))
+	assert.Equal(t, "exported 1 accounts\n", stderr)
+
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "accounts.ndjson")
+	code, stdout, stderr = runCLI(source.URL, "", "export", "--file", dump)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.JSONEq(t, `{"file": "`+dump+`", "exported": 2}`, stdout)
+
+	target := fakeapi.NewServer(testAccount(testOrgID, "FR", 0))
+	server := httptest.NewServer(target)
+	defer server.Close()
+
+	checkpoint := filepath.Join(dir, "import.checkpoint")
+	code, stdout, stderr = runCLI(server.URL, "", "import", "--file", dump, "--checkpoint", checkpoint, "--output", "table")
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "IMPORTED  ALREADY EXISTED  RESUMED  FAILED\n1         1                0        0\n", stdout)
+	assert.Len(t, target.Accounts(), 2)
+
+	// read only fields are not sent
+	imported, ok := target.Account(testAccountID)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), *imported.Version)
+
+	// running again with the checkpoint skips everything
+	code, stdout, stderr = runCLI(server.URL, "", "import", "--file", dump, "--checkpoint", checkpoint)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.JSONEq(t, `{"imported": 0, "already_existed": 0, "resumed": 2, "failed": []}`, stdout)
+}
+
+func TestRun_importFailures(t *testing.T) {
+	server := httptest.NewServer(fakeapi.NewServer())
+	defer server.Close()
+
+	account := testAccount(testAccountID, "GB", 0)
+	account.Version = nil
+	line, err := json.Marshal(account)
+	assert.NoError(t, err)
+
+	input := string(line) + "\n" + "not json\n"
+	code, stdout, stderr := runCLI(server.URL, input, "import")
+	assert.Equal(t, exitInternal, code)
+	assert.Equal(t, "f3accounts: 1 accounts could not be imported\n", stderr)
+
+	var result importResult
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, 1, result.Imported)
+	assert.Len(t, result.Failed, 1)
+	assert.Equal(t, 2, result.Failed[0].Line)
+
+	code, _, _ = runCLI(server.URL, "", "import", "--batch-size", "0")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI(server.URL, "", "import", "--file", filepath.Join(t.TempDir(), "missing.ndjson"))
+	assert.Equal(t, exitUsage, code)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/OJOMB/form3-fake-account-client/client"
+)
+
+// exportResult is the output of the export command
+type exportResult struct {
+	File     string `json:"file"`
+	Exported int    `json:"exported"`
+}
+
+// importResult is the output of the import command
+type importResult struct {
+	Imported       int             `json:"imported"`
+	AlreadyExisted int             `json:"already_existed"`
+	Resumed        int             `json:"resumed"`
+	Failed         []importFailure `json:"failed"`
+}
+
+type importFailure struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+func newImportResult(summary *client.ImportSummary) importResult {
+	result := importResult{
+		Imported:       summary.Imported,
+		AlreadyExisted: summary.AlreadyExisted,
+		Resumed:        summary.Resumed,
+		Failed:         []importFailure{},
+	}
+
+	for _, failure := range summary.Failed {
+		result.Failed = append(result.Failed, importFailure{Line: failure.Line, ID: failure.ID, Error: failure.Err.Error()})
+	}
+
+	return result
+}
+
+func runExport(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "export", "")
+	globals := addGlobalFlags(env, fs, 0)
+	file := fs.String("file", "-", `file to write the accounts to, "-" for stdout`)
+
+	var filter client.ListFilter
+	addFilterFlags(fs, &filter)
+
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	c, format, ctx, cancel, err := globals.setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	if *file == "-" {
+		exported, err := c.Export(ctx, env.stdout, filter)
+		if err != nil {
+			return err
+		}
+
+		// stdout holds the accounts so the result goes to stderr
+		fmt.Fprintf(env.stderr, "exported %d accounts\n", exported)
+		return nil
+	}
+
+	f, err := os.Create(*file)
+	if err != nil {
+		return usageErrorf("cannot create %s: %v", *file, err)
+	}
+
+	exported, err := c.Export(ctx, f, filter)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write %s: %w", *file, cerr)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(env.stdout, format, exportResult{File: *file, Exported: exported})
+}
+
+func runImport(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet(env, "import", "")
+	globals := addGlobalFlags(env, fs, 0)
+	file := fs.String("file", "-", `file to read the accounts from, "-" for stdin`)
+
+	var opts client.ImportOptions
+	fs.StringVar(&opts.CheckpointPath, "checkpoint", "", "file recording progress, an interrupted import run again with the same checkpoint carries on where it stopped")
+	fs.IntVar(&opts.Concurrency, "concurrency", 10, "number of accounts created at once")
+	fs.IntVar(&opts.BatchSize, "batch-size", 500, "number of accounts created between checkpoints")
+
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	if opts.Concurrency <= 0 || opts.BatchSize <= 0 {
+		return usageErrorf("--concurrency and --batch-size must be positive")
+	}
+
+	c, format, ctx, cancel, err := globals.setup(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	var r io.Reader = env.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return usageErrorf("cannot open %s: %v", *file, err)
+		}
+
+		defer f.Close()
+		r = f
+	}
+
+	summary, err := c.Import(ctx, r, opts)
+	if summary == nil {
+		return err
+	}
+
+	// the summary is written even if the import was interrupted so that progress is not lost
+	if werr := writeOutput(env.stdout, format, newImportResult(summary)); err == nil {
+		err = werr
+	}
+
+	if err == nil && len(summary.Failed) > 0 {
+		err = fmt.Errorf("%d accounts could not be imported", len(summary.Failed))
+	}
+
+	return err
+}
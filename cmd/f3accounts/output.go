@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormat is how command results are written to stdout
+type outputFormat string
+
+const (
+	outputJSON  outputFormat = "json"
+	outputTable outputFormat = "table"
+	outputYAML  outputFormat = "yaml"
+)
+
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch format := outputFormat(strings.ToLower(s)); format {
+	case outputJSON, outputTable, outputYAML:
+		return format, nil
+	default:
+		return "", usageErrorf("unknown output format %q, expected json, table or yaml", s)
+	}
+}
+
+// accountColumns are the headings of the table output for accounts
+var accountColumns = []string{"ID", "ORGANISATION ID", "COUNTRY", "BANK ID CODE", "BANK ID", "BIC", "ACCOUNT NUMBER", "IBAN", "STATUS", "VERSION"}
+
+// writeOutput writes v to w in format. v is an account, a list of accounts or the result of a command.
+func writeOutput(w io.Writer, format outputFormat, v interface{}) error {
+	switch format {
+	case outputTable:
+		return writeTable(w, v)
+	case outputYAML:
+		return writeYAML(w, v)
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+}
+
+// writeYAML writes v to w as YAML with the same field names and order as its JSON encoding
+func writeYAML(w io.Writer, v interface{}) error {
+	asJSON, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML so decoding it into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(asJSON, &node); err != nil {
+		return err
+	}
+
+	useBlockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// useBlockStyle clears the flow style and quoting that node picked up from being parsed as JSON
+func useBlockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		useBlockStyle(child)
+	}
+}
+
+// writeTable writes v to w as an aligned table
+func writeTable(w io.Writer, v interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	switch v := v.(type) {
+	case *accounts.AccountData:
+		writeRow(tw, accountColumns)
+		if v != nil {
+			writeRow(tw, accountRow(*v))
+		}
+	case []accounts.AccountData:
+		writeRow(tw, accountColumns)
+		for _, account := range v {
+			writeRow(tw, accountRow(account))
+		}
+	case deleteResult:
+		writeRow(tw, []string{"ID", "VERSION"})
+		writeRow(tw, []string{v.ID, fmt.Sprint(v.Version)})
+	case exportResult:
+		writeRow(tw, []string{"FILE", "EXPORTED"})
+		writeRow(tw, []string{v.File, fmt.Sprint(v.Exported)})
+	case importResult:
+		writeRow(tw, []string{"IMPORTED", "ALREADY EXISTED", "RESUMED", "FAILED"})
+		writeRow(tw, []string{fmt.Sprint(v.Imported), fmt.Sprint(v.AlreadyExisted), fmt.Sprint(v.Resumed), fmt.Sprint(len(v.Failed))})
+		if len(v.Failed) > 0 {
+			writeRow(tw, nil)
+			writeRow(tw, []string{"LINE", "ID", "ERROR"})
+			for _, failure := range v.Failed {
+				writeRow(tw, []string{fmt.Sprint(failure.Line), failure.ID, failure.Error})
+			}
+		}
+	default:
+		return fmt.Errorf("cannot write %T as a table", v)
+	}
+
+	return tw.Flush()
+}
+
+func writeRow(w io.Writer, cells []string) {
+	fmt.Fprintln(w, strings.Join(cells, "\t"))
+}
+
+// accountRow returns the cells of account under accountColumns
+func accountRow(account accounts.AccountData) []string {
+	var attrs accounts.AccountAttributes
+	if account.Attributes != nil {
+		attrs = *account.Attributes
+	}
+
+	var country, status, version string
+	if attrs.Country != nil {
+		country = *attrs.Country
+	}
+
+	if attrs.Status != nil {
+		status = attrs.Status.String()
+	}
+
+	if account.Version != nil {
+		version = fmt.Sprint(*account.Version)
+	}
+
+	return []string{
+		account.ID,
+		account.OrganisationID,
+		country,
+		attrs.BankIDCode,
+		attrs.BankID,
+		attrs.Bic,
+		attrs.AccountNumber,
+		attrs.Iban,
+		status,
+		version,
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected outputFormat
+		errMsg   string
+	}{
+		{input: "json", expected: outputJSON},
+		{input: "TABLE", expected: outputTable},
+		{input: "yaml", expected: outputYAML},
+		{input: "xml", errMsg: `unknown output format "xml", expected json, table or yaml`},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.input), func(t *testing.T) {
+			format, err := parseOutputFormat(tc.input)
+			if tc.errMsg != "" {
+				assert.EqualError(t, err, tc.errMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, format)
+		})
+	}
+}
+
+func TestWriteOutput_list(t *testing.T) {
+	status := accounts.AccountStatusPending
+	account := testAccount(testAccountID, "GB", 1)
+	account.Attributes.Status = &status
+	listed := []accounts.AccountData{account}
+
+	var yamlOut bytes.Buffer
+	assert.NoError(t, writeOutput(&yamlOut, outputYAML, listed))
+	assert.Equal(
+		t,
+		"- attributes:\n"+
+			"    bank_id: \"400300\"\n"+
+			"    bank_id_code: GBDSC\n"+
+			"    bic: NWBKGB22\n"+
+			"    country: GB\n"+
+			"    name:\n"+
+			"      - Samantha Holder\n"+
+			"    status: pending\n"+
+			"  id: ad27e265-9605-4b4b-a0e5-3003ea9cc4dc\n"+
+			"  organisation_id: eb0bd6f5-c3f5-44b2-b677-acd23cdde73c\n"+
+			"  type: accounts\n"+
+			"  version: 1\n",
+		yamlOut.String(),
+	)
+
+	var tableOut bytes.Buffer
+	assert.NoError(t, writeOutput(&tableOut, outputTable, []accounts.AccountData{}))
+	assert.Equal(t, "ID  ORGANISATION ID  COUNTRY  BANK ID CODE  BANK ID  BIC  ACCOUNT NUMBER  IBAN  STATUS  VERSION\n", tableOut.String())
+
+	tableOut.Reset()
+	assert.NoError(t, writeOutput(&tableOut, outputTable, listed))
+	assert.Contains(t, tableOut.String(), "pending  1\n")
+
+	assert.EqualError(t, writeOutput(&tableOut, outputTable, 1), "cannot write int as a table")
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/accounts/fixtures"
+	"github.com/OJOMB/form3-fake-account-client/client"
+)
+
+// operation is a kind of request sent during a load test
+type operation string
+
+const (
+	opCreate operation = "create"
+	opFetch  operation = "fetch"
+	opDelete operation = "delete"
+)
+
+// operations are the operations in the order they are reported
+var operations = []operation{opCreate, opFetch, opDelete}
+
+// mix is how often each operation is picked, by weight
+type mix map[operation]int
+
+// parseMix parses a mix written as op=weight pairs separated by commas, e.g. create=1,fetch=3,delete=1
+func parseMix(s string) (mix, error) {
+	m := mix{}
+	for _, pair := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, usageErrorf("invalid --mix entry %q, expected op=weight", pair)
+		}
+
+		op := operation(name)
+		if op != opCreate && op != opFetch && op != opDelete {
+			return nil, usageErrorf("unknown operation %q in --mix, expected create, fetch or delete", name)
+		}
+
+		n, err := strconv.Atoi(weight)
+		if err != nil || n < 0 {
+			return nil, usageErrorf("invalid weight %q for %s in --mix, expected a whole number", weight, name)
+		}
+
+		m[op] += n
+	}
+
+	total := 0
+	for _, weight := range m {
+		total += weight
+	}
+
+	if total == 0 {
+		return nil, usageErrorf("--mix must give at least one operation a positive weight")
+	}
+
+	return m, nil
+}
+
+// pick returns an operation chosen at random in proportion to the weights
+func (m mix) pick(r *rand.Rand) operation {
+	total := 0
+	for _, op := range operations {
+		total += m[op]
+	}
+
+	n := r.Intn(total)
+	for _, op := range operations {
+		if n < m[op] {
+			return op
+		}
+
+		n -= m[op]
+	}
+
+	return opCreate
+}
+
+// loadConfig describes a load test
+type loadConfig struct {
+	duration    time.Duration
+	concurrency int
+	// rps is the target rate of requests per second, 0 sends requests as fast as the workers can
+	rps            float64
+	mix            mix
+	seed           int64
+	requestTimeout time.Duration
+	// country is the country of created accounts, empty for any supported country
+	country string
+	factory *fixtures.Factory
+}
+
+// createdAccount is an account created during a load test that fetches and deletes can use
+type createdAccount struct {
+	id      string
+	version uint
+}
+
+// accountPool holds the accounts created during a load test that have not been deleted
+type accountPool struct {
+	mu       sync.Mutex
+	accounts []createdAccount
+}
+
+func (pool *accountPool) add(account createdAccount) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.accounts = append(pool.accounts, account)
+}
+
+// take removes an account picked at random from the pool and returns it, false if the pool is empty
+func (pool *accountPool) take(r *rand.Rand) (createdAccount, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if len(pool.accounts) == 0 {
+		return createdAccount{}, false
+	}
+
+	idx := r.Intn(len(pool.accounts))
+	account := pool.accounts[idx]
+	pool.accounts[idx] = pool.accounts[len(pool.accounts)-1]
+	pool.accounts = pool.accounts[:len(pool.accounts)-1]
+	return account, true
+}
+
+// ids returns the IDs of the accounts in the pool
+func (pool *accountPool) ids() []string {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	ids := make([]string, 0, len(pool.accounts))
+	for _, account := range pool.accounts {
+		ids = append(ids, account.id)
+	}
+
+	return ids
+}
+
+// loadTest sends a mix of requests to an API until its duration is up
+type loadTest struct {
+	api  client.AccountsAPI
+	cfg  loadConfig
+	pool *accountPool
+	// unconfirmed holds accounts whose create failed in a way that may have created them anyway.
+	// They are only deleted when cleaning up, so that fetches and deletes only use accounts known to exist.
+	unconfirmed *accountPool
+	results     *results
+	// dropped counts the requests that were due at the target rate while every worker was busy
+	dropped atomic.Int64
+}
+
+func newLoadTest(api client.AccountsAPI, cfg loadConfig) *loadTest {
+	return &loadTest{api: api, cfg: cfg, pool: &accountPool{}, unconfirmed: &accountPool{}, results: newResults()}
+}
+
+// run sends requests until the duration is up or ctx ends and returns the report
+func (lt *loadTest) run(ctx context.Context) *report {
+	ctx, cancel := context.WithTimeout(ctx, lt.cfg.duration)
+	defer cancel()
+
+	// with a target rate, workers send a request for each tick, otherwise they send requests back to back
+	var ticks chan struct{}
+	if lt.cfg.rps > 0 {
+		ticks = make(chan struct{})
+	}
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < lt.cfg.concurrency; worker++ {
+		wg.Add(1)
+		go func(r *rand.Rand) {
+			defer wg.Done()
+			lt.work(ctx, r, ticks)
+		}(rand.New(rand.NewSource(lt.cfg.seed + int64(worker))))
+	}
+
+	if ticks != nil {
+		go lt.pace(ctx, ticks)
+	}
+
+	wg.Wait()
+
+	return lt.results.report(time.Since(start), lt.dropped.Load())
+}
+
+// pace sends a tick to ticks at the target rate until ctx ends, dropping ticks that no worker is free to take
+func (lt *loadTest) pace(ctx context.Context, ticks chan<- struct{}) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / lt.cfg.rps))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			select {
+			case ticks <- struct{}{}:
+			case <-ctx.Done():
+				return
+			default:
+				lt.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// work sends requests until ctx ends, waiting for a tick before each one if ticks is not nil
+func (lt *loadTest) work(ctx context.Context, r *rand.Rand, ticks <-chan struct{}) {
+	for {
+		if ticks != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+
+		lt.send(ctx, r, lt.cfg.mix.pick(r))
+	}
+}
+
+// send sends a single request for op and records the outcome.
+// Fetches and deletes need an account to work on, so they are sent as creates until one has been created.
+func (lt *loadTest) send(ctx context.Context, r *rand.Rand, op operation) {
+	// accounts are taken out of the pool while they are worked on so that a fetch never races a delete
+	var account createdAccount
+	var ok bool
+	if op != opCreate {
+		account, ok = lt.pool.take(r)
+	}
+
+	if op != opCreate && !ok {
+		op = opCreate
+	}
+
+	var newAccount accounts.AccountData
+	if op == opCreate {
+		var err error
+		if newAccount, err = lt.newAccount(); err != nil {
+			lt.results.record(op, 0, err)
+			return
+		}
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, lt.cfg.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch op {
+	case opCreate:
+		var resp *accounts.Response
+		resp, err = lt.api.Create(reqCtx, newAccount)
+		created := createdAccount{id: newAccount.ID}
+		if err == nil && resp != nil && resp.Data != nil && resp.Data.Version != nil {
+			created.version = uint(*resp.Data.Version)
+		}
+
+		switch {
+		case err == nil:
+			lt.pool.add(created)
+		case mayHaveCreated(err):
+			lt.unconfirmed.add(created)
+		}
+	case opFetch:
+		_, err = lt.api.Fetch(reqCtx, account.id)
+		lt.pool.add(account)
+	case opDelete:
+		// the account is only known to be gone once the API has deleted it or says it is missing,
+		// otherwise it is kept for later requests and cleaning up
+		if err = lt.api.Delete(reqCtx, account.id, account.version); err != nil && client.StatusCode(err) != http.StatusNotFound {
+			lt.pool.add(account)
+		}
+	}
+
+	// requests that failed because the test ended are neither successes nor failures
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	lt.results.record(op, time.Since(start), err)
+}
+
+// mayHaveCreated reports whether an account may exist after its create failed with err, e.g. because the request
+// timed out after reaching the API. Only invalid requests and client errors other than a conflict rule that out.
+func mayHaveCreated(err error) bool {
+	if client.IsInputError(err) {
+		return false
+	}
+
+	status := client.StatusCode(err)
+	return status == 0 || status == http.StatusConflict || status >= http.StatusInternalServerError
+}
+
+// newAccount returns the next account to create
+func (lt *loadTest) newAccount() (accounts.AccountData, error) {
+	if lt.cfg.country == "" {
+		return lt.cfg.factory.AnyAccount(), nil
+	}
+
+	return lt.cfg.factory.Account(lt.cfg.country)
+}
+
+// cleanup deletes the accounts created during the test that are still there, and any that may have been
+func (lt *loadTest) cleanup(ctx context.Context) (int, error) {
+	ids := append(lt.pool.ids(), lt.unconfirmed.ids()...)
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	summary, err := lt.api.DeleteMany(ctx, client.DeleteManyRequest{IDs: ids}, client.BulkOptions{Concurrency: lt.cfg.concurrency})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(summary.Failed) > 0 {
+		return len(summary.Deleted), fmt.Errorf("%d accounts could not be deleted, first error: %w", len(summary.Failed), summary.Failed[0].Err)
+	}
+
+	return len(summary.Deleted), nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/accounts/fixtures"
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig(m mix) loadConfig {
+	return loadConfig{
+		duration:       200 * time.Millisecond,
+		concurrency:    4,
+		mix:            m,
+		seed:           1,
+		requestTimeout: time.Second,
+		factory:        fixtures.New(1),
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mix         string
+		expected    mix
+		expectedErr string
+	}{
+		{name: "every operation", mix: "create=1,fetch=3,delete=1", expected: mix{opCreate: 1, opFetch: 3, opDelete: 1}},
+		{name: "spaces and repeats", mix: "create=1, create=2", expected: mix{opCreate: 3}},
+		{name: "zero weight", mix: "create=1,delete=0", expected: mix{opCreate: 1, opDelete: 0}},
+		{name: "missing weight", mix: "create", expectedErr: `invalid --mix entry "create", expected op=weight`},
+		{name: "unknown operation", mix: "list=1", expectedErr: `unknown operation "list" in --mix, expected create, fetch or delete`},
+		{name: "negative weight", mix: "fetch=-1", expectedErr: `invalid weight "-1" for fetch in --mix, expected a whole number`},
+		{name: "all zero", mix: "fetch=0", expectedErr: "--mix must give at least one operation a positive weight"},
+	}
+
+	for idx, tc := range testCases {
+		m, err := parseMix(tc.mix)
+		if tc.expectedErr != "" {
+			assert.EqualError(t, err, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.Equal(t, tc.expected, m, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
+
+func TestMix_pick(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := mix{opCreate: 1, opFetch: 3}
+
+	counts := map[operation]int{}
+	for idx := 0; idx < 4000; idx++ {
+		counts[m.pick(r)]++
+	}
+
+	assert.Zero(t, counts[opDelete])
+	assert.InDelta(t, 1000, counts[opCreate], 150)
+	assert.InDelta(t, 3000, counts[opFetch], 150)
+}
+
+func TestRunLoadTest_mixAndCleanup(t *testing.T) {
+	fake := clienttest.NewFake()
+
+	rep, err := runLoadTest(context.Background(), fake, testConfig(mix{opCreate: 2, opFetch: 2, opDelete: 1}), true)
+	assert.NoError(t, err)
+
+	assert.Greater(t, rep.Requests, 0)
+	assert.Zero(t, rep.Errors)
+	assert.Len(t, rep.Operations, 3)
+	// requests cut short by the end of the test are not reported, there is at most one per worker
+	assert.InDelta(t, len(fake.Calls(clienttest.MethodCreate)), rep.Operations[0].Requests, 4)
+	assert.InDelta(t, len(fake.Calls(clienttest.MethodFetch)), rep.Operations[1].Requests, 4)
+	assert.InDelta(t, len(fake.Calls(clienttest.MethodDelete)), rep.Operations[2].Requests, 4)
+
+	// every account created and not deleted during the test is cleaned up
+	assert.Greater(t, rep.CleanedUp, 0)
+	assert.Empty(t, fake.Accounts())
+}
+
+func TestRunLoadTest_noCleanup(t *testing.T) {
+	fake := clienttest.NewFake()
+
+	rep, err := runLoadTest(context.Background(), fake, testConfig(mix{opCreate: 1}), false)
+	assert.NoError(t, err)
+
+	assert.Zero(t, rep.CleanedUp)
+	assert.Len(t, fake.Accounts(), rep.Requests)
+	fake.AssertNotCalled(t, clienttest.MethodDeleteMany)
+}
+
+func TestRunLoadTest_errorsByKind(t *testing.T) {
+	fake := clienttest.NewFake()
+	fake.FailAlways(clienttest.MethodFetch, client.NewAPIError(http.StatusInternalServerError, "boom"))
+	fake.FailNext(clienttest.MethodCreate, client.NewUnavailableError("circuit open", nil), 3)
+
+	rep, err := runLoadTest(context.Background(), fake, testConfig(mix{opCreate: 1, opFetch: 1}), true)
+	assert.NoError(t, err)
+
+	fetches := rep.Operations[1].Requests
+	assert.Greater(t, fetches, 0)
+	assert.Equal(t, map[string]int{"api_500": fetches, "unavailable": 3}, rep.ErrorsByKind)
+	assert.Equal(t, fetches+3, rep.Errors)
+	assert.InDelta(t, float64(fetches+3)/float64(rep.Requests), rep.ErrorRate, 1e-9)
+}
+
+func TestRunLoadTest_targetRate(t *testing.T) {
+	fake := clienttest.NewFake()
+	cfg := testConfig(mix{opCreate: 1, opFetch: 1})
+	cfg.rps = 50
+	cfg.duration = 500 * time.Millisecond
+
+	rep, err := runLoadTest(context.Background(), fake, cfg, true)
+	assert.NoError(t, err)
+
+	// 50 requests a second for half a second, give or take scheduling
+	assert.InDelta(t, 25, rep.Requests, 5)
+	assert.Zero(t, rep.Dropped)
+}
+
+func TestRunLoadTest_requestTimeout(t *testing.T) {
+	fake := clienttest.NewFake()
+	fake.FetchFunc = func(ctx context.Context, _ string) (*accounts.Response, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	cfg := testConfig(mix{opCreate: 1, opFetch: 1})
+	cfg.requestTimeout = 20 * time.Millisecond
+
+	rep, err := runLoadTest(context.Background(), fake, cfg, true)
+	assert.NoError(t, err)
+
+	assert.Greater(t, rep.ErrorsByKind["timeout"], 0)
+	assert.Equal(t, rep.ErrorsByKind["timeout"], rep.Operations[1].Errors)
+	assert.GreaterOrEqual(t, rep.Operations[1].Latency.P50, 20.0)
+}
+
+func TestRunLoadTest_cleansUpAccountsWhoseOutcomeIsUnknown(t *testing.T) {
+	fake := clienttest.NewFake()
+	// creates reach the API but time out before it answers
+	fake.CreateFunc = func(ctx context.Context, account accounts.AccountData) (*accounts.Response, error) {
+		fake.Put(account)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	cfg := testConfig(mix{opCreate: 1})
+	cfg.requestTimeout = 20 * time.Millisecond
+
+	rep, err := runLoadTest(context.Background(), fake, cfg, true)
+	assert.NoError(t, err)
+
+	assert.Greater(t, rep.ErrorsByKind["timeout"], 0)
+	assert.Greater(t, rep.CleanedUp, 0)
+	assert.Empty(t, fake.Accounts())
+}
+
+func TestRunLoadTest_neverFetchesOrDeletesUnconfirmedAccounts(t *testing.T) {
+	fake := clienttest.NewFake()
+	// creates fail without creating anything, though the client cannot tell
+	fake.FailAlways(clienttest.MethodCreate, client.NewAPIError(http.StatusServiceUnavailable, "unavailable"))
+
+	rep, err := runLoadTest(context.Background(), fake, testConfig(mix{opCreate: 1, opFetch: 1, opDelete: 1}), true)
+	assert.NoError(t, err)
+
+	// with no account known to exist every request is sent as a create
+	assert.Equal(t, rep.Requests, rep.Operations[0].Requests)
+	assert.Equal(t, map[string]int{"api_503": rep.Requests}, rep.ErrorsByKind)
+	fake.AssertNotCalled(t, clienttest.MethodFetch)
+	fake.AssertNotCalled(t, clienttest.MethodDelete)
+
+	// the accounts are still cleaned up in case any of the creates went through
+	fake.AssertNumberOfCalls(t, clienttest.MethodDeleteMany, 1)
+	assert.Zero(t, rep.CleanedUp)
+}
+
+func TestMayHaveCreated(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "timeout", err: context.DeadlineExceeded, expected: true},
+		{name: "unavailable", err: client.NewUnavailableError("circuit open", nil), expected: true},
+		{name: "server error", err: client.NewAPIError(http.StatusBadGateway, "bad gateway"), expected: true},
+		{name: "conflict", err: client.NewAPIError(http.StatusConflict, "duplicate"), expected: true},
+		{name: "bad request", err: client.NewAPIError(http.StatusBadRequest, "invalid"), expected: false},
+		{name: "rate limited", err: client.NewAPIError(http.StatusTooManyRequests, "slow down"), expected: false},
+		{name: "invalid input", err: client.NewInputError("invalid account", nil), expected: false},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d: %s", idx+1, tc.name), func(t *testing.T) {
+			assert.Equal(t, tc.expected, mayHaveCreated(tc.err))
+		})
+	}
+}
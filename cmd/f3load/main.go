@@ -0,0 +1,238 @@
+// Command f3load puts the account API under load with a mix of creates, fetches and deletes,
+// then reports throughput, latency percentiles and errors by kind as text or JSON.
+//
+// Usage:
+//
+//	f3load [flags]
+//
+// For example, 50 requests a second for a minute against the docker-compose API, mostly fetches:
+//
+//	f3load --host http://localhost:8080 --duration 1m --rps 50 --mix create=1,fetch=8,delete=1
+//
+// Accounts are generated with the fixtures package and any left over when the test ends are deleted.
+// The exit code is 1 if the error rate is above --max-error-rate, so that f3load can gate a release.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/accounts/fixtures"
+	"github.com/OJOMB/form3-fake-account-client/client"
+)
+
+// exit codes
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+// hostEnvVar names the environment variable holding the default API host, shared with f3accounts
+const hostEnvVar = "F3_ACCOUNTS_HOST"
+
+// cleanupTimeout is how long deleting the accounts left over after a test may take
+const cleanupTimeout = time.Minute
+
+// environment is what f3load uses to talk to the outside world, it is swapped out in tests
+type environment struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(key string) string
+	// transport is the RoundTripper used by the client, nil means http.DefaultTransport
+	transport http.RoundTripper
+}
+
+// usageError is returned for mistakes in how f3load was invoked
+type usageError struct {
+	msg string
+}
+
+func (uerr *usageError) Error() string {
+	return uerr.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env := &environment{stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	code := run(ctx, env, os.Args[1:])
+
+	stop()
+	os.Exit(code)
+}
+
+// flags are the command line flags of f3load
+type flags struct {
+	host           string
+	duration       time.Duration
+	concurrency    int
+	rps            float64
+	mix            string
+	country        string
+	seed           int64
+	requestTimeout time.Duration
+	output         string
+	cleanup        bool
+	maxErrorRate   float64
+}
+
+// run runs a load test configured by args and returns the process exit code
+func run(ctx context.Context, env *environment, args []string) int {
+	fs := flag.NewFlagSet("f3load", flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprint(env.stderr, "Usage: f3load [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	f := flags{}
+	fs.StringVar(&f.host, "host", env.getenv(hostEnvVar), "API host e.g. http://localhost:8080, defaults to $"+hostEnvVar)
+	fs.DurationVar(&f.duration, "duration", 30*time.Second, "how long to send requests for")
+	fs.IntVar(&f.concurrency, "concurrency", 10, "number of requests in flight at once")
+	fs.Float64Var(&f.rps, "rps", 0, "target requests per second, 0 to send as fast as --concurrency allows")
+	fs.StringVar(&f.mix, "mix", "create=1,fetch=3,delete=1", "relative weights of the operations sent")
+	fs.StringVar(&f.country, "country", "", "country of created accounts, one of "+strings.Join(fixtures.Countries(), ", ")+", default any")
+	fs.Int64Var(&f.seed, "seed", 0, "seed for generated accounts and the operation mix, default the current time")
+	fs.DurationVar(&f.requestTimeout, "request-timeout", 10*time.Second, "time limit for each request")
+	fs.StringVar(&f.output, "output", "text", "report format: text or json")
+	fs.BoolVar(&f.cleanup, "cleanup", true, "delete the accounts left over when the test ends")
+	fs.Float64Var(&f.maxErrorRate, "max-error-rate", 1, "fail if more than this fraction of requests fail, e.g. 0.01")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		return exitUsage
+	}
+
+	if fs.NArg() > 0 {
+		fmt.Fprintf(env.stderr, "f3load: unexpected argument %q\n", fs.Arg(0))
+		return exitUsage
+	}
+
+	cfg, err := f.loadConfig()
+	if err != nil {
+		fmt.Fprintf(env.stderr, "f3load: %v\n", err)
+		return exitUsage
+	}
+
+	c, err := client.NewClient(f.host, env.transport)
+	if err != nil {
+		fmt.Fprintf(env.stderr, "f3load: %v\n", err)
+		return exitUsage
+	}
+
+	rep, cleanupErr := runLoadTest(ctx, c, cfg, f.cleanup)
+
+	if f.output == "json" {
+		err = writeJSON(env.stdout, rep)
+	} else {
+		err = writeText(env.stdout, rep)
+	}
+
+	if err != nil {
+		fmt.Fprintf(env.stderr, "f3load: writing report: %v\n", err)
+		return exitFailed
+	}
+
+	if cleanupErr != nil {
+		fmt.Fprintf(env.stderr, "f3load: cleaning up: %v\n", cleanupErr)
+		return exitFailed
+	}
+
+	if rep.ErrorRate > f.maxErrorRate {
+		fmt.Fprintf(env.stderr, "f3load: error rate %.2f%% is above the maximum of %.2f%%\n", rep.ErrorRate*100, f.maxErrorRate*100)
+		return exitFailed
+	}
+
+	return exitOK
+}
+
+// loadConfig checks the flags and returns the load test they describe
+func (f flags) loadConfig() (loadConfig, error) {
+	if f.host == "" {
+		return loadConfig{}, usageErrorf("no API host, set --host or %s", hostEnvVar)
+	}
+
+	if f.duration <= 0 {
+		return loadConfig{}, usageErrorf("--duration must be positive")
+	}
+
+	if f.concurrency < 1 {
+		return loadConfig{}, usageErrorf("--concurrency must be at least 1")
+	}
+
+	if f.rps < 0 {
+		return loadConfig{}, usageErrorf("--rps cannot be negative")
+	}
+
+	if f.requestTimeout <= 0 {
+		return loadConfig{}, usageErrorf("--request-timeout must be positive")
+	}
+
+	if f.output != "text" && f.output != "json" {
+		return loadConfig{}, usageErrorf("unknown output format %q, expected text or json", f.output)
+	}
+
+	if f.maxErrorRate < 0 || f.maxErrorRate > 1 {
+		return loadConfig{}, usageErrorf("--max-error-rate must be between 0 and 1")
+	}
+
+	if f.country != "" && !slices.Contains(fixtures.Countries(), f.country) {
+		return loadConfig{}, usageErrorf("unsupported country %q, expected one of %s", f.country, strings.Join(fixtures.Countries(), ", "))
+	}
+
+	m, err := parseMix(f.mix)
+	if err != nil {
+		return loadConfig{}, err
+	}
+
+	seed := f.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return loadConfig{
+		duration:       f.duration,
+		concurrency:    f.concurrency,
+		rps:            f.rps,
+		mix:            m,
+		seed:           seed,
+		requestTimeout: f.requestTimeout,
+		country:        f.country,
+		factory:        fixtures.New(seed),
+	}, nil
+}
+
+// runLoadTest runs the load test described by cfg against api and, if cleanup is set,
+// deletes the accounts it leaves behind even if ctx has ended
+func runLoadTest(ctx context.Context, api client.AccountsAPI, cfg loadConfig, cleanup bool) (*report, error) {
+	lt := newLoadTest(api, cfg)
+	rep := lt.run(ctx)
+	if !cleanup {
+		return rep, nil
+	}
+
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	deleted, err := lt.cleanup(cleanupCtx)
+	rep.CleanedUp = deleted
+	return rep, err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/client/clienttest/fakeapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// runLoad runs f3load with args against host and returns the exit code, stdout and stderr
+func runLoad(host string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	env := &environment{
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string {
+			if key == hostEnvVar {
+				return host
+			}
+
+			return ""
+		},
+	}
+
+	code := run(context.Background(), env, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_usage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		host        string
+		args        []string
+		expectedErr string
+	}{
+		{name: "no host", args: nil, expectedErr: "no API host, set --host or F3_ACCOUNTS_HOST"},
+		{name: "unexpected argument", host: "http://localhost", args: []string{"create"}, expectedErr: `unexpected argument "create"`},
+		{name: "zero duration", host: "http://localhost", args: []string{"--duration", "0s"}, expectedErr: "--duration must be positive"},
+		{name: "no workers", host: "http://localhost", args: []string{"--concurrency", "0"}, expectedErr: "--concurrency must be at least 1"},
+		{name: "negative rate", host: "http://localhost", args: []string{"--rps", "-1"}, expectedErr: "--rps cannot be negative"},
+		{name: "bad output", host: "http://localhost", args: []string{"--output", "yaml"}, expectedErr: `unknown output format "yaml", expected text or json`},
+		{name: "bad error rate", host: "http://localhost", args: []string{"--max-error-rate", "2"}, expectedErr: "--max-error-rate must be between 0 and 1"},
+		{name: "unsupported country", host: "http://localhost", args: []string{"--country", "ZZ"}, expectedErr: `unsupported country "ZZ"`},
+		{name: "bad mix", host: "http://localhost", args: []string{"--mix", "list=1"}, expectedErr: `unknown operation "list" in --mix`},
+		{name: "unknown flag", host: "http://localhost", args: []string{"--nope"}, expectedErr: "flag provided but not defined: -nope"},
+	}
+
+	for idx, tc := range testCases {
+		code, stdout, stderr := runLoad(tc.host, tc.args...)
+		assert.Equal(t, exitUsage, code, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.Empty(t, stdout, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+		assert.Contains(t, stderr, tc.expectedErr, fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
+
+func TestRun_jsonReport(t *testing.T) {
+	api := fakeapi.NewServer()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	code, stdout, stderr := runLoad(server.URL, "--duration", "300ms", "--concurrency", "2", "--country", "DE", "--seed", "7", "--output", "json")
+	assert.Equal(t, exitOK, code, stderr)
+
+	var rep report
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &rep))
+	assert.Greater(t, rep.Requests, 0)
+	assert.Zero(t, rep.Errors)
+	assert.Greater(t, rep.Throughput, 0.0)
+	assert.Greater(t, rep.Latency.Max, 0.0)
+	assert.GreaterOrEqual(t, rep.Latency.Max, rep.Latency.P99)
+	assert.GreaterOrEqual(t, rep.Latency.P99, rep.Latency.P50)
+
+	// the accounts left over were cleaned up
+	assert.Empty(t, api.Accounts())
+}
+
+func TestRun_textReportAndMaxErrorRate(t *testing.T) {
+	api := fakeapi.NewServer()
+	api.FailWith(http.MethodGet, http.StatusInternalServerError)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	code, stdout, stderr := runLoad(server.URL, "--duration", "300ms", "--concurrency", "2", "--mix", "create=1,fetch=1", "--max-error-rate", "0.1", "--cleanup=false")
+	assert.Equal(t, exitFailed, code)
+	assert.Contains(t, stderr, "is above the maximum of 10.00%")
+
+	assert.Contains(t, stdout, "OPERATION  REQUESTS  ERRORS")
+	assert.Contains(t, stdout, "\ncreate ")
+	assert.Contains(t, stdout, "\nfetch ")
+	assert.Contains(t, stdout, "\ntotal ")
+	assert.Contains(t, stdout, "errors by kind:\n  api_500 ")
+}
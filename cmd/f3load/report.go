@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/client"
+)
+
+// results collects the outcome of every request sent during a load test
+type results struct {
+	mu        sync.Mutex
+	latencies map[operation][]time.Duration
+	errors    map[operation]map[string]int
+}
+
+func newResults() *results {
+	return &results{latencies: map[operation][]time.Duration{}, errors: map[operation]map[string]int{}}
+}
+
+// record adds the outcome of a request for op that took latency and failed with err, if it is not nil
+func (res *results) record(op operation, latency time.Duration, err error) {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+
+	res.latencies[op] = append(res.latencies[op], latency)
+	if err == nil {
+		return
+	}
+
+	if res.errors[op] == nil {
+		res.errors[op] = map[string]int{}
+	}
+
+	res.errors[op][errorKind(err)]++
+}
+
+// errorKind names the kind of err for the error breakdown, API errors are split up by status code
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case client.IsAPIError(err) && client.StatusCode(err) != 0:
+		return fmt.Sprintf("api_%d", client.StatusCode(err))
+	case client.IsAPIError(err):
+		return "api"
+	case client.IsUnavailableError(err):
+		return "unavailable"
+	case client.IsInputError(err):
+		return "input"
+	default:
+		return "internal"
+	}
+}
+
+// latencyReport summarises the latencies of requests, in milliseconds
+type latencyReport struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// operationReport summarises the requests for a single operation
+type operationReport struct {
+	Operation    operation      `json:"operation"`
+	Requests     int            `json:"requests"`
+	Errors       int            `json:"errors"`
+	Throughput   float64        `json:"throughput_rps"`
+	Latency      latencyReport  `json:"latency"`
+	ErrorsByKind map[string]int `json:"errors_by_kind,omitempty"`
+}
+
+// report summarises a load test
+type report struct {
+	DurationSeconds float64 `json:"duration_seconds"`
+	Requests        int     `json:"requests"`
+	Errors          int     `json:"errors"`
+	ErrorRate       float64 `json:"error_rate"`
+	Throughput      float64 `json:"throughput_rps"`
+	// Dropped is the number of requests not sent because every worker was busy when they were due
+	Dropped      int64             `json:"dropped,omitempty"`
+	Latency      latencyReport     `json:"latency"`
+	Operations   []operationReport `json:"operations"`
+	ErrorsByKind map[string]int    `json:"errors_by_kind,omitempty"`
+	// CleanedUp is the number of accounts created by the test that were deleted afterwards
+	CleanedUp int `json:"cleaned_up"`
+}
+
+// report summarises the results of a load test that ran for elapsed
+func (res *results) report(elapsed time.Duration, dropped int64) *report {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+
+	rep := &report{DurationSeconds: elapsed.Seconds(), Dropped: dropped, Operations: []operationReport{}}
+
+	var all []time.Duration
+	for _, op := range operations {
+		latencies := res.latencies[op]
+		if len(latencies) == 0 {
+			continue
+		}
+
+		opRep := operationReport{
+			Operation:    op,
+			Requests:     len(latencies),
+			Throughput:   float64(len(latencies)) / elapsed.Seconds(),
+			Latency:      summariseLatencies(latencies),
+			ErrorsByKind: res.errors[op],
+		}
+
+		for kind, n := range res.errors[op] {
+			opRep.Errors += n
+			if rep.ErrorsByKind == nil {
+				rep.ErrorsByKind = map[string]int{}
+			}
+
+			rep.ErrorsByKind[kind] += n
+		}
+
+		rep.Requests += opRep.Requests
+		rep.Errors += opRep.Errors
+		rep.Operations = append(rep.Operations, opRep)
+		all = append(all, latencies...)
+	}
+
+	rep.Latency = summariseLatencies(all)
+	if rep.Requests > 0 {
+		rep.ErrorRate = float64(rep.Errors) / float64(rep.Requests)
+		rep.Throughput = float64(rep.Requests) / elapsed.Seconds()
+	}
+
+	return rep
+}
+
+// summariseLatencies returns the percentiles of latencies, which it sorts
+func summariseLatencies(latencies []time.Duration) latencyReport {
+	if len(latencies) == 0 {
+		return latencyReport{}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencyReport{
+		P50: milliseconds(percentile(latencies, 50)),
+		P90: milliseconds(percentile(latencies, 90)),
+		P95: milliseconds(percentile(latencies, 95)),
+		P99: milliseconds(percentile(latencies, 99)),
+		Max: milliseconds(latencies[len(latencies)-1]),
+	}
+}
+
+// percentile returns the p-th percentile of sorted by the nearest rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// milliseconds returns d in milliseconds to the nearest microsecond
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// writeJSON writes rep to w as indented JSON
+func writeJSON(w io.Writer, rep *report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// writeText writes rep to w as a human readable summary
+func writeText(w io.Writer, rep *report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "duration:   %.1fs\n", rep.DurationSeconds)
+	fmt.Fprintf(&b, "requests:   %d (%.1f/s)\n", rep.Requests, rep.Throughput)
+	fmt.Fprintf(&b, "errors:     %d (%.2f%%)\n", rep.Errors, rep.ErrorRate*100)
+	if rep.Dropped > 0 {
+		fmt.Fprintf(&b, "dropped:    %d requests due while every worker was busy\n", rep.Dropped)
+	}
+
+	fmt.Fprintf(&b, "cleaned up: %d accounts\n\n", rep.CleanedUp)
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tERRORS\tRPS\tP50 MS\tP90 MS\tP95 MS\tP99 MS\tMAX MS")
+	for _, opRep := range rep.Operations {
+		writeLatencyRow(tw, string(opRep.Operation), opRep.Requests, opRep.Errors, opRep.Throughput, opRep.Latency)
+	}
+
+	writeLatencyRow(tw, "total", rep.Requests, rep.Errors, rep.Throughput, rep.Latency)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(rep.ErrorsByKind) > 0 {
+		b.WriteString("\nerrors by kind:\n")
+		kinds := make([]string, 0, len(rep.ErrorsByKind))
+		for kind := range rep.ErrorsByKind {
+			kinds = append(kinds, kind)
+		}
+
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Fprintf(&b, "  %-12s %d\n", kind, rep.ErrorsByKind[kind])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeLatencyRow(w io.Writer, name string, requests, errs int, throughput float64, latency latencyReport) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\n",
+		name, requests, errs, throughput, latency.P50, latency.P90, latency.P95, latency.P99, latency.Max)
+}
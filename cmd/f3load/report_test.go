@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 0, 100)
+	for idx := 1; idx <= 100; idx++ {
+		sorted = append(sorted, time.Duration(idx)*time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(sorted, 100))
+	assert.Equal(t, time.Millisecond, percentile(sorted, 0))
+	assert.Equal(t, 7*time.Millisecond, percentile(sorted[6:7], 99))
+}
+
+func TestErrorKind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "api error with status", err: client.NewAPIError(http.StatusConflict, "duplicate"), expected: "api_409"},
+		{name: "api error without status", err: client.NewAPIError(0, "bad body"), expected: "api"},
+		{name: "unavailable", err: client.NewUnavailableError("circuit open", nil), expected: "unavailable"},
+		{name: "input", err: client.NewInputError("bad ID", nil), expected: "input"},
+		{name: "timeout", err: client.NewInternalError("request failed", fmt.Errorf("wrapped: %w", context.DeadlineExceeded)), expected: "timeout"},
+		{name: "anything else", err: errors.New("boom"), expected: "internal"},
+	}
+
+	for idx, tc := range testCases {
+		assert.Equal(t, tc.expected, errorKind(tc.err), fmt.Sprintf("test case %d: %s", idx+1, tc.name))
+	}
+}
+
+func TestResults_report(t *testing.T) {
+	res := newResults()
+	for idx := 1; idx <= 10; idx++ {
+		res.record(opCreate, time.Duration(idx)*time.Millisecond, nil)
+	}
+
+	res.record(opDelete, 20*time.Millisecond, client.NewAPIError(http.StatusConflict, "stale version"))
+	res.record(opDelete, 30*time.Millisecond, nil)
+
+	rep := res.report(2*time.Second, 3)
+	assert.Equal(t, 12, rep.Requests)
+	assert.Equal(t, 1, rep.Errors)
+	assert.InDelta(t, 1.0/12, rep.ErrorRate, 1e-9)
+	assert.InDelta(t, 6.0, rep.Throughput, 1e-9)
+	assert.Equal(t, int64(3), rep.Dropped)
+	assert.Equal(t, map[string]int{"api_409": 1}, rep.ErrorsByKind)
+	assert.Equal(t, latencyReport{P50: 6, P90: 20, P95: 30, P99: 30, Max: 30}, rep.Latency)
+
+	assert.Len(t, rep.Operations, 2)
+	assert.Equal(t, operationReport{
+		Operation:  opCreate,
+		Requests:   10,
+		Throughput: 5,
+		Latency:    latencyReport{P50: 5, P90: 9, P95: 10, P99: 10, Max: 10},
+	}, rep.Operations[0])
+	assert.Equal(t, opDelete, rep.Operations[1].Operation)
+	assert.Equal(t, 1, rep.Operations[1].Errors)
+
+	var b bytes.Buffer
+	assert.NoError(t, writeText(&b, rep))
+	assert.Equal(t, `duration:   2.0s
+requests:   12 (6.0/s)
+errors:     1 (8.33%)
+dropped:    3 requests due while every worker was busy
+cleaned up: 0 accounts
+
+OPERATION  REQUESTS  ERRORS  RPS  P50 MS  P90 MS  P95 MS  P99 MS  MAX MS
+create     10        0       5.0  5.00    9.00    10.00   10.00   10.00
+delete     2         1       1.0  20.00   30.00   30.00   30.00   30.00
+total      12        1       6.0  6.00    20.00   30.00   30.00   30.00
+
+errors by kind:
+  api_409      1
+`, b.String())
+}
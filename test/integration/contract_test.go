@@ -0,0 +1,14 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/OJOMB/form3-fake-account-client/client/apitest"
+)
+
+// TestContract checks the docker-compose API against the behaviours every account API implementation must have
+func TestContract(t *testing.T) {
+	requireAPI(t)
+
+	apitest.RunContract(t, testBaseURL, apitest.WithOrganisationID(testOrgID), apitest.WithSeed(testSeed))
+}
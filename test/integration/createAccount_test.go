@@ -7,21 +7,19 @@ import (
 	"time"
 
 	"github.com/OJOMB/form3-fake-account-client/accounts"
-	"github.com/OJOMB/form3-fake-account-client/client"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateAccount_SuccessPath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	accountID, err := uuid.NewRandom()
 	assert.NoError(t, err)
 
 	account := accounts.AccountData{
 		ID:             accountID.String(),
-		OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+		OrganisationID: testOrgID,
 		Type:           "accounts",
 		Attributes: &accounts.AccountAttributes{
 			AccountClassification:   accounts.AccountClassificationPersonal,
@@ -72,15 +70,14 @@ func TestCreateAccount_SuccessPath(t *testing.T) {
 
 // TestCreateAccount_WithExistingID_FailurePath checks for expected errors when creating an account with an ID that already exists
 func TestCreateAccount_WithExistingID_FailurePath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	accountID, err := uuid.NewRandom()
 	assert.NoError(t, err)
 
 	account := accounts.AccountData{
 		ID:             accountID.String(),
-		OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+		OrganisationID: testOrgID,
 		Type:           "accounts",
 		Attributes: &accounts.AccountAttributes{
 			Country: ptrStr("GB"),
@@ -106,8 +103,7 @@ func TestCreateAccount_WithMissingRequiredFields_FailurePath(t *testing.T) {
 	const missingDataErrorMsgFormatNest3 = "api error - failed to create account, status code 400: validation failure list:\nvalidation failure list:\nvalidation failure list:\n%s in body is required"
 	const missingDataErrorMsgFormatNest2 = "api error - failed to create account, status code 400: validation failure list:\nvalidation failure list:\n%s in body is required"
 
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	testCases := []struct {
 		name             string
@@ -117,7 +113,7 @@ func TestCreateAccount_WithMissingRequiredFields_FailurePath(t *testing.T) {
 		{
 			name: "missing country",
 			accountData: accounts.AccountData{
-				OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+				OrganisationID: testOrgID,
 				Type:           "accounts",
 				Attributes:     &accounts.AccountAttributes{Name: []string{"Jane Doe"}},
 			},
@@ -126,7 +122,7 @@ func TestCreateAccount_WithMissingRequiredFields_FailurePath(t *testing.T) {
 		{
 			name: "missing name",
 			accountData: accounts.AccountData{
-				OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+				OrganisationID: testOrgID,
 				Type:           "accounts",
 				Attributes:     &accounts.AccountAttributes{Country: ptrStr("GB")},
 			},
@@ -146,7 +142,7 @@ func TestCreateAccount_WithMissingRequiredFields_FailurePath(t *testing.T) {
 		{
 			name: "missing type",
 			accountData: accounts.AccountData{
-				OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+				OrganisationID: testOrgID,
 				Attributes:     &accounts.AccountAttributes{Country: ptrStr("GB"), Name: []string{"Jane Doe"}},
 			},
 			expectedErrorMsg: fmt.Sprintf(missingDataErrorMsgFormatNest2, "type"),
@@ -5,7 +5,6 @@ import (
 	"testing"
 
 	"github.com/OJOMB/form3-fake-account-client/accounts"
-	"github.com/OJOMB/form3-fake-account-client/client"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -13,15 +12,14 @@ import (
 // TestDeleteAccount_SuccessPath tests the success path of the DeleteAccount function
 // this is done by creatiung an account and then deleting it. Verifying that we get a successful response.
 func TestDeleteAccount_SuccessPath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	accountID, err := uuid.NewRandom()
 	assert.NoError(t, err)
 
 	account := accounts.AccountData{
 		ID:             accountID.String(),
-		OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+		OrganisationID: testOrgID,
 		Type:           "accounts",
 		Attributes: &accounts.AccountAttributes{
 			Country: ptrStr("GB"),
@@ -40,8 +38,7 @@ func TestDeleteAccount_SuccessPath(t *testing.T) {
 }
 
 func TestDeleteAccount_DeleteNonExistentAccount_Failure(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	accountID, err := uuid.NewRandom()
 	assert.NoError(t, err)
@@ -52,15 +49,14 @@ func TestDeleteAccount_DeleteNonExistentAccount_Failure(t *testing.T) {
 }
 
 func TestDeleteAccount_IncorrectVersion_SuccessPath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	accountID, err := uuid.NewRandom()
 	assert.NoError(t, err)
 
 	account := accounts.AccountData{
 		ID:             accountID.String(),
-		OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+		OrganisationID: testOrgID,
 		Type:           "accounts",
 		Attributes: &accounts.AccountAttributes{
 			Country: ptrStr("GB"),
@@ -79,10 +75,9 @@ func TestDeleteAccount_IncorrectVersion_SuccessPath(t *testing.T) {
 }
 
 func TestDeleteAccount_InvalidUUID_SuccessPath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	// now we can attempt to delete an account with an invalid uuid
-	err = c.Delete(context.Background(), "invalid-uuid", 1)
+	err := c.Delete(context.Background(), "invalid-uuid", 1)
 	assert.Equal(t, "api error - failed to delete account, status code 400: id is not a valid uuid", err.Error())
 }
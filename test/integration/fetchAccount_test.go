@@ -6,21 +6,19 @@ import (
 	"testing"
 
 	"github.com/OJOMB/form3-fake-account-client/accounts"
-	"github.com/OJOMB/form3-fake-account-client/client"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestFetchAccount_SuccessPath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	accountID, err := uuid.NewRandom()
 	assert.NoError(t, err)
 
 	account := accounts.AccountData{
 		ID:             accountID.String(),
-		OrganisationID: "600b4bf3-4cae-4e1c-b382-968f86fc7489",
+		OrganisationID: testOrgID,
 		Type:           "accounts",
 		Attributes: &accounts.AccountAttributes{
 			AccountClassification:   accounts.AccountClassificationPersonal,
@@ -70,8 +68,7 @@ func TestFetchAccount_SuccessPath(t *testing.T) {
 }
 
 func TestFetchAccount_NonExistentAccount_FailurePath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	// liklehood of collision essentially zero so just use random uuid
 	accountID, err := uuid.NewRandom()
@@ -87,8 +84,7 @@ func TestFetchAccount_NonExistentAccount_FailurePath(t *testing.T) {
 }
 
 func TestFetchAccount_WithInvalidUUID_FailurePath(t *testing.T) {
-	c, err := client.NewClient(testBaseURL, nil)
-	assert.NoError(t, err)
+	c := newTestClient(t)
 
 	accountID := "not-a-uuid"
 	fetchResp, err := c.Fetch(context.Background(), accountID)
@@ -1,15 +1,182 @@
 package integration
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
 	"time"
 
 	"github.com/OJOMB/form3-fake-account-client/accounts"
+	"github.com/OJOMB/form3-fake-account-client/client"
+	"github.com/google/uuid"
 )
 
 const (
-	testBaseURL = "http://0.0.0.0:8080"
+	defaultBaseURL = "http://0.0.0.0:8080"
+	// baseURLEnvVar overrides the API the tests run against, as for f3accounts
+	baseURLEnvVar = "F3_ACCOUNTS_HOST"
+	// waitEnvVar overrides how long to wait for the API to become healthy, e.g. 2m
+	waitEnvVar = "F3_INTEGRATION_WAIT"
+
+	defaultWait     = 30 * time.Second
+	healthPollEvery = 500 * time.Millisecond
+	cleanupTimeout  = time.Minute
+)
+
+var (
+	// testBaseURL is the API the tests run against
+	testBaseURL string
+	// testOrgID is a random organisation for this run, so that runs do not see each other's accounts
+	testOrgID string
+	// testSeed seeds the accounts created by the tests, it is printed so that a run can be reproduced
+	testSeed int64
+	// skipReason is set when the API cannot be reached, every test is then skipped with it
+	skipReason string
+
+	created createdAccounts
 )
 
+// createdAccounts are the IDs of the accounts the tests tried to create, so they can be deleted after the run
+type createdAccounts struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (ca *createdAccounts) add(id string) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	ca.ids = append(ca.ids, id)
+}
+
+func (ca *createdAccounts) all() []string {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	return append([]string(nil), ca.ids...)
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+// runTests waits for the API, runs the tests and deletes the accounts they created, whether they passed or not
+func runTests(m *testing.M) int {
+	testBaseURL = os.Getenv(baseURLEnvVar)
+	if testBaseURL == "" {
+		testBaseURL = defaultBaseURL
+	}
+
+	wait := defaultWait
+	if s := os.Getenv(waitEnvVar); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "integration: invalid %s %q: %v\n", waitEnvVar, s, err)
+			return 2
+		}
+
+		wait = d
+	}
+
+	testOrgID = uuid.NewString()
+	testSeed = time.Now().UnixNano()
+
+	if err := waitUntilHealthy(testBaseURL, wait); err != nil {
+		skipReason = fmt.Sprintf("account API at %s is not reachable: %v. Start it with docker-compose up, "+
+			"point %s at a running API or give it longer to start with %s", testBaseURL, err, baseURLEnvVar, waitEnvVar)
+		fmt.Fprintf(os.Stderr, "integration: skipping every test: %s\n", skipReason)
+		return m.Run()
+	}
+
+	fmt.Fprintf(os.Stderr, "integration: running against %s as organisation %s with seed %d\n", testBaseURL, testOrgID, testSeed)
+
+	code := m.Run()
+	if err := cleanup(); err != nil {
+		fmt.Fprintf(os.Stderr, "integration: cleaning up: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+
+	return code
+}
+
+// waitUntilHealthy waits up to wait for the API at baseURL to report itself as up
+func waitUntilHealthy(baseURL string, wait time.Duration) error {
+	c, err := client.NewClient(baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+
+	return c.WaitUntilHealthy(ctx, healthPollEvery)
+}
+
+// cleanup deletes every account the tests tried to create that still exists
+func cleanup() error {
+	ids := created.all()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	c, err := client.NewClient(testBaseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	summary, err := c.DeleteMany(ctx, client.DeleteManyRequest{IDs: ids}, client.BulkOptions{})
+	if err != nil {
+		return err
+	}
+
+	if len(summary.Failed) > 0 || len(summary.Conflicted) > 0 {
+		return fmt.Errorf("%d of %d accounts could not be deleted", len(summary.Failed)+len(summary.Conflicted), len(ids))
+	}
+
+	return nil
+}
+
+// newTestClient returns a client for the API that remembers the accounts it creates so they are cleaned up.
+// The test is skipped if the API cannot be reached.
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+	requireAPI(t)
+
+	trackCreated := func(next client.Next) client.Next {
+		return func(ctx context.Context, req *client.OperationRequest) (*client.OperationResponse, error) {
+			// tracked before sending as the account may be created even if the response is lost
+			if req.Operation == client.OperationCreate && req.Account != nil {
+				created.add(req.Account.ID)
+			}
+
+			return next(ctx, req)
+		}
+	}
+
+	c, err := client.NewClient(testBaseURL, nil, client.WithMiddleware(trackCreated))
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	return c
+}
+
+// requireAPI skips the test if the API cannot be reached
+func requireAPI(t *testing.T) {
+	t.Helper()
+
+	if skipReason != "" {
+		t.Skip(skipReason)
+	}
+}
+
 func ptrStr(s string) *string {
 	return &s
 }